@@ -0,0 +1,214 @@
+package services
+
+import (
+	"akane/be-ftth/config"
+	"akane/be-ftth/models"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"gorm.io/gorm"
+)
+
+// haversineMeter menghitung jarak dua titik [lat, lng] dalam meter
+func haversineMeter(a, b [2]float64) float64 {
+	const earthRadius = 6371000.0
+	lat1, lat2 := a[0]*math.Pi/180, b[0]*math.Pi/180
+	dLat := lat2 - lat1
+	dLng := (b[1] - a[1]) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+func pathLengthMeter(path [][2]float64) float64 {
+	total := 0.0
+	for i := 1; i < len(path); i++ {
+		total += haversineMeter(path[i-1], path[i])
+	}
+	return math.Round(total*10) / 10
+}
+
+// CableSlackPercent membaca CABLE_SLACK_PERCENT (cadangan gulungan / lendutan kabel), default 5%
+func CableSlackPercent() float64 {
+	return envFloat("CABLE_SLACK_PERCENT", 5)
+}
+
+// computedLengthMeter = panjang jalur x (1 + slack%)
+func computedLengthMeter(path [][2]float64, slackPercent float64) float64 {
+	return math.Round(pathLengthMeter(path)*(1+slackPercent/100)*10) / 10
+}
+
+// ParseCableCoordinates membaca kolom Coordinates ([[lat,lng],...] atau [{"lat":..,"lng":..},...]).
+// String kosong / "[]" menghasilkan path kosong tanpa error.
+func ParseCableCoordinates(raw string) ([][2]float64, error) {
+	if raw == "" || raw == "[]" {
+		return nil, nil
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal([]byte(raw), &items); err != nil {
+		return nil, fmt.Errorf("koordinat kabel harus berupa array JSON")
+	}
+	path := make([][2]float64, 0, len(items))
+	for i, item := range items {
+		var pair []float64
+		if err := json.Unmarshal(item, &pair); err == nil && len(pair) >= 2 {
+			path = append(path, [2]float64{pair[0], pair[1]})
+			continue
+		}
+		var obj struct {
+			Lat *float64 `json:"lat"`
+			Lng *float64 `json:"lng"`
+		}
+		if err := json.Unmarshal(item, &obj); err == nil && obj.Lat != nil && obj.Lng != nil {
+			path = append(path, [2]float64{*obj.Lat, *obj.Lng})
+			continue
+		}
+		return nil, fmt.Errorf("titik ke-%d pada koordinat kabel tidak valid", i+1)
+	}
+	for i, p := range path {
+		if p[0] < -90 || p[0] > 90 || p[1] < -180 || p[1] > 180 {
+			return nil, fmt.Errorf("titik ke-%d di luar rentang lat/lng", i+1)
+		}
+	}
+	return path, nil
+}
+
+// effectiveCablePath memakai Coordinates; jika kosong dipakai garis lurus antar node (sama seperti peta)
+func effectiveCablePath(cable models.NetworkCable, source, target models.NetworkNode) [][2]float64 {
+	if path, err := ParseCableCoordinates(cable.Coordinates); err == nil && len(path) >= 2 {
+		return path
+	}
+	return [][2]float64{
+		{source.Latitude, source.Longitude},
+		{target.Latitude, target.Longitude},
+	}
+}
+
+// applyCableLength mengisi ComputedLengthMeter lalu LengthMeter efektif (manual jika ada, selain itu hasil hitung)
+func applyCableLength(cable *models.NetworkCable, source, target models.NetworkNode, slackPercent float64) {
+	cable.ComputedLengthMeter = computedLengthMeter(effectiveCablePath(*cable, source, target), slackPercent)
+	if cable.ManualLengthMeter != nil && *cable.ManualLengthMeter > 0 {
+		cable.LengthMeter = *cable.ManualLengthMeter
+	} else {
+		cable.LengthMeter = cable.ComputedLengthMeter
+	}
+}
+
+// PreserveLegacyCableLength memindahkan panjang lama yang diketik manual (belum pernah dihitung) ke ManualLengthMeter
+// sebelum panjang dihitung ulang, agar tidak tertimpa hasil hitung. Mengembalikan true jika ada yang dipindah.
+func PreserveLegacyCableLength(cable *models.NetworkCable) bool {
+	if cable.ManualLengthMeter != nil || cable.ComputedLengthMeter != 0 || cable.LengthMeter <= 0 {
+		return false
+	}
+	manual := cable.LengthMeter
+	cable.ManualLengthMeter = &manual
+	return true
+}
+
+// RefreshCableLength menghitung ulang panjang satu kabel (belum disimpan) memakai CABLE_SLACK_PERCENT
+func RefreshCableLength(cable *models.NetworkCable) error {
+	var source, target models.NetworkNode
+	if err := config.DB.First(&source, cable.SourceNodeID).Error; err != nil {
+		return fmt.Errorf("node sumber tidak ditemukan")
+	}
+	if err := config.DB.First(&target, cable.TargetNodeID).Error; err != nil {
+		return fmt.Errorf("node tujuan tidak ditemukan")
+	}
+	applyCableLength(cable, source, target, CableSlackPercent())
+	return nil
+}
+
+// CableLengthChange adalah perubahan panjang satu kabel pada hitung ulang massal
+type CableLengthChange struct {
+	CableID        int      `json:"cable_id"`
+	SourceName     string   `json:"source_name"`
+	TargetName     string   `json:"target_name"`
+	ManualMeter    *float64 `json:"manual_length_meter"`
+	OldComputed    float64  `json:"old_computed_meter"`
+	NewComputed    float64  `json:"new_computed_meter"`
+	OldLengthMeter float64  `json:"old_length_meter"`
+	NewLengthMeter float64  `json:"new_length_meter"`
+	FromPath       bool     `json:"from_path"`       // false = garis lurus karena koordinat kosong / tidak valid
+	ManualBackfill bool     `json:"manual_backfill"` // Panjang lama dipindah ke manual_length_meter
+}
+
+// CableLengthRecompute adalah ringkasan hitung ulang massal
+type CableLengthRecompute struct {
+	SlackPercent float64             `json:"slack_percent"`
+	DryRun       bool                `json:"dry_run"`
+	Total        int                 `json:"total"`
+	Changed      int                 `json:"changed"`
+	Changes      []CableLengthChange `json:"changes"`
+}
+
+// RecomputeCableLengths menghitung ulang ComputedLengthMeter semua kabel.
+// Kabel lama yang panjangnya diketik manual (belum pernah dihitung) dipindah dulu ke ManualLengthMeter agar tidak hilang.
+func RecomputeCableLengths(slackPercent float64, dryRun bool) (*CableLengthRecompute, error) {
+	var cables []models.NetworkCable
+	if err := config.DB.Preload("SourceNode").Preload("TargetNode").Order("cable_id").Find(&cables).Error; err != nil {
+		return nil, err
+	}
+
+	result := &CableLengthRecompute{SlackPercent: slackPercent, DryRun: dryRun, Total: len(cables), Changes: []CableLengthChange{}}
+	for _, cable := range cables {
+		change := CableLengthChange{
+			CableID:        cable.CableID,
+			SourceName:     cable.SourceNode.Name,
+			TargetName:     cable.TargetNode.Name,
+			OldComputed:    cable.ComputedLengthMeter,
+			OldLengthMeter: cable.LengthMeter,
+		}
+		change.ManualBackfill = PreserveLegacyCableLength(&cable)
+		path, err := ParseCableCoordinates(cable.Coordinates)
+		change.FromPath = err == nil && len(path) >= 2
+
+		applyCableLength(&cable, cable.SourceNode, cable.TargetNode, slackPercent)
+		change.ManualMeter = cable.ManualLengthMeter
+		change.NewComputed = cable.ComputedLengthMeter
+		change.NewLengthMeter = cable.LengthMeter
+		if !change.ManualBackfill && change.NewComputed == change.OldComputed && change.NewLengthMeter == change.OldLengthMeter {
+			continue
+		}
+
+		result.Changed++
+		result.Changes = append(result.Changes, change)
+		if dryRun {
+			continue
+		}
+		if err := config.DB.Model(&models.NetworkCable{}).Where("cable_id = ?", cable.CableID).Updates(map[string]interface{}{
+			"manual_length_meter":   cable.ManualLengthMeter,
+			"computed_length_meter": cable.ComputedLengthMeter,
+			"length_meter":          cable.LengthMeter,
+		}).Error; err != nil {
+			return nil, fmt.Errorf("gagal menyimpan kabel %d: %v", cable.CableID, err)
+		}
+	}
+	return result, nil
+}
+
+// RefreshNodeCableLengths menghitung ulang panjang kabel yang terhubung ke node setelah posisinya berubah.
+// Hanya kabel tanpa jalur (garis lurus antar node) yang panjangnya ikut berubah.
+func RefreshNodeCableLengths(tx *gorm.DB, nodeID int) error {
+	var cables []models.NetworkCable
+	if err := tx.Preload("SourceNode").Preload("TargetNode").
+		Where("source_node_id = ? OR target_node_id = ?", nodeID, nodeID).Find(&cables).Error; err != nil {
+		return err
+	}
+	slackPercent := CableSlackPercent()
+	for _, cable := range cables {
+		oldComputed, oldLength := cable.ComputedLengthMeter, cable.LengthMeter
+		backfill := PreserveLegacyCableLength(&cable)
+		applyCableLength(&cable, cable.SourceNode, cable.TargetNode, slackPercent)
+		if !backfill && cable.ComputedLengthMeter == oldComputed && cable.LengthMeter == oldLength {
+			continue
+		}
+		if err := tx.Model(&models.NetworkCable{}).Where("cable_id = ?", cable.CableID).Updates(map[string]interface{}{
+			"manual_length_meter":   cable.ManualLengthMeter,
+			"computed_length_meter": cable.ComputedLengthMeter,
+			"length_meter":          cable.LengthMeter,
+		}).Error; err != nil {
+			return fmt.Errorf("gagal menyimpan kabel %d: %v", cable.CableID, err)
+		}
+	}
+	return nil
+}
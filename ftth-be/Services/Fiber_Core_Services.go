@@ -0,0 +1,403 @@
+package services
+
+import (
+	"akane/be-ftth/config"
+	"akane/be-ftth/models"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+const (
+	CoreSpare  = "spare"
+	CoreActive = "active"
+	CoreBroken = "broken"
+)
+
+// Urutan warna standar TIA-598 untuk tube dan core
+var fiberColors = []string{"Biru", "Oranye", "Hijau", "Coklat", "Abu-abu", "Putih", "Merah", "Hitam", "Kuning", "Ungu", "Pink", "Tosca"}
+
+// FiberColor mengembalikan warna ke-n (mulai 1), berulang setiap 12 warna
+func FiberColor(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return fiberColors[(n-1)%len(fiberColors)]
+}
+
+// buildCableCores menyusun core 1..count dengan kode warna. Kabel <= 12 core dianggap satu tube.
+func buildCableCores(cableID, count, perTube int) []models.CableCore {
+	if perTube <= 0 {
+		perTube = 12
+	}
+	if count <= 12 {
+		perTube = count
+	}
+	cores := make([]models.CableCore, 0, count)
+	for n := 1; n <= count; n++ {
+		tube := (n-1)/perTube + 1
+		inTube := (n-1)%perTube + 1
+		cores = append(cores, models.CableCore{
+			CableID:    cableID,
+			CoreNumber: n,
+			TubeNumber: tube,
+			TubeColor:  FiberColor(tube),
+			CoreColor:  FiberColor(inTube),
+			Status:     CoreSpare,
+		})
+	}
+	return cores
+}
+
+// SetCableCores mengatur jumlah core kabel. Core baru dibuat dengan kode warna; core di atas jumlah baru
+// hanya dihapus jika tidak punya sambungan.
+func SetCableCores(cableID, count, perTube int) ([]models.CableCore, error) {
+	if count <= 0 || count > 288 {
+		return nil, fmt.Errorf("jumlah core harus 1-288")
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var cable models.NetworkCable
+		if err := tx.First(&cable, "cable_id = ?", cableID).Error; err != nil {
+			return fmt.Errorf("kabel tidak ditemukan")
+		}
+
+		var spliced int64
+		if err := tx.Model(&models.SpliceRecord{}).
+			Joins("JOIN cable_cores ON cable_cores.core_id = splice_records.core_a_id OR cable_cores.core_id = splice_records.core_b_id").
+			Where("cable_cores.cable_id = ? AND cable_cores.core_number > ?", cableID, count).
+			Count(&spliced).Error; err != nil {
+			return err
+		}
+		if spliced > 0 {
+			return fmt.Errorf("core di atas nomor %d masih punya sambungan", count)
+		}
+		if err := tx.Where("cable_id = ? AND core_number > ?", cableID, count).Delete(&models.CableCore{}).Error; err != nil {
+			return err
+		}
+
+		var existing []models.CableCore
+		if err := tx.Where("cable_id = ?", cableID).Find(&existing).Error; err != nil {
+			return err
+		}
+		have := make(map[int]bool, len(existing))
+		for _, c := range existing {
+			have[c.CoreNumber] = true
+		}
+		var missing []models.CableCore
+		for _, c := range buildCableCores(cableID, count, perTube) {
+			if !have[c.CoreNumber] {
+				missing = append(missing, c)
+			}
+		}
+		if len(missing) > 0 {
+			if err := tx.Create(&missing).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&cable).Update("core_count", count).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return GetCableCores(cableID)
+}
+
+// GetCableCores mengembalikan core kabel. Kabel yang sudah punya core_count tetapi belum punya record
+// core dibuatkan otomatis (misalnya data lama).
+func GetCableCores(cableID int) ([]models.CableCore, error) {
+	var cable models.NetworkCable
+	if err := config.DB.First(&cable, "cable_id = ?", cableID).Error; err != nil {
+		return nil, fmt.Errorf("kabel tidak ditemukan")
+	}
+
+	var cores []models.CableCore
+	if err := config.DB.Where("cable_id = ?", cableID).Order("core_number ASC").Find(&cores).Error; err != nil {
+		return nil, err
+	}
+	if len(cores) == 0 && cable.CoreCount > 0 {
+		cores = buildCableCores(cableID, cable.CoreCount, 12)
+		if err := config.DB.Create(&cores).Error; err != nil {
+			return nil, err
+		}
+	}
+	return cores, nil
+}
+
+// UpdateCableCore mengubah status / catatan satu core
+func UpdateCableCore(coreID int, status, note string) (*models.CableCore, error) {
+	if status != CoreSpare && status != CoreActive && status != CoreBroken {
+		return nil, fmt.Errorf("status harus spare, active atau broken")
+	}
+	var core models.CableCore
+	if err := config.DB.First(&core, "core_id = ?", coreID).Error; err != nil {
+		return nil, fmt.Errorf("core tidak ditemukan")
+	}
+	if err := config.DB.Model(&core).Updates(map[string]interface{}{"status": status, "note": note}).Error; err != nil {
+		return nil, err
+	}
+	return &core, nil
+}
+
+// SpliceRequest adalah data sambungan baru di sebuah closure
+type SpliceRequest struct {
+	CoreAID int      `json:"core_a_id"`
+	CoreBID int      `json:"core_b_id"`
+	LossDB  *float64 `json:"loss_db"`
+	Tray    string   `json:"tray"`
+	Note    string   `json:"note"`
+}
+
+// cableEndsAt memastikan kabel berujung di node (sambungan hanya bisa di ujung kabel)
+func cableEndsAt(tx *gorm.DB, cableID, nodeID int) (bool, error) {
+	var cable models.NetworkCable
+	if err := tx.First(&cable, "cable_id = ?", cableID).Error; err != nil {
+		return false, err
+	}
+	return cable.SourceNodeID == nodeID || cable.TargetNodeID == nodeID, nil
+}
+
+// CreateSplice mencatat sambungan dua core di closure. Setiap ujung core hanya bisa disambung sekali per closure.
+func CreateSplice(nodeID int, req SpliceRequest, executor string) (*models.SpliceRecord, error) {
+	if req.CoreAID == 0 || req.CoreBID == 0 || req.CoreAID == req.CoreBID {
+		return nil, fmt.Errorf("core_a_id dan core_b_id wajib diisi dan berbeda")
+	}
+
+	var splice models.SpliceRecord
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var node models.NetworkNode
+		if err := tx.First(&node, "node_id = ?", nodeID).Error; err != nil {
+			return fmt.Errorf("node tidak ditemukan")
+		}
+		if node.Type != models.TypeODC && node.Type != models.TypeTB && node.Type != models.TypeODP {
+			return fmt.Errorf("sambungan hanya bisa di ODC, ODP atau TB (joint closure)")
+		}
+
+		var cores []models.CableCore
+		if err := tx.Where("core_id IN ?", []int{req.CoreAID, req.CoreBID}).Find(&cores).Error; err != nil {
+			return err
+		}
+		if len(cores) != 2 {
+			return fmt.Errorf("core tidak ditemukan")
+		}
+		if cores[0].CableID == cores[1].CableID {
+			return fmt.Errorf("core %d dan %d berada di kabel yang sama", cores[0].CoreNumber, cores[1].CoreNumber)
+		}
+		for _, core := range cores {
+			ok, err := cableEndsAt(tx, core.CableID, nodeID)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return fmt.Errorf("kabel core %d tidak berujung di %s", core.CoreNumber, node.Name)
+			}
+		}
+
+		var used int64
+		if err := tx.Model(&models.SpliceRecord{}).
+			Where("node_id = ? AND (core_a_id IN ? OR core_b_id IN ?)", nodeID, []int{req.CoreAID, req.CoreBID}, []int{req.CoreAID, req.CoreBID}).
+			Count(&used).Error; err != nil {
+			return err
+		}
+		if used > 0 {
+			return fmt.Errorf("salah satu core sudah disambung di %s", node.Name)
+		}
+
+		splice = models.SpliceRecord{
+			NodeID:    nodeID,
+			CoreAID:   req.CoreAID,
+			CoreBID:   req.CoreBID,
+			LossDB:    req.LossDB,
+			Tray:      req.Tray,
+			Note:      req.Note,
+			CreatedBy: executor,
+		}
+		if err := tx.Create(&splice).Error; err != nil {
+			return err
+		}
+		// Core yang disambung berarti dipakai
+		return tx.Model(&models.CableCore{}).Where("core_id IN ? AND status = ?", []int{req.CoreAID, req.CoreBID}, CoreSpare).
+			Update("status", CoreActive).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &splice, nil
+}
+
+// GetNodeSplices mengembalikan semua sambungan di closure beserta detail core
+func GetNodeSplices(nodeID int) ([]models.SpliceRecord, error) {
+	splices := []models.SpliceRecord{}
+	err := config.DB.Preload("CoreA").Preload("CoreB").Where("node_id = ?", nodeID).
+		Order("tray ASC, splice_id ASC").Find(&splices).Error
+	return splices, err
+}
+
+// DeleteSplice menghapus catatan sambungan; core tanpa sambungan lain dikembalikan ke spare
+func DeleteSplice(spliceID int) (*models.SpliceRecord, error) {
+	var splice models.SpliceRecord
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&splice, "splice_id = ?", spliceID).Error; err != nil {
+			return fmt.Errorf("sambungan tidak ditemukan")
+		}
+		if err := tx.Delete(&splice).Error; err != nil {
+			return err
+		}
+		// Core yang tidak lagi punya sambungan di mana pun kembali menjadi spare
+		for _, coreID := range []int{splice.CoreAID, splice.CoreBID} {
+			var remaining int64
+			if err := tx.Model(&models.SpliceRecord{}).
+				Where("core_a_id = ? OR core_b_id = ?", coreID, coreID).
+				Count(&remaining).Error; err != nil {
+				return err
+			}
+			if remaining > 0 {
+				continue
+			}
+			if err := tx.Model(&models.CableCore{}).Where("core_id = ? AND status = ?", coreID, CoreActive).
+				Update("status", CoreSpare).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &splice, nil
+}
+
+// CoreTraceSegment adalah satu kabel yang dilalui fiber
+type CoreTraceSegment struct {
+	CableID    int       `json:"cable_id"`
+	CableType  string    `json:"cable_type"`
+	CoreID     int       `json:"core_id"`
+	CoreNumber int       `json:"core_number"`
+	TubeColor  string    `json:"tube_color"`
+	CoreColor  string    `json:"core_color"`
+	Status     string    `json:"status"`
+	From       TraceNode `json:"from"`
+	To         TraceNode `json:"to"`
+	LengthM    float64   `json:"length_meter"`
+	SpliceID   int       `json:"splice_id,omitempty"`   // Sambungan di node "to" menuju segmen berikutnya
+	SpliceLoss *float64  `json:"splice_loss,omitempty"` // Loss sambungan tersebut
+}
+
+// CoreTraceResult adalah jalur satu fiber dari ujung ke ujung
+type CoreTraceResult struct {
+	Segments         []CoreTraceSegment `json:"segments"`
+	TotalLengthMeter float64            `json:"total_length_meter"`
+	Start            TraceNode          `json:"start"` // Ujung fiber yang tidak tersambung lagi (port OLT/ODP/ODC)
+	End              TraceNode          `json:"end"`
+}
+
+type coreTracer struct {
+	graph   *TopologyGraph
+	cores   map[int]models.CableCore
+	splices map[[2]int]models.SpliceRecord // (node_id, core_id) -> sambungan
+}
+
+// follow berjalan dari core ke arah node "toward" sampai fiber tidak tersambung lagi
+func (t *coreTracer) follow(coreID, toward int, seen map[int]bool) []CoreTraceSegment {
+	var segments []CoreTraceSegment
+	for {
+		core := t.cores[coreID]
+		cable := t.graph.Cables[core.CableID]
+		from := cable.SourceNodeID
+		if from == toward {
+			from = cable.TargetNodeID
+		}
+		seg := CoreTraceSegment{
+			CableID: cable.CableID, CableType: cable.CableType,
+			CoreID: core.CoreID, CoreNumber: core.CoreNumber, TubeColor: core.TubeColor, CoreColor: core.CoreColor, Status: core.Status,
+			From: toTraceNode(t.graph.Nodes[from]), To: toTraceNode(t.graph.Nodes[toward]), LengthM: cable.LengthMeter,
+		}
+
+		splice, ok := t.splices[[2]int{toward, coreID}]
+		next := splice.CoreBID
+		if next == coreID {
+			next = splice.CoreAID
+		}
+		if !ok || seen[next] {
+			segments = append(segments, seg)
+			return segments
+		}
+		seg.SpliceID = splice.SpliceID
+		seg.SpliceLoss = splice.LossDB
+		segments = append(segments, seg)
+
+		seen[next] = true
+		nextCable := t.graph.Cables[t.cores[next].CableID]
+		nextToward := nextCable.TargetNodeID
+		if nextToward == toward {
+			nextToward = nextCable.SourceNodeID
+		}
+		coreID, toward = next, nextToward
+	}
+}
+
+// TraceCore menelusuri satu fiber ke dua arah lewat sambungan sampai kedua ujungnya
+func TraceCore(coreID int) (*CoreTraceResult, error) {
+	g, err := LoadTopologyGraph()
+	if err != nil {
+		return nil, err
+	}
+
+	var coreRows []models.CableCore
+	if err := config.DB.Find(&coreRows).Error; err != nil {
+		return nil, err
+	}
+	var spliceRows []models.SpliceRecord
+	if err := config.DB.Find(&spliceRows).Error; err != nil {
+		return nil, err
+	}
+	return traceCore(g, coreRows, spliceRows, coreID)
+}
+
+// traceCore adalah inti TraceCore atas graf, core dan sambungan yang sudah dimuat
+func traceCore(g *TopologyGraph, coreRows []models.CableCore, spliceRows []models.SpliceRecord, coreID int) (*CoreTraceResult, error) {
+	t := &coreTracer{graph: g, cores: make(map[int]models.CableCore, len(coreRows)), splices: make(map[[2]int]models.SpliceRecord, len(spliceRows)*2)}
+	for _, c := range coreRows {
+		if _, ok := g.Cables[c.CableID]; ok {
+			t.cores[c.CoreID] = c
+		}
+	}
+	for _, s := range spliceRows {
+		if _, ok := t.cores[s.CoreAID]; !ok {
+			continue
+		}
+		if _, ok := t.cores[s.CoreBID]; !ok {
+			continue
+		}
+		t.splices[[2]int{s.NodeID, s.CoreAID}] = s
+		t.splices[[2]int{s.NodeID, s.CoreBID}] = s
+	}
+
+	core, ok := t.cores[coreID]
+	if !ok {
+		return nil, fmt.Errorf("core tidak ditemukan")
+	}
+	cable := g.Cables[core.CableID]
+
+	seen := map[int]bool{coreID: true}
+	forward := t.follow(coreID, cable.TargetNodeID, seen)
+	backward := t.follow(coreID, cable.SourceNodeID, seen)
+
+	// backward[0] adalah core awal dilihat dari arah sebaliknya (sudah diwakili forward[0]).
+	// Segmen sisi belakang dibalik; sambungan di ujung "to"-nya adalah sambungan milik segmen sebelumnya.
+	result := &CoreTraceResult{Segments: make([]CoreTraceSegment, 0, len(backward)+len(forward)-1)}
+	for i := len(backward) - 1; i >= 1; i-- {
+		seg := backward[i]
+		seg.From, seg.To = seg.To, seg.From
+		seg.SpliceID, seg.SpliceLoss = backward[i-1].SpliceID, backward[i-1].SpliceLoss
+		result.Segments = append(result.Segments, seg)
+	}
+	result.Segments = append(result.Segments, forward...)
+
+	for _, s := range result.Segments {
+		result.TotalLengthMeter += s.LengthM
+	}
+	result.Start = result.Segments[0].From
+	result.End = result.Segments[len(result.Segments)-1].To
+	return result, nil
+}
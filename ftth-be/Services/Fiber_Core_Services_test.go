@@ -0,0 +1,70 @@
+package services
+
+import (
+	"akane/be-ftth/models"
+	"testing"
+)
+
+// coreTestGraph: OLT(1) --kabel 10--> TB(2) <--kabel 20-- ODP(3), kabel 20 sengaja digambar terbalik
+func coreTestGraph() *TopologyGraph {
+	nodes := []models.NetworkNode{
+		{NodeID: 1, Name: "OLT-1", Type: models.TypeOLT},
+		{NodeID: 2, Name: "TB-1", Type: models.TypeTB},
+		{NodeID: 3, Name: "ODP-1", Type: models.TypeODP},
+	}
+	cables := []models.NetworkCable{
+		{CableID: 10, SourceNodeID: 1, TargetNodeID: 2, CableType: "feeder", LengthMeter: 100},
+		{CableID: 20, SourceNodeID: 3, TargetNodeID: 2, CableType: "distribution", LengthMeter: 50},
+	}
+	return NewTopologyGraph(nodes, cables)
+}
+
+func TestTraceCoreFollowsSplices(t *testing.T) {
+	loss := 0.1
+	cores := []models.CableCore{
+		{CoreID: 101, CableID: 10, CoreNumber: 1, Status: CoreActive},
+		{CoreID: 102, CableID: 10, CoreNumber: 2, Status: CoreSpare},
+		{CoreID: 201, CableID: 20, CoreNumber: 1, Status: CoreActive},
+	}
+	splices := []models.SpliceRecord{{SpliceID: 7, NodeID: 2, CoreAID: 101, CoreBID: 201, LossDB: &loss}}
+
+	// Dari sisi mana pun jalurnya sama: ODP-1 -> TB-1 -> OLT-1 atau kebalikannya
+	for _, start := range []int{101, 201} {
+		result, err := traceCore(coreTestGraph(), cores, splices, start)
+		if err != nil {
+			t.Fatalf("traceCore(%d): %v", start, err)
+		}
+		if len(result.Segments) != 2 {
+			t.Fatalf("traceCore(%d): expected 2 segments, got %+v", start, result.Segments)
+		}
+		if result.TotalLengthMeter != 150 {
+			t.Fatalf("traceCore(%d): total length %.1f", start, result.TotalLengthMeter)
+		}
+		ends := map[int]bool{result.Start.NodeID: true, result.End.NodeID: true}
+		if !ends[1] || !ends[3] {
+			t.Fatalf("traceCore(%d): unexpected ends %d -> %d", start, result.Start.NodeID, result.End.NodeID)
+		}
+		first := result.Segments[0]
+		if first.To.NodeID != 2 || first.SpliceID != 7 || first.SpliceLoss == nil || *first.SpliceLoss != loss {
+			t.Fatalf("traceCore(%d): splice not reported on first segment %+v", start, first)
+		}
+		if result.Segments[1].From.NodeID != 2 || result.Segments[1].SpliceID != 0 {
+			t.Fatalf("traceCore(%d): unexpected last segment %+v", start, result.Segments[1])
+		}
+	}
+}
+
+func TestTraceCoreWithoutSplice(t *testing.T) {
+	cores := []models.CableCore{{CoreID: 102, CableID: 10, CoreNumber: 2}}
+	result, err := traceCore(coreTestGraph(), cores, nil, 102)
+	if err != nil {
+		t.Fatalf("traceCore: %v", err)
+	}
+	if len(result.Segments) != 1 || result.Start.NodeID != 1 || result.End.NodeID != 2 {
+		t.Fatalf("unexpected trace %+v", result)
+	}
+
+	if _, err := traceCore(coreTestGraph(), cores, nil, 999); err == nil {
+		t.Fatalf("expected error for unknown core")
+	}
+}
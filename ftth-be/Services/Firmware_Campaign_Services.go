@@ -0,0 +1,510 @@
+package services
+
+import (
+	"akane/be-ftth/config"
+	"akane/be-ftth/genieacs"
+	"akane/be-ftth/models"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Status device di dalam kampanye firmware
+const (
+	FirmwareQueued  = "queued"
+	FirmwarePending = "pending"
+	FirmwareDone    = "done"
+	FirmwareFault   = "fault"
+	FirmwareTimeout = "timeout"
+	FirmwareSkipped = "skipped"
+)
+
+const firmwareGenieTimeout = 2 * time.Minute
+
+// Satu kampanye hanya boleh dimajukan oleh satu goroutine (cron & endpoint start)
+var firmwareCampaignMu sync.Mutex
+
+// firmwareTargetProjection adalah parameter yang dibutuhkan untuk memilih target kampanye
+var firmwareTargetProjection = genieacs.Projection{
+	"_id",
+	"_deviceId._Manufacturer",
+	"_deviceId._ProductClass",
+	"_deviceId._SerialNumber",
+	"VirtualParameters.getSerialNumber._value",
+	"VirtualParameters.pppoeUsername._value",
+	"VirtualParameters.IPTR069._value",
+	"VirtualParameters.pppoeIP._value",
+	"InternetGatewayDevice.DeviceInfo.SoftwareVersion._value",
+}
+
+// FirmwareCampaignSummary adalah jumlah device per status dalam satu kampanye
+type FirmwareCampaignSummary struct {
+	models.FirmwareCampaign
+	Counts map[string]int64 `json:"counts"`
+	Total  int64            `json:"total"`
+}
+
+// RegisterFirmwareFile mendaftarkan firmware. Jika content tidak nil file diunggah ke GenieACS,
+// jika nil file harus sudah ada di GenieACS dengan nama yang sama.
+func RegisterFirmwareFile(file models.FirmwareFile, content io.Reader) (*models.FirmwareFile, error) {
+	if file.FileName == "" {
+		return nil, fmt.Errorf("nama file wajib diisi")
+	}
+
+	acs, err := genieacs.NewFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	meta := genieacs.FileMeta{
+		FileType:     genieacs.FileTypeFirmware,
+		OUI:          file.OUI,
+		ProductClass: file.ProductClass,
+		Version:      file.Version,
+	}
+	if content != nil {
+		if err := acs.UploadFile(ctx, file.FileName, content, meta); err != nil {
+			return nil, fmt.Errorf("gagal upload ke GenieACS: %v", err)
+		}
+	}
+
+	existing, err := acs.GetFile(ctx, file.FileName)
+	if err != nil {
+		if genieacs.IsNotFound(err) {
+			return nil, fmt.Errorf("file '%s' tidak ditemukan di GenieACS", file.FileName)
+		}
+		return nil, err
+	}
+	file.SizeBytes = existing.Length
+	if file.ProductClass == "" {
+		file.ProductClass = existing.Metadata.ProductClass
+	}
+	if file.OUI == "" {
+		file.OUI = existing.Metadata.OUI
+	}
+	if file.Version == "" {
+		file.Version = existing.Metadata.Version
+	}
+
+	// Daftar ulang file dengan nama sama cukup memperbarui metadata
+	var record models.FirmwareFile
+	if err := config.DB.Where("file_name = ?", file.FileName).First(&record).Error; err == nil {
+		file.FileID = record.FileID
+		file.CreatedAt = record.CreatedAt
+	}
+	if err := config.DB.Save(&file).Error; err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// CreateFirmwareCampaign menentukan target dari GenieACS & data pelanggan lalu menyimpan kampanye (status draft)
+func CreateFirmwareCampaign(campaign models.FirmwareCampaign) (*models.FirmwareCampaign, int, error) {
+	var file models.FirmwareFile
+	if err := config.DB.First(&file, "file_id = ?", campaign.FileID).Error; err != nil {
+		return nil, 0, fmt.Errorf("file firmware tidak ditemukan")
+	}
+
+	if campaign.TargetProductClass == "" {
+		campaign.TargetProductClass = file.ProductClass
+	}
+	// Firmware spesifik per model, jangan pernah kirim ke semua device
+	if campaign.TargetProductClass == "" {
+		return nil, 0, fmt.Errorf("target product class wajib diisi")
+	}
+	if campaign.BatchSize <= 0 {
+		campaign.BatchSize = 20
+	}
+	if campaign.BatchTimeoutMinutes <= 0 {
+		campaign.BatchTimeoutMinutes = 60
+	}
+	if campaign.FaultThresholdPercent <= 0 {
+		campaign.FaultThresholdPercent = 10
+	}
+	campaign.Status = "draft"
+
+	acs, err := genieacs.NewFromEnv()
+	if err != nil {
+		return nil, 0, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), firmwareGenieTimeout)
+	defer cancel()
+
+	targets, err := resolveFirmwareTargets(ctx, acs, campaign, file)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(targets) == 0 {
+		return nil, 0, fmt.Errorf("tidak ada device yang cocok dengan target kampanye")
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&campaign).Error; err != nil {
+			return err
+		}
+		for i := range targets {
+			targets[i].CampaignID = campaign.CampaignID
+		}
+		return tx.CreateInBatches(targets, 200).Error
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return &campaign, len(targets), nil
+}
+
+// resolveFirmwareTargets mengambil device sesuai manufacturer/product class dari GenieACS,
+// lalu menyaring berdasarkan FAT/router pelanggan jika diisi
+func resolveFirmwareTargets(ctx context.Context, acs *genieacs.Client, campaign models.FirmwareCampaign, file models.FirmwareFile) ([]models.FirmwareCampaignDevice, error) {
+	conds := []genieacs.Query{genieacs.Eq("_deviceId._ProductClass", campaign.TargetProductClass)}
+	if campaign.TargetManufacturer != "" {
+		conds = append(conds, genieacs.Eq("_deviceId._Manufacturer", campaign.TargetManufacturer))
+	}
+
+	devices, err := acs.FindDevices(ctx, genieacs.And(conds...), firmwareTargetProjection)
+	if err != nil {
+		return nil, err
+	}
+
+	query := config.DB.Model(&models.Client{})
+	filterByClient := false
+	if campaign.TargetFat != "" {
+		query = query.Where("fat = ?", campaign.TargetFat)
+		filterByClient = true
+	}
+	if campaign.TargetRouterID != "" {
+		query = query.Where("router_id = ?", campaign.TargetRouterID)
+		filterByClient = true
+	}
+	var clients []models.Client
+	if err := query.Select("client_id", "onu_sn", "pppoe_username", "ip_address").Find(&clients).Error; err != nil {
+		return nil, err
+	}
+
+	// Index pelanggan berdasarkan SN, username PPPoE dan IP
+	clientIndex := make(map[string]int)
+	for _, cl := range clients {
+		for _, key := range []string{cl.OnuSN, cl.PppoeUsername, cl.IPAddress} {
+			if key != "" && key != "0.0.0.0" {
+				clientIndex[key] = cl.ClientID
+			}
+		}
+	}
+
+	var targets []models.FirmwareCampaignDevice
+	for _, d := range devices {
+		sn := d.DeviceID.SerialNumber
+		if sn == "" {
+			sn = d.VirtualParameters.DeviceSN.Value.String()
+		}
+
+		var clientID *int
+		for _, key := range []string{sn, d.VirtualParameters.DeviceSN.Value.String(), d.VirtualParameters.PppoeUser.Value.String(), d.VirtualParameters.IPTR069.Value.String(), d.VirtualParameters.PppoeIP.Value.String()} {
+			if id, ok := clientIndex[key]; ok && key != "" {
+				cid := id
+				clientID = &cid
+				break
+			}
+		}
+		if filterByClient && clientID == nil {
+			continue
+		}
+
+		target := models.FirmwareCampaignDevice{
+			DeviceID:        d.ID,
+			ClientID:        clientID,
+			SerialNumber:    sn,
+			ProductClass:    d.DeviceID.ProductClass,
+			SoftwareVersion: d.InternetGateway.DeviceInfo.SoftwareVersion.Value.String(),
+			Status:          FirmwareQueued,
+		}
+		// Device yang sudah di versi target tidak perlu diupgrade
+		if file.Version != "" && target.SoftwareVersion == file.Version {
+			target.Status = FirmwareSkipped
+		}
+		targets = append(targets, target)
+	}
+	return targets, nil
+}
+
+// SetFirmwareCampaignStatus memindahkan status kampanye (start/resume, pause, cancel)
+func SetFirmwareCampaignStatus(id int, status, reason string) (*models.FirmwareCampaign, error) {
+	firmwareCampaignMu.Lock()
+	defer firmwareCampaignMu.Unlock()
+
+	var campaign models.FirmwareCampaign
+	if err := config.DB.First(&campaign, "campaign_id = ?", id).Error; err != nil {
+		return nil, fmt.Errorf("kampanye tidak ditemukan")
+	}
+
+	allowed := map[string][]string{
+		"running":   {"draft", "paused"},
+		"paused":    {"running"},
+		"cancelled": {"draft", "running", "paused"},
+	}
+	valid := false
+	for _, from := range allowed[status] {
+		if campaign.Status == from {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return nil, fmt.Errorf("kampanye berstatus %s tidak bisa diubah ke %s", campaign.Status, status)
+	}
+
+	now := time.Now()
+	campaign.Status = status
+	campaign.PauseReason = reason
+	if status == "running" && campaign.StartedAt == nil {
+		campaign.StartedAt = &now
+	}
+	if status == "cancelled" {
+		campaign.FinishedAt = &now
+		// Device yang belum dikirim tidak akan pernah diproses
+		config.DB.Model(&models.FirmwareCampaignDevice{}).
+			Where("campaign_id = ? AND status = ?", id, FirmwareQueued).
+			Update("status", FirmwareSkipped)
+		cancelPendingFirmwareTasks(id)
+	}
+	if err := config.DB.Save(&campaign).Error; err != nil {
+		return nil, err
+	}
+	return &campaign, nil
+}
+
+// cancelPendingFirmwareTasks menghapus task download yang sudah antre di GenieACS untuk
+// device berstatus pending. Task yang sudah selesai/fault dicatat sesuai hasilnya; jika
+// GenieACS tidak bisa dihubungi device tetap pending dan task-nya masih bisa dieksekusi
+// saat ONU inform berikutnya.
+func cancelPendingFirmwareTasks(campaignID int) {
+	var pending []models.FirmwareCampaignDevice
+	if err := config.DB.Where("campaign_id = ? AND status = ?", campaignID, FirmwarePending).Find(&pending).Error; err != nil || len(pending) == 0 {
+		return
+	}
+
+	acs, err := genieacs.NewFromEnv()
+	if err != nil {
+		log.Printf("[FIRMWARE] Kampanye %d dibatalkan, %d task tidak bisa dihapus: %v", campaignID, len(pending), err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), firmwareGenieTimeout)
+	defer cancel()
+
+	for i := range pending {
+		dev := &pending[i]
+
+		status, fault, err := acs.TaskStatus(ctx, dev.DeviceID, dev.TaskID)
+		if err != nil {
+			log.Printf("[FIRMWARE] Gagal cek task %s: %v", dev.TaskID, err)
+			continue
+		}
+
+		now := time.Now()
+		switch status {
+		case genieacs.TaskDone:
+			dev.Status = FirmwareDone
+		case genieacs.TaskFault:
+			dev.Status = FirmwareFault
+			dev.FaultCode = fault.Code
+			dev.FaultMessage = fault.Message
+		default:
+			if err := acs.DeleteTask(ctx, dev.TaskID); err != nil && !genieacs.IsNotFound(err) {
+				log.Printf("[FIRMWARE] Gagal hapus task %s: %v", dev.TaskID, err)
+				continue
+			}
+			dev.Status = FirmwareSkipped
+			dev.FaultMessage = "Dibatalkan bersama kampanye"
+		}
+		dev.FinishedAt = &now
+		config.DB.Save(dev)
+	}
+}
+
+// GetFirmwareCampaignSummary mengembalikan kampanye beserta jumlah device per status
+func GetFirmwareCampaignSummary(id int) (*FirmwareCampaignSummary, error) {
+	var campaign models.FirmwareCampaign
+	if err := config.DB.Preload("File").First(&campaign, "campaign_id = ?", id).Error; err != nil {
+		return nil, fmt.Errorf("kampanye tidak ditemukan")
+	}
+
+	type statusCount struct {
+		Status string
+		Total  int64
+	}
+	var rows []statusCount
+	if err := config.DB.Model(&models.FirmwareCampaignDevice{}).
+		Select("status, COUNT(*) AS total").
+		Where("campaign_id = ?", id).
+		Group("status").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	summary := &FirmwareCampaignSummary{FirmwareCampaign: campaign, Counts: make(map[string]int64)}
+	for _, r := range rows {
+		summary.Counts[r.Status] = r.Total
+		summary.Total += r.Total
+	}
+	return summary, nil
+}
+
+// RunFirmwareCampaignJob memajukan semua kampanye yang sedang berjalan (dipanggil scheduler)
+func RunFirmwareCampaignJob() (int, error) {
+	var campaigns []models.FirmwareCampaign
+	if err := config.DB.Where("status = ?", "running").Find(&campaigns).Error; err != nil {
+		return 0, err
+	}
+
+	processed := 0
+	for _, campaign := range campaigns {
+		n, err := AdvanceFirmwareCampaign(campaign.CampaignID)
+		if err != nil {
+			log.Printf("[FIRMWARE] Kampanye %d: %v", campaign.CampaignID, err)
+			continue
+		}
+		processed += n
+	}
+	return processed, nil
+}
+
+// AdvanceFirmwareCampaign memperbarui status batch yang sedang berjalan, mem-pause kampanye jika
+// fault melewati ambang, dan mengirim batch berikutnya saat batch sebelumnya selesai.
+// Mengembalikan jumlah device yang diperbarui atau dikirim.
+func AdvanceFirmwareCampaign(id int) (int, error) {
+	firmwareCampaignMu.Lock()
+	defer firmwareCampaignMu.Unlock()
+
+	var campaign models.FirmwareCampaign
+	if err := config.DB.Preload("File").First(&campaign, "campaign_id = ?", id).Error; err != nil {
+		return 0, fmt.Errorf("kampanye tidak ditemukan")
+	}
+	if campaign.Status != "running" {
+		return 0, nil
+	}
+	if campaign.File == nil {
+		return 0, fmt.Errorf("file firmware kampanye sudah dihapus")
+	}
+
+	acs, err := genieacs.NewFromEnv()
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), firmwareGenieTimeout)
+	defer cancel()
+
+	processed, stillPending, err := refreshFirmwareBatch(ctx, acs, campaign)
+	if err != nil {
+		return processed, err
+	}
+
+	// Evaluasi ambang fault dari device yang sudah selesai (timeout tidak dihitung: biasanya ONU offline)
+	var done, faults int64
+	config.DB.Model(&models.FirmwareCampaignDevice{}).Where("campaign_id = ? AND status = ?", id, FirmwareDone).Count(&done)
+	config.DB.Model(&models.FirmwareCampaignDevice{}).Where("campaign_id = ? AND status = ?", id, FirmwareFault).Count(&faults)
+	if finished := done + faults; finished > 0 {
+		faultPercent := float64(faults) * 100 / float64(finished)
+		if faultPercent > campaign.FaultThresholdPercent {
+			campaign.Status = "paused"
+			campaign.PauseReason = fmt.Sprintf("Fault %.1f%% (%d dari %d) melewati ambang %.1f%%", faultPercent, faults, finished, campaign.FaultThresholdPercent)
+			config.DB.Save(&campaign)
+			log.Printf("[FIRMWARE] Kampanye %d di-pause: %s", id, campaign.PauseReason)
+			return processed, nil
+		}
+	}
+
+	// Batch berikutnya hanya dikirim setelah batch sebelumnya selesai semua
+	if stillPending > 0 {
+		return processed, nil
+	}
+
+	var batch []models.FirmwareCampaignDevice
+	if err := config.DB.Where("campaign_id = ? AND status = ?", id, FirmwareQueued).
+		Order("id ASC").Limit(campaign.BatchSize).Find(&batch).Error; err != nil {
+		return processed, err
+	}
+
+	if len(batch) == 0 {
+		now := time.Now()
+		campaign.Status = "completed"
+		campaign.FinishedAt = &now
+		config.DB.Save(&campaign)
+		log.Printf("[FIRMWARE] Kampanye %d selesai", id)
+		return processed, nil
+	}
+
+	task := genieacs.Task{Name: "download", FileName: campaign.File.FileName, FileType: genieacs.FileTypeFirmware}
+	for i := range batch {
+		dev := &batch[i]
+		now := time.Now()
+		dev.SentAt = &now
+
+		result, err := acs.PushTask(ctx, dev.DeviceID, task, true)
+		if err != nil {
+			dev.Status = FirmwareFault
+			dev.FaultMessage = err.Error()
+			dev.FinishedAt = &now
+		} else {
+			dev.TaskID = result.Task.ID
+			dev.Status = FirmwarePending
+			if result.Applied {
+				dev.Status = FirmwareDone
+				dev.FinishedAt = &now
+			}
+		}
+		config.DB.Save(dev)
+		processed++
+	}
+	return processed, nil
+}
+
+// refreshFirmwareBatch memperbarui status device pending dari GenieACS dan mengembalikan
+// jumlah yang diperbarui serta jumlah yang masih pending
+func refreshFirmwareBatch(ctx context.Context, acs *genieacs.Client, campaign models.FirmwareCampaign) (int, int, error) {
+	var pending []models.FirmwareCampaignDevice
+	if err := config.DB.Where("campaign_id = ? AND status = ?", campaign.CampaignID, FirmwarePending).Find(&pending).Error; err != nil {
+		return 0, 0, err
+	}
+
+	timeout := time.Duration(campaign.BatchTimeoutMinutes) * time.Minute
+	updated, stillPending := 0, 0
+	for i := range pending {
+		dev := &pending[i]
+
+		status, fault, err := acs.TaskStatus(ctx, dev.DeviceID, dev.TaskID)
+		if err != nil {
+			return updated, len(pending) - updated, err
+		}
+
+		now := time.Now()
+		switch status {
+		case genieacs.TaskDone:
+			dev.Status = FirmwareDone
+		case genieacs.TaskFault:
+			dev.Status = FirmwareFault
+			dev.FaultCode = fault.Code
+			dev.FaultMessage = fault.Message
+		default:
+			if dev.SentAt == nil || now.Sub(*dev.SentAt) < timeout {
+				stillPending++
+				continue
+			}
+			// ONU tidak inform dalam batas waktu: batalkan task agar tidak dieksekusi belakangan
+			if err := acs.DeleteTask(ctx, dev.TaskID); err != nil && !genieacs.IsNotFound(err) {
+				log.Printf("[FIRMWARE] Gagal hapus task %s: %v", dev.TaskID, err)
+			}
+			dev.Status = FirmwareTimeout
+		}
+		dev.FinishedAt = &now
+		config.DB.Save(dev)
+		updated++
+	}
+	return updated, stillPending, nil
+}
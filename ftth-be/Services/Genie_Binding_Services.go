@@ -0,0 +1,233 @@
+package services
+
+import (
+	"akane/be-ftth/config"
+	"akane/be-ftth/genieacs"
+	"akane/be-ftth/models"
+	"fmt"
+	"time"
+)
+
+// Sumber binding pelanggan <-> device GenieACS
+const (
+	BindViaSN     = "sn"
+	BindViaPPPoE  = "pppoe"
+	BindViaManual = "manual"
+)
+
+// BindingCandidate adalah device yang mungkin milik pelanggan tetapi belum cukup yakin untuk diikat otomatis
+type BindingCandidate struct {
+	DeviceID string `json:"device_id"`
+	DeviceSN string `json:"device_sn"`
+	Reason   string `json:"reason"` // sn, pppoe, ip
+}
+
+// AmbiguousBinding adalah pelanggan dengan lebih dari satu kandidat atau hanya cocok lewat IP
+type AmbiguousBinding struct {
+	ClientID   int                `json:"client_id"`
+	ClientName string             `json:"client_name"`
+	OnuSN      string             `json:"onu_sn"`
+	Candidates []BindingCandidate `json:"candidates"`
+}
+
+// BindingReview adalah ringkasan kondisi binding untuk ditinjau admin
+type BindingReview struct {
+	BoundClients     int                   `json:"bound_clients"`
+	UnboundClients   []models.Client       `json:"unbound_clients"`
+	UnmatchedDevices []genieacs.DeviceInfo `json:"unmatched_devices"`
+	Ambiguous        []AmbiguousBinding    `json:"ambiguous"`
+	StaleBindings    []models.Client       `json:"stale_bindings"` // Device yang terikat sudah tidak ada di GenieACS
+}
+
+// deviceIndex mengelompokkan device berdasarkan SN, username PPPoE dan IP untuk pencocokan O(1)
+type deviceIndex struct {
+	byID    map[string]genieacs.DeviceInfo
+	bySN    map[string][]string
+	byPPPoE map[string][]string
+	byIP    map[string][]string
+}
+
+func newDeviceIndex(devices []genieacs.DeviceInfo) deviceIndex {
+	idx := deviceIndex{
+		byID:    make(map[string]genieacs.DeviceInfo, len(devices)),
+		bySN:    make(map[string][]string),
+		byPPPoE: make(map[string][]string),
+		byIP:    make(map[string][]string),
+	}
+	for _, d := range devices {
+		idx.byID[d.DeviceID] = d
+		if d.DeviceSN != "" {
+			idx.bySN[d.DeviceSN] = append(idx.bySN[d.DeviceSN], d.DeviceID)
+		}
+		if d.PppoeUser != "" {
+			idx.byPPPoE[d.PppoeUser] = append(idx.byPPPoE[d.PppoeUser], d.DeviceID)
+		}
+		if d.IPAddress != "" && d.IPAddress != "0.0.0.0" {
+			idx.byIP[d.IPAddress] = append(idx.byIP[d.IPAddress], d.DeviceID)
+		}
+	}
+	return idx
+}
+
+// matchClient mengembalikan device yang pasti milik pelanggan (SN atau PPPoE tunggal) atau daftar kandidat.
+// Kecocokan IP saja tidak pernah diikat otomatis karena IP PPPoE bisa berpindah antar pelanggan.
+func (idx deviceIndex) matchClient(client models.Client) (string, string, []BindingCandidate) {
+	if ids := idx.bySN[client.OnuSN]; client.OnuSN != "" && len(ids) == 1 {
+		return ids[0], BindViaSN, nil
+	}
+	if ids := idx.byPPPoE[client.PppoeUsername]; client.PppoeUsername != "" && len(ids) == 1 {
+		return ids[0], BindViaPPPoE, nil
+	}
+
+	var candidates []BindingCandidate
+	seen := make(map[string]bool)
+	add := func(ids []string, reason string) {
+		for _, id := range ids {
+			if !seen[id] {
+				seen[id] = true
+				candidates = append(candidates, BindingCandidate{DeviceID: id, DeviceSN: idx.byID[id].DeviceSN, Reason: reason})
+			}
+		}
+	}
+	if client.OnuSN != "" {
+		add(idx.bySN[client.OnuSN], "sn")
+	}
+	if client.PppoeUsername != "" {
+		add(idx.byPPPoE[client.PppoeUsername], "pppoe")
+	}
+	if client.IPAddress != "" && client.IPAddress != "0.0.0.0" {
+		add(idx.byIP[client.IPAddress], "ip")
+	}
+	return "", "", candidates
+}
+
+// AutoBindClients mengikat pelanggan yang belum terikat ke device yang cocok secara pasti.
+// Device yang sudah terikat ke pelanggan lain tidak akan diikat ulang.
+func AutoBindClients(clients []models.Client, devices []genieacs.DeviceInfo) (int, error) {
+	idx := newDeviceIndex(devices)
+
+	taken := make(map[string]bool)
+	for _, cl := range clients {
+		if cl.GenieDeviceID != "" {
+			taken[cl.GenieDeviceID] = true
+		}
+	}
+
+	bound := 0
+	for i := range clients {
+		cl := &clients[i]
+		if cl.GenieDeviceID != "" {
+			continue
+		}
+		deviceID, via, _ := idx.matchClient(*cl)
+		if deviceID == "" || taken[deviceID] {
+			continue
+		}
+		if err := saveClientBinding(cl, deviceID, via); err != nil {
+			return bound, err
+		}
+		taken[deviceID] = true
+		bound++
+	}
+	return bound, nil
+}
+
+// BindClientDevice mengikat pelanggan ke device secara manual (hasil review admin)
+func BindClientDevice(clientID int, deviceID string) (*models.Client, error) {
+	var client models.Client
+	if err := config.DB.First(&client, "client_id = ?", clientID).Error; err != nil {
+		return nil, fmt.Errorf("pelanggan tidak ditemukan")
+	}
+
+	var other models.Client
+	if err := config.DB.Where("genie_device_id = ? AND client_id <> ?", deviceID, clientID).First(&other).Error; err == nil {
+		return nil, fmt.Errorf("device sudah terikat ke pelanggan %s", other.Name)
+	}
+
+	if err := saveClientBinding(&client, deviceID, BindViaManual); err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
+// UnbindClientDevice melepas binding pelanggan (misal ONU diganti)
+func UnbindClientDevice(clientID int) (*models.Client, error) {
+	var client models.Client
+	if err := config.DB.First(&client, "client_id = ?", clientID).Error; err != nil {
+		return nil, fmt.Errorf("pelanggan tidak ditemukan")
+	}
+	client.GenieDeviceID = ""
+	client.GenieBindVia = ""
+	client.GenieBoundAt = nil
+	if err := config.DB.Model(&client).Select("genie_device_id", "genie_bind_via", "genie_bound_at").Updates(&client).Error; err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
+func saveClientBinding(client *models.Client, deviceID, via string) error {
+	now := time.Now()
+	client.GenieDeviceID = deviceID
+	client.GenieBindVia = via
+	client.GenieBoundAt = &now
+	return config.DB.Model(client).Select("genie_device_id", "genie_bind_via", "genie_bound_at").Updates(client).Error
+}
+
+// ReviewGenieBindings membandingkan pelanggan dengan device GenieACS untuk ditinjau admin
+func ReviewGenieBindings(devices []genieacs.DeviceInfo) (*BindingReview, error) {
+	var clients []models.Client
+	if err := config.DB.Select("client_id", "name", "onu_sn", "pppoe_username", "ip_address", "fat", "genie_device_id", "genie_bind_via", "genie_bound_at").
+		Find(&clients).Error; err != nil {
+		return nil, err
+	}
+
+	idx := newDeviceIndex(devices)
+	review := &BindingReview{
+		UnboundClients:   []models.Client{},
+		UnmatchedDevices: []genieacs.DeviceInfo{},
+		Ambiguous:        []AmbiguousBinding{},
+		StaleBindings:    []models.Client{},
+	}
+
+	claimed := make(map[string]bool)
+	for _, cl := range clients {
+		if cl.GenieDeviceID == "" {
+			continue
+		}
+		claimed[cl.GenieDeviceID] = true
+		if _, ok := idx.byID[cl.GenieDeviceID]; ok {
+			review.BoundClients++
+		} else {
+			review.StaleBindings = append(review.StaleBindings, cl)
+		}
+	}
+
+	for _, cl := range clients {
+		if cl.GenieDeviceID != "" {
+			continue
+		}
+		deviceID, _, candidates := idx.matchClient(cl)
+		switch {
+		case deviceID != "" && claimed[deviceID]:
+			// Cocok pasti tetapi device sudah dipakai pelanggan lain: perlu ditinjau
+			review.Ambiguous = append(review.Ambiguous, AmbiguousBinding{
+				ClientID: cl.ClientID, ClientName: cl.Name, OnuSN: cl.OnuSN,
+				Candidates: []BindingCandidate{{DeviceID: deviceID, DeviceSN: idx.byID[deviceID].DeviceSN, Reason: "sudah terikat ke pelanggan lain"}},
+			})
+		case deviceID != "":
+			// Akan diikat otomatis pada sinkronisasi berikutnya
+			review.UnboundClients = append(review.UnboundClients, cl)
+		case len(candidates) > 0:
+			review.Ambiguous = append(review.Ambiguous, AmbiguousBinding{ClientID: cl.ClientID, ClientName: cl.Name, OnuSN: cl.OnuSN, Candidates: candidates})
+		default:
+			review.UnboundClients = append(review.UnboundClients, cl)
+		}
+	}
+
+	for _, d := range devices {
+		if !claimed[d.DeviceID] {
+			review.UnmatchedDevices = append(review.UnmatchedDevices, d)
+		}
+	}
+	return review, nil
+}
@@ -0,0 +1,295 @@
+package services
+
+import (
+	"akane/be-ftth/config"
+	"akane/be-ftth/models"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// JobDefinition mendaftarkan satu job ke scheduler. Run mengembalikan jumlah item yang diproses.
+type JobDefinition struct {
+	Name            string
+	Description     string
+	DefaultSchedule string
+	Run             func() (int, error)
+}
+
+// JobInfo adalah gabungan konfigurasi job di DB dengan status runtime scheduler
+type JobInfo struct {
+	models.ScheduledJob
+	Running bool           `json:"running"`
+	NextRun *time.Time     `json:"next_run"`
+	LastRun *models.JobRun `json:"last_run"`
+}
+
+// Daftar job yang dikenal aplikasi. Jadwal default hanya dipakai saat baris di DB belum ada.
+var jobDefinitions = []JobDefinition{
+	{Name: "traffic_sync", Description: "Sinkronisasi traffic interface dari Mikrotik", DefaultSchedule: "@hourly", Run: RunTrafficSyncJob},
+	{Name: "ping_check", Description: "Ping check interface & alert router down", DefaultSchedule: "@every 30m", Run: RunPingCheckJob},
+	{Name: "genieacs_sync", Description: "Sinkronisasi RX Power ONU dari GenieACS", DefaultSchedule: "@every 1h", Run: RunGenieACSSyncJob},
+	{Name: "router_health", Description: "Pengambilan CPU, memory, suhu & voltase router", DefaultSchedule: "@every 5m", Run: RunRouterHealthJob},
+	{Name: "rx_power_analysis", Description: "Analisis RX power ONU lemah/menurun & alert per FAT", DefaultSchedule: "15 * * * *", Run: RunRxPowerAnalysisJob},
+	{Name: "onu_status", Description: "Deteksi ONU offline dari _lastInform & alert gangguan massal", DefaultSchedule: "@every 5m", Run: RunOnuStatusJob},
+	{Name: "lan_host_snapshot", Description: "Snapshot perangkat yang terhubung ke ONU pelanggan", DefaultSchedule: "@every 30m", Run: RunLanHostSnapshotJob},
+	{Name: "firmware_campaign", Description: "Memajukan batch kampanye upgrade firmware ONU", DefaultSchedule: "@every 5m", Run: RunFirmwareCampaignJob},
+	{Name: "topology_mapping", Description: "Membuat pemetaan ODP baru dari jalur kabel di peta", DefaultSchedule: "@daily", Run: RunTopologyMappingJob},
+}
+
+// cronParser menerima format 5 field standar dan descriptor (@hourly, @every 30m)
+var cronParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+var (
+	schedulerMu  sync.Mutex
+	scheduler    *cron.Cron
+	jobEntries   = make(map[string]cron.EntryID)
+	jobRunning   = make(map[string]bool)
+	jobDefByName = make(map[string]JobDefinition)
+)
+
+// StartScheduler menyiapkan tabel job, mendaftarkan job yang aktif, lalu menjalankan cron
+func StartScheduler() error {
+	schedulerMu.Lock()
+	defer schedulerMu.Unlock()
+
+	scheduler = cron.New(cron.WithParser(cronParser))
+
+	// Run yang masih "running" saat service mati tidak akan pernah selesai
+	now := time.Now()
+	config.DB.Model(&models.JobRun{}).Where("status = ?", "running").
+		Updates(map[string]interface{}{"status": "interrupted", "finished_at": now, "error_message": "service dihentikan saat job berjalan"})
+
+	for _, def := range jobDefinitions {
+		jobDefByName[def.Name] = def
+
+		job := models.ScheduledJob{
+			JobName:     def.Name,
+			Description: def.Description,
+			Schedule:    def.DefaultSchedule,
+			Enabled:     true,
+		}
+		if err := config.DB.Where(models.ScheduledJob{JobName: def.Name}).FirstOrCreate(&job).Error; err != nil {
+			return fmt.Errorf("gagal menyiapkan job %s: %v", def.Name, err)
+		}
+
+		if job.Enabled {
+			if err := registerJobLocked(def, job.Schedule); err != nil {
+				log.Printf("[SCHEDULER] Jadwal job %s tidak valid (%s), pakai default: %v", def.Name, job.Schedule, err)
+				if err := registerJobLocked(def, def.DefaultSchedule); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	scheduler.Start()
+	log.Printf("[SCHEDULER] %d job terdaftar", len(jobEntries))
+	return nil
+}
+
+// StopScheduler menghentikan cron dan menunggu job yang sedang berjalan
+func StopScheduler() {
+	schedulerMu.Lock()
+	s := scheduler
+	schedulerMu.Unlock()
+	if s != nil {
+		<-s.Stop().Done()
+	}
+}
+
+func registerJobLocked(def JobDefinition, schedule string) error {
+	if id, ok := jobEntries[def.Name]; ok {
+		scheduler.Remove(id)
+		delete(jobEntries, def.Name)
+	}
+
+	id, err := scheduler.AddFunc(schedule, func() {
+		log.Printf("[CRON] Running %s...", def.Name)
+		if _, err := executeJob(def, "cron", "System/Cron"); err != nil {
+			log.Printf("[CRON] %s: %v", def.Name, err)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	jobEntries[def.Name] = id
+	return nil
+}
+
+// ValidateSchedule memastikan ekspresi cron bisa diparse sebelum disimpan
+func ValidateSchedule(schedule string) error {
+	_, err := cronParser.Parse(schedule)
+	return err
+}
+
+// UpdateJobSchedule mengubah jadwal dan/atau status enable job lalu langsung menerapkannya ke cron
+func UpdateJobSchedule(name string, schedule *string, enabled *bool) (*models.ScheduledJob, error) {
+	schedulerMu.Lock()
+	defer schedulerMu.Unlock()
+
+	def, ok := jobDefByName[name]
+	if !ok {
+		return nil, fmt.Errorf("job '%s' tidak dikenal", name)
+	}
+
+	var job models.ScheduledJob
+	if err := config.DB.First(&job, "job_name = ?", name).Error; err != nil {
+		return nil, fmt.Errorf("job '%s' tidak ditemukan", name)
+	}
+
+	if schedule != nil {
+		if err := ValidateSchedule(*schedule); err != nil {
+			return nil, fmt.Errorf("format jadwal tidak valid: %v", err)
+		}
+		job.Schedule = *schedule
+	}
+	if enabled != nil {
+		job.Enabled = *enabled
+	}
+
+	if err := config.DB.Save(&job).Error; err != nil {
+		return nil, err
+	}
+
+	if job.Enabled {
+		if err := registerJobLocked(def, job.Schedule); err != nil {
+			return nil, err
+		}
+	} else if id, ok := jobEntries[name]; ok {
+		scheduler.Remove(id)
+		delete(jobEntries, name)
+	}
+
+	return &job, nil
+}
+
+// ListJobs mengembalikan semua job beserta jadwal berikutnya dan run terakhir
+func ListJobs() ([]JobInfo, error) {
+	var jobs []models.ScheduledJob
+	if err := config.DB.Order("job_name ASC").Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+
+	schedulerMu.Lock()
+	defer schedulerMu.Unlock()
+
+	infos := make([]JobInfo, 0, len(jobs))
+	for _, job := range jobs {
+		info := JobInfo{ScheduledJob: job, Running: jobRunning[job.JobName]}
+
+		if id, ok := jobEntries[job.JobName]; ok && scheduler != nil {
+			next := scheduler.Entry(id).Next
+			if !next.IsZero() {
+				info.NextRun = &next
+			}
+		}
+
+		var last models.JobRun
+		if err := config.DB.Where("job_name = ?", job.JobName).Order("run_id DESC").First(&last).Error; err == nil {
+			info.LastRun = &last
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// TriggerJob menjalankan job di background dan langsung mengembalikan record run-nya
+func TriggerJob(name, executor string) (*models.JobRun, error) {
+	def, run, err := startJobRun(name, "manual", executor)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := *run
+	go finishJobRun(def, run)
+	return &snapshot, nil
+}
+
+// RunJobAndWait menjalankan job dan menunggu sampai selesai (untuk endpoint sync-now lama)
+func RunJobAndWait(name, executor string) (*models.JobRun, error) {
+	def, ok := lookupJob(name)
+	if !ok {
+		return nil, fmt.Errorf("job '%s' tidak dikenal", name)
+	}
+	return executeJob(def, "manual", executor)
+}
+
+func lookupJob(name string) (JobDefinition, bool) {
+	schedulerMu.Lock()
+	defer schedulerMu.Unlock()
+	def, ok := jobDefByName[name]
+	return def, ok
+}
+
+func executeJob(def JobDefinition, trigger, executor string) (*models.JobRun, error) {
+	_, run, err := startJobRun(def.Name, trigger, executor)
+	if err != nil {
+		return nil, err
+	}
+	return finishJobRun(def, run)
+}
+
+// startJobRun menandai job sedang berjalan (menolak eksekusi bertumpuk) dan membuat record run
+func startJobRun(name, trigger, executor string) (JobDefinition, *models.JobRun, error) {
+	schedulerMu.Lock()
+	def, ok := jobDefByName[name]
+	if !ok {
+		schedulerMu.Unlock()
+		return def, nil, fmt.Errorf("job '%s' tidak dikenal", name)
+	}
+	if jobRunning[name] {
+		schedulerMu.Unlock()
+		return def, nil, fmt.Errorf("job '%s' masih berjalan", name)
+	}
+	jobRunning[name] = true
+	schedulerMu.Unlock()
+
+	run := &models.JobRun{
+		JobName:     name,
+		TriggeredBy: trigger,
+		Executor:    executor,
+		Status:      "running",
+		StartedAt:   time.Now(),
+	}
+	if err := config.DB.Create(run).Error; err != nil {
+		log.Printf("[SCHEDULER] Gagal mencatat run %s: %v", name, err)
+	}
+	return def, run, nil
+}
+
+func finishJobRun(def JobDefinition, run *models.JobRun) (*models.JobRun, error) {
+	defer func() {
+		schedulerMu.Lock()
+		jobRunning[def.Name] = false
+		schedulerMu.Unlock()
+	}()
+
+	items, err := runJobSafely(def)
+	recordJobStat(def.Name, run.StartedAt, err)
+
+	finished := time.Now()
+	run.FinishedAt = &finished
+	run.ItemsProcessed = items
+	run.Status = "success"
+	if err != nil {
+		run.Status = "failed"
+		run.ErrorMessage = err.Error()
+	}
+	if run.RunID != 0 {
+		config.DB.Save(run)
+	}
+	return run, err
+}
+
+// runJobSafely memastikan panic di dalam job tercatat sebagai run gagal, bukan mematikan service
+func runJobSafely(def JobDefinition) (items int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return def.Run()
+}
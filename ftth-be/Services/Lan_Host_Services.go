@@ -0,0 +1,124 @@
+package services
+
+import (
+	"akane/be-ftth/config"
+	"akane/be-ftth/genieacs"
+	"akane/be-ftth/models"
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+// RunLanHostSnapshotJob menyimpan snapshot perangkat yang terhubung ke setiap ONU pelanggan yang sudah terikat.
+// Tabel host semua device diambil dalam satu request ke GenieACS.
+func RunLanHostSnapshotJob() (int, error) {
+	acs, err := genieacs.NewFromEnv()
+	if errors.Is(err, genieacs.ErrNotConfigured) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	var clients []models.Client
+	if err := config.DB.Select("client_id", "genie_device_id").Where("genie_device_id <> ''").Find(&clients).Error; err != nil {
+		return 0, err
+	}
+	if len(clients) == 0 {
+		return 0, nil
+	}
+
+	hostsByDevice, err := acs.ListConnectedHosts(context.Background())
+	if err != nil {
+		return 0, err
+	}
+
+	saved := 0
+	for _, cl := range clients {
+		hosts, ok := hostsByDevice[cl.GenieDeviceID]
+		if !ok {
+			continue
+		}
+		if err := saveLanHostSnapshot(cl, hosts); err != nil {
+			log.Printf("[JOB-LANHOST] Gagal menyimpan snapshot client %d: %v", cl.ClientID, err)
+			continue
+		}
+		saved++
+	}
+
+	cleanupLanHostSnapshots()
+	return saved, nil
+}
+
+func saveLanHostSnapshot(client models.Client, hosts []genieacs.HostDevice) error {
+	snapshot := models.LanHostSnapshot{ClientID: client.ClientID, DeviceID: client.GenieDeviceID}
+	for _, h := range hosts {
+		// Tabel Hosts juga menyimpan perangkat yang sudah putus, hanya yang aktif yang dihitung
+		if !h.IsActive() {
+			continue
+		}
+		iface := h.InterfaceType()
+		snapshot.Hosts = append(snapshot.Hosts, models.LanHostEntry{
+			ClientID:        client.ClientID,
+			MACAddress:      h.MACAddress,
+			IPAddress:       h.IPAddress,
+			HostName:        h.HostName,
+			Interface:       iface,
+			Layer1Interface: h.Layer1Interface,
+		})
+		switch iface {
+		case genieacs.HostInterfaceWifi:
+			snapshot.WifiHosts++
+		case genieacs.HostInterfaceLAN:
+			snapshot.LanHosts++
+		}
+	}
+	snapshot.TotalHosts = len(snapshot.Hosts)
+
+	// Entry host ikut tersimpan lewat asosiasi Hosts dalam satu transaksi
+	return config.DB.Create(&snapshot).Error
+}
+
+// cleanupLanHostSnapshots menghapus snapshot yang lebih tua dari LAN_HOST_RETENTION_DAYS (default 30 hari)
+func cleanupLanHostSnapshots() {
+	cutoff := time.Now().AddDate(0, 0, -envInt("LAN_HOST_RETENTION_DAYS", 30))
+	sub := config.DB.Model(&models.LanHostSnapshot{}).Select("snapshot_id").Where("recorded_at < ?", cutoff)
+	if err := config.DB.Where("snapshot_id IN (?)", sub).Delete(&models.LanHostEntry{}).Error; err != nil {
+		log.Printf("[JOB-LANHOST] Gagal membersihkan host lama: %v", err)
+		return
+	}
+	if err := config.DB.Where("recorded_at < ?", cutoff).Delete(&models.LanHostSnapshot{}).Error; err != nil {
+		log.Printf("[JOB-LANHOST] Gagal membersihkan snapshot lama: %v", err)
+	}
+}
+
+// LanHostTrend adalah riwayat jumlah perangkat pelanggan dalam rentang waktu
+type LanHostTrend struct {
+	From      time.Time                `json:"from"`
+	To        time.Time                `json:"to"`
+	Peak      *models.LanHostSnapshot  `json:"peak"` // Snapshot dengan perangkat terbanyak
+	Average   float64                  `json:"average"`
+	Snapshots []models.LanHostSnapshot `json:"snapshots"`
+}
+
+// GetLanHostTrend mengembalikan snapshot (tanpa detail host) pelanggan dalam rentang waktu
+func GetLanHostTrend(clientID int, from, to time.Time) (*LanHostTrend, error) {
+	trend := &LanHostTrend{From: from, To: to, Snapshots: []models.LanHostSnapshot{}}
+	if err := config.DB.Where("client_id = ? AND recorded_at BETWEEN ? AND ?", clientID, from, to).
+		Order("recorded_at ASC").Find(&trend.Snapshots).Error; err != nil {
+		return nil, err
+	}
+
+	total := 0
+	for i := range trend.Snapshots {
+		s := &trend.Snapshots[i]
+		total += s.TotalHosts
+		if trend.Peak == nil || s.TotalHosts > trend.Peak.TotalHosts {
+			trend.Peak = s
+		}
+	}
+	if len(trend.Snapshots) > 0 {
+		trend.Average = float64(total) / float64(len(trend.Snapshots))
+	}
+	return trend, nil
+}
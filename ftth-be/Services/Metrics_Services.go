@@ -0,0 +1,291 @@
+package services
+
+import (
+	"akane/be-ftth/config"
+	"akane/be-ftth/models"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JobStat menyimpan statistik eksekusi satu cron job (in-memory, reset saat restart)
+type JobStat struct {
+	Runs         int64     `json:"runs"`
+	Errors       int64     `json:"errors"`
+	LastDuration float64   `json:"last_duration_seconds"`
+	LastRun      time.Time `json:"last_run"`
+	LastSuccess  time.Time `json:"last_success"`
+	LastError    string    `json:"last_error"`
+}
+
+// PingStat adalah hasil ping terakhir satu interface
+type PingStat struct {
+	RouterName    string
+	InterfaceName string
+	PacketLoss    int
+	AvgRtt        int
+	Failed        bool
+}
+
+var (
+	metricsMu   sync.RWMutex
+	jobStats    = make(map[string]*JobStat)
+	pingStats   = make(map[int]PingStat)  // key: InterfaceID
+	routerState = make(map[string]bool)   // key: RouterID, true = bisa dihubungi
+	routerNames = make(map[string]string) // key: RouterID
+)
+
+// recordJobStat mencatat durasi dan error satu eksekusi job untuk /metrics
+func recordJobStat(name string, start time.Time, err error) {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+
+	stat, ok := jobStats[name]
+	if !ok {
+		stat = &JobStat{}
+		jobStats[name] = stat
+	}
+	stat.Runs++
+	stat.LastDuration = time.Since(start).Seconds()
+	stat.LastRun = start
+	if err != nil {
+		stat.Errors++
+		stat.LastError = err.Error()
+	} else {
+		stat.LastSuccess = time.Now()
+		stat.LastError = ""
+	}
+}
+
+func recordPingResult(interfaceID int, stat PingStat) {
+	metricsMu.Lock()
+	pingStats[interfaceID] = stat
+	metricsMu.Unlock()
+}
+
+func recordRouterReachable(routerID, routerName string, up bool) {
+	metricsMu.Lock()
+	routerState[routerID] = up
+	routerNames[routerID] = routerName
+	metricsMu.Unlock()
+}
+
+// ==========================================
+// PROMETHEUS TEXT EXPOSITION
+// ==========================================
+
+type promWriter struct {
+	sb strings.Builder
+}
+
+func (w *promWriter) header(name, help, kind string) {
+	fmt.Fprintf(&w.sb, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// sample menulis satu baris metrik. labels berupa pasangan key, value, key, value, ...
+func (w *promWriter) sample(name string, value float64, labels ...string) {
+	w.sb.WriteString(name)
+	if len(labels) > 0 {
+		w.sb.WriteString("{")
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				w.sb.WriteString(",")
+			}
+			fmt.Fprintf(&w.sb, "%s=\"%s\"", labels[i], escapeLabel(labels[i+1]))
+		}
+		w.sb.WriteString("}")
+	}
+	w.sb.WriteString(" ")
+	w.sb.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	w.sb.WriteString("\n")
+}
+
+func escapeLabel(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, `"`, `\"`)
+	return strings.ReplaceAll(v, "\n", `\n`)
+}
+
+// RenderPrometheusMetrics menyusun seluruh metrik dalam format text Prometheus
+func RenderPrometheusMetrics() (string, error) {
+	w := &promWriter{}
+
+	if err := writeInterfaceTrafficMetrics(w); err != nil {
+		return "", err
+	}
+	writePingAndRouterMetrics(w)
+	if err := writeClientMetrics(w); err != nil {
+		return "", err
+	}
+	writeJobMetrics(w)
+
+	return w.sb.String(), nil
+}
+
+func writeInterfaceTrafficMetrics(w *promWriter) error {
+	type latestTraffic struct {
+		InterfaceID   int
+		DownloadSpeed float64
+		UploadSpeed   float64
+	}
+	var rows []latestTraffic
+	if err := config.DB.Raw(`
+		SELECT t.interface_id, t.download_speed, t.upload_speed
+		FROM interface_traffics t
+		INNER JOIN (
+			SELECT interface_id, MAX(traffic_id) AS traffic_id
+			FROM interface_traffics WHERE is_deleted = 0
+			GROUP BY interface_id
+		) latest ON latest.traffic_id = t.traffic_id
+	`).Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	var interfaces []models.InterfaceMonitoring
+	if err := config.DB.Preload("Router").Where("is_deleted = ?", 0).Find(&interfaces).Error; err != nil {
+		return err
+	}
+	ifaceMap := make(map[int]models.InterfaceMonitoring)
+	for _, iface := range interfaces {
+		ifaceMap[iface.InterfaceID] = iface
+	}
+
+	w.header("ftth_interface_rx_bits_per_second", "Download (rx) terakhir dari sinkronisasi traffic.", "gauge")
+	for _, r := range rows {
+		if iface, ok := ifaceMap[r.InterfaceID]; ok {
+			w.sample("ftth_interface_rx_bits_per_second", r.DownloadSpeed, "router", iface.Router.RouterName, "interface", iface.InterfaceName)
+		}
+	}
+	w.header("ftth_interface_tx_bits_per_second", "Upload (tx) terakhir dari sinkronisasi traffic.", "gauge")
+	for _, r := range rows {
+		if iface, ok := ifaceMap[r.InterfaceID]; ok {
+			w.sample("ftth_interface_tx_bits_per_second", r.UploadSpeed, "router", iface.Router.RouterName, "interface", iface.InterfaceName)
+		}
+	}
+	return nil
+}
+
+func writePingAndRouterMetrics(w *promWriter) {
+	metricsMu.RLock()
+	defer metricsMu.RUnlock()
+
+	ids := make([]int, 0, len(pingStats))
+	for id := range pingStats {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	w.header("ftth_ping_packet_loss_percent", "Packet loss ping terakhir per interface.", "gauge")
+	for _, id := range ids {
+		p := pingStats[id]
+		w.sample("ftth_ping_packet_loss_percent", float64(p.PacketLoss), "router", p.RouterName, "interface", p.InterfaceName)
+	}
+	w.header("ftth_ping_rtt_milliseconds", "Rata-rata RTT ping terakhir per interface.", "gauge")
+	for _, id := range ids {
+		p := pingStats[id]
+		if !p.Failed {
+			w.sample("ftth_ping_rtt_milliseconds", float64(p.AvgRtt), "router", p.RouterName, "interface", p.InterfaceName)
+		}
+	}
+
+	routerIDs := make([]string, 0, len(routerState))
+	for id := range routerState {
+		routerIDs = append(routerIDs, id)
+	}
+	sort.Strings(routerIDs)
+
+	w.header("ftth_router_up", "1 jika router bisa dihubungi via API pada pengecekan terakhir.", "gauge")
+	for _, id := range routerIDs {
+		up := 0.0
+		if routerState[id] {
+			up = 1
+		}
+		w.sample("ftth_router_up", up, "router_id", id, "router", routerNames[id])
+	}
+}
+
+func writeClientMetrics(w *promWriter) error {
+	var clients []models.Client
+	if err := config.DB.Select("client_id", "onu_sn", "rx_power", "fat", "package_id").Find(&clients).Error; err != nil {
+		return err
+	}
+
+	// Label memakai client_id, bukan nama pelanggan, agar kardinalitas tetap
+	// stabil dan data pribadi tidak ikut terbuka di /metrics.
+	w.header("ftth_onu_rx_power_dbm", "RX power ONU terakhir per pelanggan (dari GenieACS).", "gauge")
+	for _, cl := range clients {
+		rx, err := strconv.ParseFloat(strings.TrimSpace(cl.RxPower), 64)
+		if err != nil || rx == 0 {
+			continue
+		}
+		w.sample("ftth_onu_rx_power_dbm", rx, "client_id", strconv.Itoa(cl.ClientID), "onu_sn", cl.OnuSN, "fat", cl.Fat)
+	}
+
+	type groupCount struct {
+		Name  string
+		Total int64
+	}
+
+	var perPackage []groupCount
+	if err := config.DB.Raw(`
+		SELECT COALESCE(p.package_name, 'Tanpa Paket') AS name, COUNT(*) AS total
+		FROM clients c
+		LEFT JOIN internetpackages p ON p.package_id = c.package_id
+		WHERE c.deleted_at IS NULL
+		GROUP BY name
+	`).Scan(&perPackage).Error; err != nil {
+		return err
+	}
+	w.header("ftth_clients_per_package", "Jumlah pelanggan aktif per paket internet.", "gauge")
+	for _, g := range perPackage {
+		w.sample("ftth_clients_per_package", float64(g.Total), "package", g.Name)
+	}
+
+	var perFat []groupCount
+	if err := config.DB.Raw(`
+		SELECT COALESCE(NULLIF(fat, ''), 'Tanpa FAT') AS name, COUNT(*) AS total
+		FROM clients
+		WHERE deleted_at IS NULL
+		GROUP BY name
+	`).Scan(&perFat).Error; err != nil {
+		return err
+	}
+	w.header("ftth_clients_per_fat", "Jumlah pelanggan aktif per area FAT/ODP.", "gauge")
+	for _, g := range perFat {
+		w.sample("ftth_clients_per_fat", float64(g.Total), "fat", g.Name)
+	}
+	return nil
+}
+
+func writeJobMetrics(w *promWriter) {
+	metricsMu.RLock()
+	defer metricsMu.RUnlock()
+
+	names := make([]string, 0, len(jobStats))
+	for name := range jobStats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	w.header("ftth_job_runs_total", "Total eksekusi cron job sejak service dijalankan.", "counter")
+	for _, n := range names {
+		w.sample("ftth_job_runs_total", float64(jobStats[n].Runs), "job", n)
+	}
+	w.header("ftth_job_errors_total", "Total eksekusi cron job yang gagal.", "counter")
+	for _, n := range names {
+		w.sample("ftth_job_errors_total", float64(jobStats[n].Errors), "job", n)
+	}
+	w.header("ftth_job_last_duration_seconds", "Durasi eksekusi terakhir cron job.", "gauge")
+	for _, n := range names {
+		w.sample("ftth_job_last_duration_seconds", jobStats[n].LastDuration, "job", n)
+	}
+	w.header("ftth_job_last_success_timestamp_seconds", "Unix timestamp eksekusi sukses terakhir.", "gauge")
+	for _, n := range names {
+		if !jobStats[n].LastSuccess.IsZero() {
+			w.sample("ftth_job_last_success_timestamp_seconds", float64(jobStats[n].LastSuccess.Unix()), "job", n)
+		}
+	}
+}
@@ -13,6 +13,11 @@ type RouterSystemInfo struct {
 	Model     string `json:"model"`
 	Version   string `json:"version"`
 	CPU       string `json:"cpu"`
+	CPULoad   string `json:"cpu_load"`
+	FreeMem   string `json:"free_memory"`
+	TotalMem  string `json:"total_memory"`
+	FreeHDD   string `json:"free_hdd"`
+	TotalHDD  string `json:"total_hdd"`
 	Uptime    string `json:"uptime"`
 	Identity  string `json:"identity"` // Nama router dari /system/identity
 }
@@ -63,6 +68,11 @@ func GetRouterSystemInfo(ip string, port int, username, password, remoteType str
 		info.Model = res["board-name"]
 		info.Version = res["version"]
 		info.CPU = res["cpu"]
+		info.CPULoad = res["cpu-load"]
+		info.FreeMem = res["free-memory"]
+		info.TotalMem = res["total-memory"]
+		info.FreeHDD = res["free-hdd-space"]
+		info.TotalHDD = res["total-hdd-space"]
 		info.Uptime = res["uptime"]
 	}
 
@@ -0,0 +1,336 @@
+package services
+
+import (
+	"akane/be-ftth/config"
+	"akane/be-ftth/models"
+	"container/heap"
+	"math"
+	"sort"
+)
+
+// NearestODP adalah satu kandidat ODP untuk pemasangan pelanggan baru
+type NearestODP struct {
+	NodeID     int     `json:"node_id"`
+	Name       string  `json:"name"`
+	Lat        float64 `json:"lat"`
+	Lng        float64 `json:"lng"`
+	Status     string  `json:"status"`
+	TotalPorts int     `json:"total_ports"`
+	UsedPorts  int     `json:"used_ports"` // Port terpakai + rusak + dipesan
+	FreePorts  int     `json:"free_ports"`
+	PortSource string  `json:"port_source"` // ports = dari record port ODP, cables = dari jumlah kabel ke CLIENT
+
+	StraightMeter      float64    `json:"straight_meter"`       // Jarak garis lurus pelanggan -> ODP
+	EstimatedDropMeter float64    `json:"estimated_drop_meter"` // Perkiraan panjang kabel drop
+	RouteMeter         *float64   `json:"route_meter"`          // Jarak pelanggan -> ODP menyusuri kabel yang ada, nil jika ODP tidak terhubung ke titik snap
+	OltCableMeter      *float64   `json:"olt_cable_meter"`      // Panjang kabel dari ODP ke OLT (bukan pelanggan -> ODP), nil jika jalur tidak sampai OLT
+	OLT                *TraceNode `json:"olt"`
+	ODC                *TraceNode `json:"odc"`
+}
+
+// NearestODPQuery adalah parameter pencarian ODP terdekat
+type NearestODPQuery struct {
+	Lat      float64
+	Lng      float64
+	Limit    int     // Default 5
+	MaxMeter float64 // Radius maksimal garis lurus, default ODP_FINDER_MAX_METER (500)
+	MinFree  int     // Minimal port kosong, default 1
+}
+
+// NearestODPResult adalah hasil pencarian beserta parameter perkiraan kabel drop yang dipakai
+type NearestODPResult struct {
+	Lat              float64      `json:"lat"`
+	Lng              float64      `json:"lng"`
+	MaxMeter         float64      `json:"max_meter"`
+	DropRouteFactor  float64      `json:"drop_route_factor"`
+	DropSlackMeter   float64      `json:"drop_slack_meter"`
+	SkippedFull      int          `json:"skipped_full"`     // ODP dalam radius yang portnya penuh
+	RankedBy         string       `json:"ranked_by"`        // Selalu "straight_line": urutan & limit memakai jarak garis lurus
+	RouteSnapMeter   *float64     `json:"route_snap_meter"` // Garis lurus pelanggan -> kabel/node terdekat (awal route_meter), nil jika di luar radius
+	RouteSnapCableID int          `json:"route_snap_cable_id,omitempty"`
+	RouteSnapNodeID  int          `json:"route_snap_node_id,omitempty"`
+	Candidates       []NearestODP `json:"candidates"`
+}
+
+// odpUnavailablePorts menghitung port yang tidak bisa dipakai per ODP.
+// ODP dengan record port memakai status selain free; sisanya memakai jumlah kabel ke node CLIENT.
+func odpUnavailablePorts() (map[int]int, map[int]bool, error) {
+	type row struct {
+		ODPNodeID int
+		Used      int
+	}
+	var portRows []row
+	if err := config.DB.Table("odp_ports p").
+		Joins("INNER JOIN odps o ON o.node_id = p.odp_node_id AND o.ports_backfilled_at IS NOT NULL").
+		Select("p.odp_node_id, SUM(CASE WHEN p.status <> ? THEN 1 ELSE 0 END) AS used", PortFree).
+		Group("p.odp_node_id").Scan(&portRows).Error; err != nil {
+		return nil, nil, err
+	}
+	used := make(map[int]int, len(portRows))
+	fromPorts := make(map[int]bool, len(portRows))
+	for _, r := range portRows {
+		used[r.ODPNodeID] = r.Used
+		fromPorts[r.ODPNodeID] = true
+	}
+
+	var cableRows []row
+	if err := config.DB.Raw(`
+		SELECT t.odp_node_id, COUNT(*) AS used FROM (
+			SELECT nc.source_node_id AS odp_node_id, nc.target_node_id AS other_id FROM network_cables nc
+			UNION ALL
+			SELECT nc.target_node_id AS odp_node_id, nc.source_node_id AS other_id FROM network_cables nc
+		) t
+		INNER JOIN network_nodes n ON n.node_id = t.other_id AND n.type = ?
+		GROUP BY t.odp_node_id
+	`, models.TypeClient).Scan(&cableRows).Error; err != nil {
+		return nil, nil, err
+	}
+	for _, r := range cableRows {
+		if !fromPorts[r.ODPNodeID] {
+			used[r.ODPNodeID] = r.Used
+		}
+	}
+	return used, fromPorts, nil
+}
+
+// FindNearestODPs mencari ODP terdekat yang masih punya port kosong dari koordinat pelanggan baru.
+// Perkiraan drop = garis lurus x DROP_ROUTE_FACTOR + DROP_SLACK_METER.
+// Kandidat diurutkan & dipotong berdasarkan jarak garis lurus. route_meter = garis lurus pelanggan ke kabel/node
+// terdekat + panjang kabel dari titik itu ke ODP; panjang kabel ODP -> OLT hanya informasi tambahan.
+func FindNearestODPs(q NearestODPQuery) (*NearestODPResult, error) {
+	if q.Limit <= 0 {
+		q.Limit = 5
+	}
+	if q.MaxMeter <= 0 {
+		q.MaxMeter = envFloat("ODP_FINDER_MAX_METER", 500)
+	}
+	if q.MinFree <= 0 {
+		q.MinFree = 1
+	}
+	result := &NearestODPResult{
+		Lat:             q.Lat,
+		Lng:             q.Lng,
+		MaxMeter:        q.MaxMeter,
+		DropRouteFactor: envFloat("DROP_ROUTE_FACTOR", 1.3),
+		DropSlackMeter:  envFloat("DROP_SLACK_METER", 10),
+		RankedBy:        "straight_line",
+		Candidates:      []NearestODP{},
+	}
+
+	var odps []models.NetworkNode
+	if err := config.DB.Preload("ODPDetail").Where("type = ?", models.TypeODP).Find(&odps).Error; err != nil {
+		return nil, err
+	}
+	used, fromPorts, err := odpUnavailablePorts()
+	if err != nil {
+		return nil, err
+	}
+
+	customer := [2]float64{q.Lat, q.Lng}
+	for _, n := range odps {
+		dist := haversineMeter(customer, [2]float64{n.Latitude, n.Longitude})
+		if dist > q.MaxMeter {
+			continue
+		}
+		total := 8
+		if n.ODPDetail != nil && n.ODPDetail.TotalPorts > 0 {
+			total = n.ODPDetail.TotalPorts
+		}
+		c := NearestODP{
+			NodeID:             n.NodeID,
+			Name:               n.Name,
+			Lat:                n.Latitude,
+			Lng:                n.Longitude,
+			Status:             n.Status,
+			TotalPorts:         total,
+			UsedPorts:          used[n.NodeID],
+			FreePorts:          total - used[n.NodeID],
+			PortSource:         "cables",
+			StraightMeter:      math.Round(dist*10) / 10,
+			EstimatedDropMeter: math.Ceil(dist*result.DropRouteFactor + result.DropSlackMeter),
+		}
+		if fromPorts[n.NodeID] {
+			c.PortSource = "ports"
+		}
+		if c.FreePorts < q.MinFree {
+			result.SkippedFull++
+			continue
+		}
+		result.Candidates = append(result.Candidates, c)
+	}
+
+	sort.Slice(result.Candidates, func(i, j int) bool {
+		return result.Candidates[i].StraightMeter < result.Candidates[j].StraightMeter
+	})
+	if len(result.Candidates) > q.Limit {
+		result.Candidates = result.Candidates[:q.Limit]
+	}
+	if len(result.Candidates) == 0 {
+		return result, nil
+	}
+
+	// Route & panjang kabel ODP -> OLT hanya dihitung untuk kandidat yang dikembalikan
+	graph, err := LoadTopologyGraph()
+	if err != nil {
+		return nil, err
+	}
+	if snap := graph.snapToNetwork(customer); snap != nil && snap.meter <= q.MaxMeter {
+		snapMeter := math.Round(snap.meter*10) / 10
+		result.RouteSnapMeter = &snapMeter
+		result.RouteSnapCableID, result.RouteSnapNodeID = snap.cableID, snap.nodeID
+		dist := graph.cableDistances(snap)
+		for i := range result.Candidates {
+			if d, ok := dist[result.Candidates[i].NodeID]; ok {
+				route := math.Round((snap.meter+d)*10) / 10
+				result.Candidates[i].RouteMeter = &route
+			}
+		}
+	}
+	for i := range result.Candidates {
+		c := &result.Candidates[i]
+		trace, err := graph.TraceUpstream(c.NodeID)
+		if err != nil {
+			continue
+		}
+		c.OLT, c.ODC = trace.OLT, trace.ODC
+		for _, hop := range trace.Hops {
+			if hop.Node.Type == models.TypeOLT {
+				meter := hop.Cumulative
+				c.OltCableMeter = &meter
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+// networkSnap adalah titik jaringan (kabel atau node) terdekat dari koordinat pelanggan
+type networkSnap struct {
+	meter    float64 // Garis lurus pelanggan -> titik snap
+	nodeID   int     // Diisi jika snap ke node
+	cableID  int     // Diisi jika snap ke kabel
+	source   int
+	target   int
+	toSource float64 // Panjang kabel dari titik snap ke node source
+	toTarget float64 // Panjang kabel dari titik snap ke node target
+}
+
+// snapToNetwork mencari kabel/node terdekat. Node CLIENT dan kabel drop ke CLIENT diabaikan
+// karena kabel pelanggan lain tidak bisa dipakai untuk menarik kabel drop baru.
+func (g *TopologyGraph) snapToNetwork(p [2]float64) *networkSnap {
+	var best *networkSnap
+	for id, n := range g.Nodes {
+		if n.Type == models.TypeClient {
+			continue
+		}
+		if d := haversineMeter(p, [2]float64{n.Latitude, n.Longitude}); best == nil || d < best.meter {
+			best = &networkSnap{meter: d, nodeID: id}
+		}
+	}
+	for _, c := range g.Cables {
+		source, target := g.Nodes[c.SourceNodeID], g.Nodes[c.TargetNodeID]
+		if source.Type == models.TypeClient || target.Type == models.TypeClient {
+			continue
+		}
+		path := effectiveCablePath(c, source, target)
+		d, fraction := projectOnPath(p, path)
+		if best != nil && d >= best.meter {
+			continue
+		}
+		// Jalur yang digambar dari target ke source dibalik agar fraction dihitung dari source
+		if haversineMeter(path[0], [2]float64{target.Latitude, target.Longitude}) <
+			haversineMeter(path[0], [2]float64{source.Latitude, source.Longitude}) {
+			fraction = 1 - fraction
+		}
+		length := g.cableMeter(c)
+		best = &networkSnap{
+			meter: d, cableID: c.CableID, source: c.SourceNodeID, target: c.TargetNodeID,
+			toSource: fraction * length, toTarget: (1 - fraction) * length,
+		}
+	}
+	return best
+}
+
+// cableMeter memakai panjang efektif kabel, atau panjang jalur jika belum dihitung
+func (g *TopologyGraph) cableMeter(c models.NetworkCable) float64 {
+	if c.LengthMeter > 0 {
+		return c.LengthMeter
+	}
+	return pathLengthMeter(effectiveCablePath(c, g.Nodes[c.SourceNodeID], g.Nodes[c.TargetNodeID]))
+}
+
+// projectOnPath mengembalikan jarak titik ke polyline [lat, lng] (meter) dan posisi proyeksinya
+// sebagai fraksi panjang polyline (0 = titik pertama, 1 = titik terakhir). Memakai proyeksi datar
+// di sekitar p, cukup akurat untuk jarak ratusan meter.
+func projectOnPath(p [2]float64, path [][2]float64) (float64, float64) {
+	const meterPerDegree = 111320.0
+	cosLat := math.Cos(p[0] * math.Pi / 180)
+	xy := func(q [2]float64) (float64, float64) {
+		return (q[1] - p[1]) * meterPerDegree * cosLat, (q[0] - p[0]) * meterPerDegree
+	}
+
+	best, bestAlong, along := math.Inf(1), 0.0, 0.0
+	for i := 1; i < len(path); i++ {
+		ax, ay := xy(path[i-1])
+		bx, by := xy(path[i])
+		dx, dy := bx-ax, by-ay
+		seg := math.Hypot(dx, dy)
+		t := 0.0
+		if seg > 0 {
+			t = math.Max(0, math.Min(1, -(ax*dx+ay*dy)/(seg*seg)))
+		}
+		if d := math.Hypot(ax+t*dx, ay+t*dy); d < best {
+			best, bestAlong = d, along+t*seg
+		}
+		along += seg
+	}
+	if along == 0 {
+		return best, 0
+	}
+	return best, bestAlong / along
+}
+
+type distItem struct {
+	node int
+	dist float64
+}
+
+type distQueue []distItem
+
+func (q distQueue) Len() int            { return len(q) }
+func (q distQueue) Less(i, j int) bool  { return q[i].dist < q[j].dist }
+func (q distQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *distQueue) Push(x interface{}) { *q = append(*q, x.(distItem)) }
+func (q *distQueue) Pop() interface{} {
+	old := *q
+	it := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return it
+}
+
+// cableDistances menghitung panjang kabel terpendek dari titik snap ke setiap node (Dijkstra),
+// tanpa melewati node CLIENT
+func (g *TopologyGraph) cableDistances(snap *networkSnap) map[int]float64 {
+	dist := make(map[int]float64)
+	q := &distQueue{}
+	if snap.cableID == 0 {
+		heap.Push(q, distItem{node: snap.nodeID})
+	} else {
+		heap.Push(q, distItem{node: snap.source, dist: snap.toSource})
+		heap.Push(q, distItem{node: snap.target, dist: snap.toTarget})
+	}
+	for q.Len() > 0 {
+		it := heap.Pop(q).(distItem)
+		if _, done := dist[it.node]; done {
+			continue
+		}
+		dist[it.node] = it.dist
+		for _, e := range g.Adj[it.node] {
+			if _, done := dist[e.To]; done || g.Nodes[e.To].Type == models.TypeClient {
+				continue
+			}
+			heap.Push(q, distItem{node: e.To, dist: it.dist + g.cableMeter(e.Cable)})
+		}
+	}
+	return dist
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"akane/be-ftth/models"
+	"math"
+	"testing"
+)
+
+func TestCableRouteFromCustomer(t *testing.T) {
+	// ODP-1 --110 m-- TB --110 m-- ODP-2 di garis lintang yang sama (0.001 derajat bujur ~ 110 m)
+	nodes := []models.NetworkNode{
+		{NodeID: 1, Name: "ODP-1", Type: models.TypeODP, Latitude: -7.0, Longitude: 110.000},
+		{NodeID: 2, Name: "TB-1", Type: models.TypeTB, Latitude: -7.0, Longitude: 110.001},
+		{NodeID: 3, Name: "ODP-2", Type: models.TypeODP, Latitude: -7.0, Longitude: 110.002},
+		{NodeID: 4, Name: "Rumah Tetangga", Type: models.TypeClient, Latitude: -7.0005, Longitude: 110.0015},
+	}
+	cables := []models.NetworkCable{
+		{CableID: 10, SourceNodeID: 1, TargetNodeID: 2, LengthMeter: 110},
+		{CableID: 20, SourceNodeID: 3, TargetNodeID: 2, LengthMeter: 110}, // Digambar terbalik
+		{CableID: 30, SourceNodeID: 3, TargetNodeID: 4, LengthMeter: 80},  // Drop tetangga, tidak boleh jadi titik snap
+	}
+	g := NewTopologyGraph(nodes, cables)
+
+	// Pelanggan ~55 m di selatan tengah kabel TB-1 -> ODP-2, tepat di rumah tetangga
+	snap := g.snapToNetwork([2]float64{-7.0005, 110.0015})
+	if snap == nil || snap.cableID != 20 {
+		t.Fatalf("expected snap to cable 20, got %+v", snap)
+	}
+	if math.Abs(snap.meter-55.3) > 1 || math.Abs(snap.toSource-55) > 1 || math.Abs(snap.toTarget-55) > 1 {
+		t.Fatalf("unexpected snap %+v", snap)
+	}
+
+	dist := g.cableDistances(snap)
+	if math.Abs(dist[3]-55) > 1 || math.Abs(dist[1]-165) > 1 {
+		t.Fatalf("unexpected cable distances %v", dist)
+	}
+	if _, ok := dist[4]; ok {
+		t.Fatalf("route must not walk into CLIENT nodes: %v", dist)
+	}
+
+	// Tepat di atas node: snap ke node, jarak dimulai dari node tersebut
+	snap = g.snapToNetwork([2]float64{-7.0, 110.0})
+	if snap == nil || snap.nodeID != 1 || snap.meter > 0.1 {
+		t.Fatalf("expected snap to ODP-1, got %+v", snap)
+	}
+	if dist := g.cableDistances(snap); dist[3] != 220 {
+		t.Fatalf("expected 220 m to ODP-2, got %v", dist[3])
+	}
+}
+
+func TestProjectOnPath(t *testing.T) {
+	path := [][2]float64{{-7.0, 110.000}, {-7.0, 110.001}, {-7.001, 110.001}}
+	tests := []struct {
+		name     string
+		p        [2]float64
+		meter    float64
+		fraction float64
+	}{
+		{"start", [2]float64{-7.0, 110.0}, 0, 0},
+		{"end", [2]float64{-7.001, 110.001}, 0, 1},
+		{"corner", [2]float64{-6.9995, 110.0015}, 78, 0.5}, // ~55 m utara & ~55 m timur dari tikungan
+		{"beside second segment", [2]float64{-7.0005, 110.0015}, 55, 0.75},
+	}
+	for _, tt := range tests {
+		meter, fraction := projectOnPath(tt.p, path)
+		if math.Abs(meter-tt.meter) > 2 || math.Abs(fraction-tt.fraction) > 0.02 {
+			t.Fatalf("%s: got %.1f m at %.2f, want %.1f m at %.2f", tt.name, meter, fraction, tt.meter, tt.fraction)
+		}
+	}
+}
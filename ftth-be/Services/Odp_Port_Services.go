@@ -0,0 +1,509 @@
+package services
+
+import (
+	"akane/be-ftth/config"
+	"akane/be-ftth/models"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	PortFree     = "free"
+	PortUsed     = "used"
+	PortFaulty   = "faulty"
+	PortReserved = "reserved"
+)
+
+// ODPPortMap adalah denah port satu ODP. Initialized false berarti record port belum dibuat
+// (lihat InitODPPorts) dan Ports kosong.
+type ODPPortMap struct {
+	ODPNodeID   int              `json:"odp_node_id"`
+	Name        string           `json:"name"`
+	Initialized bool             `json:"initialized"`
+	TotalPorts  int              `json:"total_ports"`
+	Used        int              `json:"used"`
+	Free        int              `json:"free"`
+	Faulty      int              `json:"faulty"`
+	Reserved    int              `json:"reserved"`
+	Ports       []models.ODPPort `json:"ports"`
+}
+
+// PortAssignment adalah permintaan memasang pelanggan ke port ODP
+type PortAssignment struct {
+	PortNumber int  `json:"port_number"` // 0 = port kosong pertama
+	ClientID   int  `json:"client_id"`
+	CableID    *int `json:"cable_id"` // Kosong = dicari otomatis dari kabel ODP ke node pelanggan
+}
+
+func loadODP(tx *gorm.DB, odpNodeID int) (models.NetworkNode, models.ODP, error) {
+	var node models.NetworkNode
+	if err := tx.First(&node, "node_id = ?", odpNodeID).Error; err != nil {
+		return node, models.ODP{}, fmt.Errorf("ODP tidak ditemukan")
+	}
+	if node.Type != models.TypeODP {
+		return node, models.ODP{}, fmt.Errorf("node %s bukan ODP", node.Name)
+	}
+	var odp models.ODP
+	if err := tx.Where("node_id = ?", odpNodeID).First(&odp).Error; err != nil {
+		return node, odp, fmt.Errorf("detail ODP %s belum diisi", node.Name)
+	}
+	return node, odp, nil
+}
+
+// ensureODPPorts membuat record port 1..TotalPorts yang belum ada. Port kosong di atas TotalPorts
+// (misalnya setelah kapasitas diturunkan) dihapus, port yang masih dipakai dibiarkan.
+func ensureODPPorts(tx *gorm.DB, odp models.ODP) error {
+	var ports []models.ODPPort
+	if err := tx.Where("odp_node_id = ?", odp.NodeID).Find(&ports).Error; err != nil {
+		return err
+	}
+	existing := make(map[int]bool, len(ports))
+	for _, p := range ports {
+		existing[p.PortNumber] = true
+	}
+
+	ratio := odp.SplitterRatio
+	if ratio == 0 {
+		ratio = odp.TotalPorts
+	}
+	splitter := fmt.Sprintf("1:%d", ratio)
+
+	var missing []models.ODPPort
+	for n := 1; n <= odp.TotalPorts; n++ {
+		if !existing[n] {
+			missing = append(missing, models.ODPPort{ODPNodeID: odp.NodeID, PortNumber: n, SplitterType: splitter, Status: PortFree})
+		}
+	}
+	if len(missing) > 0 {
+		if err := tx.Create(&missing).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Where("odp_node_id = ? AND port_number > ? AND status = ?", odp.NodeID, odp.TotalPorts, PortFree).
+		Delete(&models.ODPPort{}).Error
+}
+
+// portOccupant adalah pemakai port ODP dari data lama (sebelum ada record port)
+type portOccupant struct {
+	clientID     *int
+	clientNodeID *int
+	cableID      *int
+}
+
+// backfillODPPorts membuat record port ODP lalu menandai port terpakai dari data lama: kabel drop
+// ODP -> node CLIENT dan pelanggan aktif dengan fat = nama ODP. Setelah itu ODP ditandai
+// ports_backfilled_at sehingga jumlah port terpakai diambil dari record port.
+// Mengembalikan jumlah pemakai yang tidak kebagian port (ODP melebihi kapasitas).
+func backfillODPPorts(tx *gorm.DB, node models.NetworkNode, odp *models.ODP, executor string) (int, error) {
+	if err := ensureODPPorts(tx, *odp); err != nil {
+		return 0, err
+	}
+
+	var occupants []portOccupant
+	seenClient := make(map[int]bool)
+
+	var cables []models.NetworkCable
+	if err := tx.Where("source_node_id = ? OR target_node_id = ?", node.NodeID, node.NodeID).
+		Order("cable_id ASC").Find(&cables).Error; err != nil {
+		return 0, err
+	}
+	for i := range cables {
+		otherID := cables[i].TargetNodeID
+		if otherID == node.NodeID {
+			otherID = cables[i].SourceNodeID
+		}
+		var other models.NetworkNode
+		if err := tx.Select("node_id", "type").First(&other, "node_id = ?", otherID).Error; err != nil || other.Type != models.TypeClient {
+			continue
+		}
+		o := portOccupant{clientNodeID: &other.NodeID, cableID: &cables[i].CableID}
+		var clientNode models.ClientNode
+		if err := tx.Where("node_id = ?", other.NodeID).First(&clientNode).Error; err == nil {
+			if id, err := strconv.Atoi(clientNode.SubscriberID); err == nil {
+				var count int64
+				tx.Model(&models.Client{}).Where("client_id = ?", id).Count(&count)
+				if count > 0 && !seenClient[id] {
+					o.clientID = &id
+					seenClient[id] = true
+				}
+			}
+		}
+		occupants = append(occupants, o)
+	}
+
+	var clients []models.Client
+	if err := tx.Select("client_id").Where("fat = ?", node.Name).Order("client_id ASC").Find(&clients).Error; err != nil {
+		return 0, err
+	}
+	for i := range clients {
+		if seenClient[clients[i].ClientID] {
+			continue
+		}
+		seenClient[clients[i].ClientID] = true
+		occupants = append(occupants, portOccupant{clientID: &clients[i].ClientID})
+	}
+
+	var free []models.ODPPort
+	if err := tx.Where("odp_node_id = ? AND status = ?", node.NodeID, PortFree).Order("port_number ASC").Find(&free).Error; err != nil {
+		return 0, err
+	}
+	overflow := 0
+	for _, o := range occupants {
+		// Pelanggan / node yang sudah tercatat di record port tidak dipasang dua kali
+		var existing int64
+		if o.clientID != nil {
+			tx.Model(&models.ODPPort{}).Where("client_id = ?", *o.clientID).Count(&existing)
+		} else {
+			tx.Model(&models.ODPPort{}).Where("odp_node_id = ? AND client_node_id = ?", node.NodeID, *o.clientNodeID).Count(&existing)
+		}
+		if existing > 0 {
+			continue
+		}
+		if len(free) == 0 {
+			overflow++
+			continue
+		}
+		port := free[0]
+		free = free[1:]
+		if err := tx.Model(&port).Updates(map[string]interface{}{
+			"status":         PortUsed,
+			"client_id":      o.clientID,
+			"client_node_id": o.clientNodeID,
+			"cable_id":       o.cableID,
+			"note":           "Diisi dari data kabel/pelanggan lama",
+			"updated_by":     executor,
+		}).Error; err != nil {
+			return 0, err
+		}
+	}
+
+	if err := syncODPUsedPorts(tx, node.NodeID); err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	odp.PortsBackfilledAt = &now
+	if err := tx.Model(&models.ODP{}).Where("node_id = ?", node.NodeID).Update("ports_backfilled_at", now).Error; err != nil {
+		return 0, err
+	}
+	return overflow, nil
+}
+
+// prepareODPPorts dipanggil sebelum operasi tulis port: backfill jika record port belum pernah
+// dibuat, atau menyesuaikan jumlah record dengan TotalPorts jika sudah
+func prepareODPPorts(tx *gorm.DB, node models.NetworkNode, odp *models.ODP, executor string) error {
+	if odp.PortsBackfilledAt == nil {
+		_, err := backfillODPPorts(tx, node, odp, executor)
+		return err
+	}
+	return ensureODPPorts(tx, *odp)
+}
+
+// SyncODPPortRows menyesuaikan record port dengan TotalPorts setelah detail ODP diubah.
+// ODP yang belum di-backfill dibiarkan (record port dibuat lewat InitODPPorts / assign pertama).
+func SyncODPPortRows(tx *gorm.DB, odp models.ODP) error {
+	if odp.PortsBackfilledAt == nil {
+		return nil
+	}
+	return ensureODPPorts(tx, odp)
+}
+
+// InitODPPorts membuat record port ODP dan mengisi port terpakai dari kabel drop & pelanggan lama.
+// Untuk ODP yang sudah diinisialisasi hanya jumlah record port yang disesuaikan.
+// Mengembalikan denah port beserta jumlah pemakai yang tidak kebagian port.
+func InitODPPorts(odpNodeID int, executor string) (*ODPPortMap, int, error) {
+	overflow := 0
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		node, odp, err := loadODP(tx, odpNodeID)
+		if err != nil {
+			return err
+		}
+		if odp.PortsBackfilledAt != nil {
+			return ensureODPPorts(tx, odp)
+		}
+		overflow, err = backfillODPPorts(tx, node, &odp, executor)
+		return err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	portMap, err := GetODPPortMap(odpNodeID)
+	return portMap, overflow, err
+}
+
+// syncODPUsedPorts menyamakan odps.used_ports dengan jumlah port berstatus used
+func syncODPUsedPorts(tx *gorm.DB, odpNodeID int) error {
+	var used int64
+	if err := tx.Model(&models.ODPPort{}).Where("odp_node_id = ? AND status = ?", odpNodeID, PortUsed).Count(&used).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.ODP{}).Where("node_id = ?", odpNodeID).Update("used_ports", used).Error
+}
+
+// GetODPPortMap mengembalikan semua port ODP beserta pelanggan yang memakainya (read-only)
+func GetODPPortMap(odpNodeID int) (*ODPPortMap, error) {
+	node, odp, err := loadODP(config.DB, odpNodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &ODPPortMap{ODPNodeID: odpNodeID, Name: node.Name, Initialized: odp.PortsBackfilledAt != nil, TotalPorts: odp.TotalPorts, Ports: []models.ODPPort{}}
+	if err := config.DB.Preload("Client", func(db *gorm.DB) *gorm.DB {
+		return db.Select("client_id", "name", "phone", "onu_status", "rx_power")
+	}).Where("odp_node_id = ?", odpNodeID).Order("port_number ASC").Find(&m.Ports).Error; err != nil {
+		return nil, err
+	}
+	for _, p := range m.Ports {
+		switch p.Status {
+		case PortUsed:
+			m.Used++
+		case PortFaulty:
+			m.Faulty++
+		case PortReserved:
+			m.Reserved++
+		default:
+			m.Free++
+		}
+	}
+	return m, nil
+}
+
+// errPortTaken menandai port yang keburu diambil request lain di antara SELECT dan UPDATE
+var errPortTaken = errors.New("port sudah diambil request lain")
+
+// maxPortAssignAttempts adalah jumlah percobaan ulang AssignODPPort saat port bentrok
+const maxPortAssignAttempts = 3
+
+// AssignODPPort memasang pelanggan ke port ODP. Jika pelanggan sudah memakai port lain, port lama dibebaskan
+// (pindah port / pindah ODP). Field fat pelanggan disamakan dengan nama ODP.
+// Port dikunci (SELECT ... FOR UPDATE) dan UPDATE hanya berlaku jika port masih bisa dipakai; jika bentrok
+// dengan assign lain yang bersamaan, transaksi diulang agar mendapat port kosong berikutnya.
+func AssignODPPort(odpNodeID int, req PortAssignment, executor string) (*models.ODPPort, error) {
+	var port *models.ODPPort
+	var err error
+	for attempt := 0; attempt < maxPortAssignAttempts; attempt++ {
+		port, err = assignODPPort(odpNodeID, req, executor)
+		if !errors.Is(err, errPortTaken) {
+			break
+		}
+	}
+	if errors.Is(err, errPortTaken) {
+		return nil, fmt.Errorf("port ODP sedang dipakai request lain, coba lagi")
+	}
+	return port, err
+}
+
+func assignODPPort(odpNodeID int, req PortAssignment, executor string) (*models.ODPPort, error) {
+	var port models.ODPPort
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		node, odp, err := loadODP(tx, odpNodeID)
+		if err != nil {
+			return err
+		}
+		var client models.Client
+		if err := tx.Select("client_id", "name", "fat").First(&client, "client_id = ?", req.ClientID).Error; err != nil {
+			return fmt.Errorf("pelanggan tidak ditemukan")
+		}
+		if err := prepareODPPorts(tx, node, &odp, executor); err != nil {
+			return err
+		}
+
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("odp_node_id = ?", odpNodeID)
+		if req.PortNumber > 0 {
+			query = query.Where("port_number = ?", req.PortNumber)
+		} else {
+			query = query.Where("status = ?", PortFree).Order("port_number ASC")
+		}
+		if err := query.First(&port).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+			if req.PortNumber > 0 {
+				return fmt.Errorf("port %d tidak ada di ODP %s", req.PortNumber, node.Name)
+			}
+			return fmt.Errorf("ODP %s penuh", node.Name)
+		} else if err != nil {
+			return err
+		}
+		if port.Status == PortUsed && (port.ClientID == nil || *port.ClientID != client.ClientID) {
+			return fmt.Errorf("port %d sudah dipakai pelanggan lain", port.PortNumber)
+		}
+		if port.Status == PortFaulty {
+			return fmt.Errorf("port %d rusak", port.PortNumber)
+		}
+
+		// Bebaskan port lama pelanggan (di ODP mana pun) selain port tujuan
+		var previous []models.ODPPort
+		if err := tx.Where("client_id = ? AND port_id <> ?", client.ClientID, port.PortID).Find(&previous).Error; err != nil {
+			return err
+		}
+		for _, p := range previous {
+			if err := freePort(tx, p, executor); err != nil {
+				return err
+			}
+		}
+
+		var clientNodeID *int
+		var clientNode models.ClientNode
+		if err := tx.Where("subscriber_id = ?", strconv.Itoa(client.ClientID)).First(&clientNode).Error; err == nil {
+			clientNodeID = &clientNode.NodeID
+		}
+		cableID := req.CableID
+		if cableID == nil && clientNodeID != nil {
+			var cable models.NetworkCable
+			if err := tx.Where("(source_node_id = ? AND target_node_id = ?) OR (source_node_id = ? AND target_node_id = ?)",
+				odpNodeID, *clientNodeID, *clientNodeID, odpNodeID).First(&cable).Error; err == nil {
+				cableID = &cable.CableID
+			}
+		}
+
+		alreadyMine := port.Status == PortUsed && port.ClientID != nil && *port.ClientID == client.ClientID
+		update := tx.Model(&models.ODPPort{}).
+			Where("port_id = ? AND status <> ? AND (status <> ? OR client_id = ?)", port.PortID, PortFaulty, PortUsed, client.ClientID).
+			Updates(map[string]interface{}{
+				"status":         PortUsed,
+				"client_id":      client.ClientID,
+				"client_node_id": clientNodeID,
+				"cable_id":       cableID,
+				"updated_by":     executor,
+			})
+		if update.Error != nil {
+			return update.Error
+		}
+		// MySQL tidak menghitung baris yang nilainya tidak berubah, jadi port milik sendiri tidak dicek
+		if update.RowsAffected != 1 && !alreadyMine {
+			return errPortTaken
+		}
+		if client.Fat != node.Name {
+			if err := tx.Model(&models.Client{}).Where("client_id = ?", client.ClientID).Update("fat", node.Name).Error; err != nil {
+				return err
+			}
+		}
+
+		odpIDs := map[int]bool{odpNodeID: true}
+		for _, p := range previous {
+			odpIDs[p.ODPNodeID] = true
+		}
+		for id := range odpIDs {
+			if err := syncODPUsedPorts(tx, id); err != nil {
+				return err
+			}
+		}
+		return tx.First(&port, "port_id = ?", port.PortID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &port, nil
+}
+
+func freePort(tx *gorm.DB, port models.ODPPort, executor string) error {
+	return tx.Model(&models.ODPPort{}).Where("port_id = ?", port.PortID).Updates(map[string]interface{}{
+		"status":         PortFree,
+		"client_id":      nil,
+		"client_node_id": nil,
+		"cable_id":       nil,
+		"updated_by":     executor,
+	}).Error
+}
+
+// SetODPPortStatus mengubah status port (free, faulty, reserved). Mengubah port yang dipakai menjadi free
+// berarti melepas pelanggan dari port tersebut; port yang dipakai tidak bisa langsung ditandai faulty/reserved.
+func SetODPPortStatus(odpNodeID, portNumber int, status, note, executor string) (*models.ODPPort, error) {
+	if status != PortFree && status != PortFaulty && status != PortReserved {
+		return nil, fmt.Errorf("status harus free, faulty atau reserved (gunakan assign untuk used)")
+	}
+
+	var port models.ODPPort
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		node, odp, err := loadODP(tx, odpNodeID)
+		if err != nil {
+			return err
+		}
+		if err := prepareODPPorts(tx, node, &odp, executor); err != nil {
+			return err
+		}
+		if err := tx.Where("odp_node_id = ? AND port_number = ?", odpNodeID, portNumber).First(&port).Error; err != nil {
+			return fmt.Errorf("port %d tidak ditemukan", portNumber)
+		}
+		if port.Status == PortUsed && status != PortFree {
+			return fmt.Errorf("port %d masih dipakai pelanggan, bebaskan atau pindahkan dulu", portNumber)
+		}
+
+		if status == PortFree {
+			if err := freePort(tx, port, executor); err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&port).Updates(map[string]interface{}{"status": status, "note": note, "updated_by": executor}).Error; err != nil {
+			return err
+		}
+		if err := syncODPUsedPorts(tx, odpNodeID); err != nil {
+			return err
+		}
+		return tx.First(&port, "port_id = ?", port.PortID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &port, nil
+}
+
+// AssignClientPortByFat memasang pelanggan ke port ODP yang namanya sama dengan field fat.
+// Dipakai saat pelanggan dibuat, diimpor, fat-nya diubah, atau diaktifkan kembali; port 0 = port
+// kosong pertama. Port di ODP lain dibebaskan; jika fat kosong atau belum ada di peta semua port
+// pelanggan dibebaskan. Port yang sudah dipakai pelanggan di ODP yang sama dipertahankan.
+func AssignClientPortByFat(client models.Client, portNumber int, executor string) (*models.ODPPort, error) {
+	var node models.NetworkNode
+	if client.Fat == "" || config.DB.Where("type = ? AND name = ?", models.TypeODP, client.Fat).First(&node).Error != nil {
+		return nil, ReleaseClientPorts(client.ClientID, executor)
+	}
+	if portNumber == 0 {
+		var current models.ODPPort
+		if err := config.DB.Where("odp_node_id = ? AND client_id = ?", node.NodeID, client.ClientID).First(&current).Error; err == nil {
+			return &current, nil
+		}
+	}
+	return AssignODPPort(node.NodeID, PortAssignment{PortNumber: portNumber, ClientID: client.ClientID}, executor)
+}
+
+// ReleaseClientPorts membebaskan semua port yang dipakai pelanggan (saat pelanggan diputus)
+func ReleaseClientPorts(clientID int, executor string) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		var ports []models.ODPPort
+		if err := tx.Where("client_id = ?", clientID).Find(&ports).Error; err != nil {
+			return err
+		}
+		for _, p := range ports {
+			if err := freePort(tx, p, executor); err != nil {
+				return err
+			}
+			if err := syncODPUsedPorts(tx, p.ODPNodeID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ODPUsedPortCounts mengembalikan jumlah port terpakai per ODP yang record port-nya sudah di-backfill.
+// ODP lain tidak ada di map sehingga pemanggil tetap memakai jumlah kabel.
+func ODPUsedPortCounts() (map[int]int, error) {
+	type row struct {
+		ODPNodeID int
+		Used      int
+	}
+	var rows []row
+	if err := config.DB.Table("odp_ports p").
+		Joins("INNER JOIN odps o ON o.node_id = p.odp_node_id AND o.ports_backfilled_at IS NOT NULL").
+		Select("p.odp_node_id, SUM(CASE WHEN p.status = ? THEN 1 ELSE 0 END) AS used", PortUsed).
+		Group("p.odp_node_id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	counts := make(map[int]int, len(rows))
+	for _, r := range rows {
+		counts[r.ODPNodeID] = r.Used
+	}
+	return counts, nil
+}
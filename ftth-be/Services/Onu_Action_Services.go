@@ -0,0 +1,157 @@
+package services
+
+import (
+	"akane/be-ftth/config"
+	"akane/be-ftth/genieacs"
+	"akane/be-ftth/models"
+	"context"
+	"fmt"
+	"time"
+)
+
+// Batas waktu satu pemanggilan GenieACS dari endpoint aksi ONU
+const onuActionTimeout = 20 * time.Second
+
+// onuActionTasks memetakan aksi dari UI ke task GenieACS
+var onuActionTasks = map[string]genieacs.Task{
+	"reboot":        {Name: "reboot"},
+	"factory_reset": {Name: "factoryReset"},
+	"refresh":       {Name: "refreshObject", ObjectName: "InternetGatewayDevice"},
+	// Connection request dipicu lewat task ringan agar ONU langsung membuka sesi ke ACS
+	"connection_request": {Name: "getParameterValues", ParameterNames: []string{"InternetGatewayDevice.DeviceInfo.UpTime"}},
+}
+
+// IsValidOnuAction memastikan aksi dikenal
+func IsValidOnuAction(action string) bool {
+	_, ok := onuActionTasks[action]
+	return ok
+}
+
+// ResolveClientDevice mencari _id device GenieACS milik pelanggan dari SN ONU, username PPPoE atau IP
+func ResolveClientDevice(ctx context.Context, acs *genieacs.Client, client models.Client) (string, error) {
+	// Binding tersimpan lebih dipercaya daripada pencocokan ulang
+	if client.GenieDeviceID != "" {
+		return client.GenieDeviceID, nil
+	}
+
+	var conds []genieacs.Query
+	if client.OnuSN != "" {
+		conds = append(conds,
+			genieacs.Eq("_deviceId._SerialNumber", client.OnuSN),
+			genieacs.Eq("VirtualParameters.getSerialNumber._value", client.OnuSN),
+		)
+	}
+	if client.PppoeUsername != "" {
+		conds = append(conds, genieacs.Eq("VirtualParameters.pppoeUsername._value", client.PppoeUsername))
+	}
+	if client.IPAddress != "" && client.IPAddress != "0.0.0.0" {
+		conds = append(conds,
+			genieacs.Eq("VirtualParameters.IPTR069._value", client.IPAddress),
+			genieacs.Eq("VirtualParameters.pppoeIP._value", client.IPAddress),
+		)
+	}
+	if len(conds) == 0 {
+		return "", fmt.Errorf("pelanggan belum memiliki SN ONU, username PPPoE, atau IP")
+	}
+
+	devices, err := acs.FindDevices(ctx, genieacs.Or(conds...), genieacs.Projection{"_id"})
+	if err != nil {
+		return "", err
+	}
+	if len(devices) == 0 {
+		return "", genieacs.ErrDeviceNotFound
+	}
+	return devices[0].ID, nil
+}
+
+// QueueOnuAction membuat task GenieACS untuk ONU pelanggan dan mencatatnya di onu_actions
+func QueueOnuAction(client models.Client, action, executor string) (*models.OnuAction, error) {
+	task, ok := onuActionTasks[action]
+	if !ok {
+		return nil, fmt.Errorf("aksi '%s' tidak dikenal", action)
+	}
+
+	acs, err := genieacs.NewFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), onuActionTimeout)
+	defer cancel()
+
+	deviceID, err := ResolveClientDevice(ctx, acs, client)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := acs.PushTask(ctx, deviceID, task, true)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &models.OnuAction{
+		ClientID: client.ClientID,
+		DeviceID: deviceID,
+		Action:   action,
+		TaskID:   result.Task.ID,
+		Status:   genieacs.TaskPending,
+		Executor: executor,
+	}
+	if result.Applied {
+		record.Status = genieacs.TaskDone
+	}
+	if err := config.DB.Create(record).Error; err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// RefreshOnuActionStatus memperbarui status aksi yang masih pending dari GenieACS
+func RefreshOnuActionStatus(ctx context.Context, acs *genieacs.Client, record *models.OnuAction) error {
+	if record.Status != genieacs.TaskPending || record.TaskID == "" {
+		return nil
+	}
+
+	status, fault, err := acs.TaskStatus(ctx, record.DeviceID, record.TaskID)
+	if err != nil {
+		return err
+	}
+	if status == record.Status {
+		return nil
+	}
+
+	record.Status = status
+	if fault != nil {
+		record.FaultCode = fault.Code
+		record.FaultMessage = fault.Message
+	}
+	return config.DB.Save(record).Error
+}
+
+// RefreshOnuActions memperbarui semua aksi pending pada daftar (error GenieACS diabaikan per item)
+func RefreshOnuActions(records []models.OnuAction) {
+	hasPending := false
+	for _, r := range records {
+		if r.Status == genieacs.TaskPending {
+			hasPending = true
+			break
+		}
+	}
+	if !hasPending {
+		return
+	}
+
+	acs, err := genieacs.NewFromEnv()
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), onuActionTimeout)
+	defer cancel()
+
+	for i := range records {
+		if err := RefreshOnuActionStatus(ctx, acs, &records[i]); err != nil {
+			break
+		}
+	}
+}
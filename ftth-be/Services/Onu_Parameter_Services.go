@@ -0,0 +1,169 @@
+package services
+
+import (
+	"akane/be-ftth/config"
+	"akane/be-ftth/genieacs"
+	"akane/be-ftth/models"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// defaultTr069WriteAllowlist dipakai jika TR069_WRITE_ALLOWLIST kosong.
+// "*" cocok dengan satu segmen path, pola berakhiran "." cocok dengan seluruh sub-tree.
+var defaultTr069WriteAllowlist = []string{
+	"InternetGatewayDevice.LANDevice.*.WLANConfiguration.*.Enable",
+	"InternetGatewayDevice.LANDevice.*.WLANConfiguration.*.SSID",
+	"InternetGatewayDevice.LANDevice.*.WLANConfiguration.*.SSIDAdvertisementEnabled",
+	"InternetGatewayDevice.LANDevice.*.WLANConfiguration.*.Channel",
+	"InternetGatewayDevice.LANDevice.*.WLANConfiguration.*.KeyPassphrase",
+	"InternetGatewayDevice.LANDevice.*.WLANConfiguration.*.PreSharedKey.*.KeyPassphrase",
+	"InternetGatewayDevice.LANDevice.*.WLANConfiguration.*.PreSharedKey.*.PreSharedKey",
+	"InternetGatewayDevice.WANDevice.*.WANConnectionDevice.*.WANPPPConnection.*.Username",
+	"InternetGatewayDevice.WANDevice.*.WANConnectionDevice.*.WANPPPConnection.*.Password",
+	"InternetGatewayDevice.ManagementServer.PeriodicInformEnable",
+	"InternetGatewayDevice.ManagementServer.PeriodicInformInterval",
+	"Device.WiFi.SSID.*.Enable",
+	"Device.WiFi.SSID.*.SSID",
+	"Device.WiFi.AccessPoint.*.Security.KeyPassphrase",
+	"Device.PPP.Interface.*.Username",
+	"Device.PPP.Interface.*.Password",
+	"Device.ManagementServer.PeriodicInformInterval",
+	"VirtualParameters.WlanPassword",
+}
+
+// Nama parameter yang nilainya disamarkan di audit log
+var secretParameterNames = []string{"password", "passphrase", "key"}
+
+// ParameterValue adalah satu parameter yang akan di-set ke ONU
+type ParameterValue struct {
+	Path  string `json:"path"`
+	Value string `json:"value"`
+	Type  string `json:"type"` // Kosong = pakai tipe yang tercatat di GenieACS
+}
+
+// Tr069WriteAllowlist membaca TR069_WRITE_ALLOWLIST (dipisah koma), fallback ke daftar default
+func Tr069WriteAllowlist() []string {
+	raw := os.Getenv("TR069_WRITE_ALLOWLIST")
+	if strings.TrimSpace(raw) == "" {
+		return defaultTr069WriteAllowlist
+	}
+	var patterns []string
+	for _, p := range strings.Split(raw, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
+
+// IsParameterWritableAllowed mengecek path terhadap allowlist
+func IsParameterWritableAllowed(path string, allowlist []string) bool {
+	for _, pattern := range allowlist {
+		if matchParameterPattern(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchParameterPattern(pattern, path string) bool {
+	subtree := strings.HasSuffix(pattern, ".")
+	pSegs := strings.Split(strings.TrimSuffix(pattern, "."), ".")
+	segs := strings.Split(path, ".")
+	if len(segs) < len(pSegs) || (!subtree && len(segs) != len(pSegs)) {
+		return false
+	}
+	for i, p := range pSegs {
+		if p != "*" && p != segs[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// MaskParameterValue menyamarkan nilai parameter rahasia (password, passphrase, key) untuk log
+func MaskParameterValue(path, value string) string {
+	name := strings.ToLower(path[strings.LastIndex(path, ".")+1:])
+	for _, s := range secretParameterNames {
+		if strings.Contains(name, s) {
+			return "******"
+		}
+	}
+	return value
+}
+
+// SetOnuParameters memvalidasi parameter (allowlist, ada di device, writable) lalu mengirim
+// setParameterValues. Jika device terikat ke pelanggan, dicatat juga di riwayat aksi ONU.
+func SetOnuParameters(deviceID string, values []ParameterValue, executor string) (*genieacs.TaskResult, error) {
+	if len(values) == 0 {
+		return nil, fmt.Errorf("tidak ada parameter yang diubah")
+	}
+
+	allowlist := Tr069WriteAllowlist()
+	paths := make([]string, 0, len(values))
+	for i := range values {
+		values[i].Path = strings.Trim(strings.TrimSpace(values[i].Path), ".")
+		if !IsParameterWritableAllowed(values[i].Path, allowlist) {
+			return nil, fmt.Errorf("parameter %s tidak ada di allowlist", values[i].Path)
+		}
+		paths = append(paths, values[i].Path)
+	}
+
+	acs, err := genieacs.NewFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), onuActionTimeout)
+	defer cancel()
+
+	nodes, err := acs.GetParameterNodes(ctx, deviceID, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	params := make([][]interface{}, 0, len(values))
+	for _, v := range values {
+		node, ok := nodes[v.Path]
+		switch {
+		case !ok:
+			return nil, fmt.Errorf("parameter %s tidak ditemukan di device (refresh dulu path-nya)", v.Path)
+		case node.Object:
+			return nil, fmt.Errorf("%s adalah object, bukan parameter", v.Path)
+		case !node.Writable:
+			return nil, fmt.Errorf("parameter %s read-only", v.Path)
+		}
+
+		paramType := v.Type
+		if paramType == "" {
+			paramType = node.Type
+		}
+		if paramType == "" {
+			paramType = "xsd:string"
+		}
+		params = append(params, []interface{}{v.Path, v.Value, paramType})
+	}
+
+	result, err := acs.SetParameterValues(ctx, deviceID, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var client models.Client
+	if err := config.DB.Select("client_id").Where("genie_device_id = ?", deviceID).First(&client).Error; err == nil {
+		status := genieacs.TaskPending
+		if result.Applied {
+			status = genieacs.TaskDone
+		}
+		config.DB.Create(&models.OnuAction{
+			ClientID: client.ClientID,
+			DeviceID: deviceID,
+			Action:   "set_parameter",
+			TaskID:   result.Task.ID,
+			Status:   status,
+			Executor: executor,
+		})
+	}
+	return result, nil
+}
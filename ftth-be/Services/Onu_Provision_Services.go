@@ -0,0 +1,302 @@
+package services
+
+import (
+	"akane/be-ftth/config"
+	"akane/be-ftth/genieacs"
+	"akane/be-ftth/models"
+	"akane/be-ftth/utils"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Path TR-098 untuk kredensial PPPoE WAN ONU
+const (
+	pppoeUsernamePath = "InternetGatewayDevice.WANDevice.1.WANConnectionDevice.1.WANPPPConnection.1.Username"
+	pppoePasswordPath = "InternetGatewayDevice.WANDevice.1.WANConnectionDevice.1.WANPPPConnection.1.Password"
+)
+
+// Template default jika ONU_WIFI_SSID_TEMPLATE / ONU_WIFI_PASSWORD_TEMPLATE kosong
+const (
+	defaultWifiSSIDTemplate     = "{name}"
+	defaultWifiPasswordTemplate = "{random8}"
+)
+
+var templateNonSSID = regexp.MustCompile(`[^A-Za-z0-9 _\-.]`)
+
+// OnuProvisionOptions menentukan bagian mana yang dikonfigurasi ke ONU
+type OnuProvisionOptions struct {
+	PPPoE bool
+	WiFi  bool
+}
+
+// OnuProvisionResult adalah hasil provisioning ONU. Password WiFi sengaja tidak ikut di sini
+// karena hasil ini dicatat ke log dan respon API; password disimpan terenkripsi di data pelanggan.
+type OnuProvisionResult struct {
+	Status   string `json:"status"` // done, pending, skipped, failed
+	Message  string `json:"message"`
+	DeviceID string `json:"device_id,omitempty"`
+	TaskID   string `json:"task_id,omitempty"`
+	ActionID int    `json:"action_id,omitempty"`
+	SSID     string `json:"ssid,omitempty"`
+}
+
+// StartClientOnuProvision mencatat aksi "provision" berstatus pending lalu menjalankan provisioning
+// di goroutine, karena task GenieACS bisa menunggu hingga onuActionTimeout. Hasil akhirnya
+// (task_id, done/pending/fault) ditulis ke aksi yang sama sehingga bisa dipolling dari riwayat aksi ONU.
+// Mengembalikan status skipped tanpa membuat aksi jika tidak ada yang perlu dikirim.
+func StartClientOnuProvision(client models.Client, opts OnuProvisionOptions, executor string) OnuProvisionResult {
+	opts, reason := provisionPrecheck(client, opts)
+	if reason != "" {
+		return OnuProvisionResult{Status: "skipped", Message: reason}
+	}
+
+	action := models.OnuAction{
+		ClientID: client.ClientID,
+		DeviceID: client.GenieDeviceID,
+		Action:   "provision",
+		Status:   genieacs.TaskPending,
+		Executor: executor,
+	}
+	if err := config.DB.Create(&action).Error; err != nil {
+		return OnuProvisionResult{Status: "failed", Message: "gagal mencatat aksi provisioning: " + err.Error()}
+	}
+
+	go finishClientOnuProvision(client, opts, action, executor)
+	return OnuProvisionResult{
+		Status:   genieacs.TaskPending,
+		Message:  "Provisioning ONU berjalan di background",
+		ActionID: action.ActionID,
+	}
+}
+
+// finishClientOnuProvision menjalankan provisioning lalu memperbarui aksi & log sistem
+func finishClientOnuProvision(client models.Client, opts OnuProvisionOptions, action models.OnuAction, executor string) {
+	result := provisionClientOnu(client, opts)
+
+	updates := map[string]interface{}{
+		"task_id": result.TaskID,
+		"status":  result.Status,
+	}
+	if result.DeviceID != "" {
+		updates["device_id"] = result.DeviceID
+	}
+	if result.Status == "failed" {
+		updates["status"] = genieacs.TaskFault
+		updates["fault_message"] = result.Message
+	}
+	config.DB.Model(&models.OnuAction{}).Where("action_id = ?", action.ActionID).Updates(updates)
+
+	if result.Status == "failed" {
+		utils.CreateLog(executor, "CLIENT", "ERROR", fmt.Sprintf("Gagal provisioning ONU pelanggan ID %d: %s", client.ClientID, result.Message))
+		return
+	}
+	utils.CreateLog(executor, "CLIENT", "INFO", fmt.Sprintf("Provisioning ONU pelanggan ID %d: %s", client.ClientID, result.Message))
+}
+
+// provisionPrecheck menyaring kondisi yang membuat provisioning dilewati tanpa menghubungi GenieACS
+func provisionPrecheck(client models.Client, opts OnuProvisionOptions) (OnuProvisionOptions, string) {
+	if client.OnuSN == "" {
+		return opts, "SN ONU kosong"
+	}
+	if opts.PPPoE && (client.PppoeUsername == "" || client.PppoePassword == "") {
+		opts.PPPoE = false
+	}
+	if !opts.PPPoE && !opts.WiFi {
+		return opts, "tidak ada parameter yang perlu dikirim"
+	}
+	if _, err := genieacs.NewFromEnv(); errors.Is(err, genieacs.ErrNotConfigured) {
+		return opts, err.Error()
+	}
+	return opts, ""
+}
+
+// provisionClientOnu mencari ONU pelanggan di GenieACS berdasarkan SN lalu mengirim
+// username/password PPPoE WAN dan SSID/password WiFi default dalam satu task
+func provisionClientOnu(client models.Client, opts OnuProvisionOptions) OnuProvisionResult {
+	acs, err := genieacs.NewFromEnv()
+	if err != nil {
+		return OnuProvisionResult{Status: "failed", Message: err.Error()}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), onuActionTimeout)
+	defer cancel()
+
+	devices, err := acs.FindDevices(ctx, genieacs.Or(
+		genieacs.Eq("_deviceId._SerialNumber", client.OnuSN),
+		genieacs.Eq("VirtualParameters.getSerialNumber._value", client.OnuSN),
+	), genieacs.Projection{"_id"})
+	if err != nil {
+		return OnuProvisionResult{Status: "failed", Message: err.Error()}
+	}
+	if len(devices) == 0 {
+		return OnuProvisionResult{Status: "failed", Message: fmt.Sprintf("ONU dengan SN %s belum terdaftar di GenieACS", client.OnuSN)}
+	}
+	deviceID := devices[0].ID
+
+	// SN yang diinput saat provisioning adalah kecocokan pasti, langsung diikat ke pelanggan
+	// (binding pelanggan lain ke device yang sama dilepas karena ONU sudah dipindah)
+	if client.GenieDeviceID != deviceID && len(devices) == 1 {
+		config.DB.Model(&models.Client{}).Where("genie_device_id = ? AND client_id <> ?", deviceID, client.ClientID).
+			Updates(map[string]interface{}{"genie_device_id": "", "genie_bind_via": "", "genie_bound_at": nil})
+		if err := saveClientBinding(&client, deviceID, BindViaSN); err != nil {
+			return OnuProvisionResult{Status: "failed", DeviceID: deviceID, Message: "gagal menyimpan binding device: " + err.Error()}
+		}
+	}
+
+	result := OnuProvisionResult{DeviceID: deviceID}
+	var values [][]interface{}
+	var parts []string
+	var wifiPassword string
+	if opts.PPPoE {
+		values = append(values,
+			[]interface{}{pppoeUsernamePath, client.PppoeUsername, "xsd:string"},
+			[]interface{}{pppoePasswordPath, client.PppoePassword, "xsd:string"},
+		)
+		parts = append(parts, "PPPoE")
+	}
+	if opts.WiFi {
+		ssid, password := DefaultWifiCredentials(client)
+		result.SSID, wifiPassword = ssid, password
+		values = append(values,
+			[]interface{}{"InternetGatewayDevice.LANDevice.1.WLANConfiguration.1.SSID", ssid, "xsd:string"},
+			[]interface{}{"VirtualParameters.WlanPassword", password, "xsd:string"},
+		)
+		parts = append(parts, "WiFi")
+	}
+
+	task, err := acs.SetParameterValues(ctx, deviceID, values)
+	if err != nil {
+		result.Status = "failed"
+		result.Message = err.Error()
+		return result
+	}
+
+	result.TaskID = task.Task.ID
+	result.Status = genieacs.TaskPending
+	result.Message = strings.Join(parts, " & ") + " dikirim, menunggu ONU inform"
+	if task.Applied {
+		result.Status = genieacs.TaskDone
+		result.Message = strings.Join(parts, " & ") + " berhasil dikonfigurasi"
+	}
+
+	// Password WiFi yang sudah dikirim disimpan terenkripsi agar bisa dilihat admin kembali
+	if opts.WiFi {
+		if err := saveClientWifiCredentials(client.ClientID, result.SSID, wifiPassword); err != nil {
+			result.Message += " (peringatan: gagal menyimpan password WiFi - " + err.Error() + ")"
+		}
+	}
+	return result
+}
+
+// saveClientWifiCredentials menyimpan SSID & password WiFi (AES) yang terakhir dikirim ke ONU pelanggan
+func saveClientWifiCredentials(clientID int, ssid, password string) error {
+	enc, err := utils.EncryptAES(password)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	return config.DB.Model(&models.Client{}).Where("client_id = ?", clientID).Updates(map[string]interface{}{
+		"wifi_ssid":         ssid,
+		"wifi_password_enc": enc,
+		"wifi_provision_at": &now,
+	}).Error
+}
+
+// ClientWifiCredentials adalah kredensial WiFi terakhir yang dikirim lewat provisioning
+type ClientWifiCredentials struct {
+	ClientID    int        `json:"client_id"`
+	SSID        string     `json:"ssid"`
+	Password    string     `json:"password"`
+	ProvisionAt *time.Time `json:"provision_at"`
+}
+
+// GetClientWifiCredentials mendekripsi password WiFi pelanggan hasil provisioning
+func GetClientWifiCredentials(client models.Client) (*ClientWifiCredentials, error) {
+	if client.WifiPasswordEnc == "" {
+		return nil, fmt.Errorf("password WiFi pelanggan belum pernah dikirim lewat provisioning")
+	}
+	password, err := utils.DecryptAES(client.WifiPasswordEnc)
+	if err != nil {
+		return nil, fmt.Errorf("gagal mendekripsi password WiFi: %v", err)
+	}
+	return &ClientWifiCredentials{
+		ClientID:    client.ClientID,
+		SSID:        client.WifiSSID,
+		Password:    password,
+		ProvisionAt: client.WifiProvisionAt,
+	}, nil
+}
+
+// DefaultWifiCredentials membentuk SSID & password WiFi dari template .env.
+// Placeholder: {name}, {client_id}, {pppoe_username}, {phone_last4}, {sn_last4}, {random8}
+func DefaultWifiCredentials(client models.Client) (string, string) {
+	ssidTpl := os.Getenv("ONU_WIFI_SSID_TEMPLATE")
+	if ssidTpl == "" {
+		ssidTpl = defaultWifiSSIDTemplate
+	}
+	passTpl := os.Getenv("ONU_WIFI_PASSWORD_TEMPLATE")
+	if passTpl == "" {
+		passTpl = defaultWifiPasswordTemplate
+	}
+
+	ssid := strings.TrimSpace(templateNonSSID.ReplaceAllString(renderClientTemplate(ssidTpl, client), ""))
+	if len(ssid) > 32 {
+		ssid = strings.TrimSpace(ssid[:32])
+	}
+	if ssid == "" {
+		ssid = "WIFI-" + strconv.Itoa(client.ClientID)
+	}
+
+	// WPA2 membutuhkan minimal 8 karakter
+	password := renderClientTemplate(passTpl, client)
+	if len(password) < 8 {
+		password += randomDigits(8 - len(password))
+	}
+	if len(password) > 63 {
+		password = password[:63]
+	}
+	return ssid, password
+}
+
+func renderClientTemplate(tpl string, client models.Client) string {
+	replacer := strings.NewReplacer(
+		"{name}", client.Name,
+		"{client_id}", strconv.Itoa(client.ClientID),
+		"{pppoe_username}", client.PppoeUsername,
+		"{phone_last4}", lastN(client.Phone, 4),
+		"{sn_last4}", lastN(client.OnuSN, 4),
+	)
+	out := replacer.Replace(tpl)
+	for strings.Contains(out, "{random8}") {
+		out = strings.Replace(out, "{random8}", randomDigits(8), 1)
+	}
+	return out
+}
+
+func lastN(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[len(s)-n:]
+}
+
+func randomDigits(n int) string {
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		d, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			sb.WriteByte('0')
+			continue
+		}
+		sb.WriteByte(byte('0' + d.Int64()))
+	}
+	return sb.String()
+}
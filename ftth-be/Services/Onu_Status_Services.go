@@ -0,0 +1,356 @@
+package services
+
+import (
+	"akane/be-ftth/config"
+	"akane/be-ftth/genieacs"
+	"akane/be-ftth/models"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Status ONU pelanggan
+const (
+	OnuOnline  = "online"
+	OnuOffline = "offline"
+	OnuUnknown = "unknown"
+)
+
+// Level topologi yang dipakai untuk mengelompokkan ONU offline
+const (
+	OutageLevelOLT = "OLT"
+	OutageLevelODC = "ODC"
+	OutageLevelODP = "ODP"
+)
+
+// OnuOfflineThresholds adalah ambang deteksi ONU offline dan gangguan massal (bisa diatur lewat .env)
+type OnuOfflineThresholds struct {
+	InformIntervalSeconds int     `json:"inform_interval_seconds"` // Periodic inform interval yang diset di ONU
+	MissedInforms         int     `json:"missed_informs"`          // ONU offline jika _lastInform lebih tua dari N interval
+	OutageMinOnus         int     `json:"outage_min_onu"`          // Minimal ONU offline di satu ODP/ODC/OLT untuk dianggap gangguan massal
+	OutagePercent         float64 `json:"outage_percent"`          // Minimal persentase ONU offline di satu ODP/ODC/OLT
+}
+
+// GetOnuOfflineThresholds membaca GENIEACS_INFORM_INTERVAL_SECONDS, ONU_OFFLINE_MISSED_INFORMS,
+// ONU_OUTAGE_MIN dan ONU_OUTAGE_PERCENT
+func GetOnuOfflineThresholds() OnuOfflineThresholds {
+	return OnuOfflineThresholds{
+		InformIntervalSeconds: envInt("GENIEACS_INFORM_INTERVAL_SECONDS", 300),
+		MissedInforms:         envInt("ONU_OFFLINE_MISSED_INFORMS", 3),
+		OutageMinOnus:         envInt("ONU_OUTAGE_MIN", 3),
+		OutagePercent:         envFloat("ONU_OUTAGE_PERCENT", 50),
+	}
+}
+
+// OfflineAfter adalah umur _lastInform maksimal sebelum ONU dianggap offline
+func (t OnuOfflineThresholds) OfflineAfter() time.Duration {
+	return time.Duration(t.InformIntervalSeconds*t.MissedInforms) * time.Second
+}
+
+// OnuStatusFromInform menentukan status ONU dari _lastInform GenieACS
+func OnuStatusFromInform(lastInform string, now time.Time, t OnuOfflineThresholds) (string, *time.Time) {
+	if lastInform == "" {
+		return OnuUnknown, nil
+	}
+	at, err := time.Parse(time.RFC3339, lastInform)
+	if err != nil {
+		return OnuUnknown, nil
+	}
+	if now.Sub(at) > t.OfflineAfter() {
+		return OnuOffline, &at
+	}
+	return OnuOnline, &at
+}
+
+// UpdateOnuStatuses memperbarui status online/offline pelanggan yang sudah terikat ke device
+// dan mencatat riwayat setiap kali status berubah. Mengembalikan jumlah perubahan status.
+func UpdateOnuStatuses(clients []models.Client, deviceByID map[string]genieacs.DeviceInfo, t OnuOfflineThresholds) (int, error) {
+	now := time.Now()
+	changed := 0
+	for i := range clients {
+		cl := &clients[i]
+		dev, ok := deviceByID[cl.GenieDeviceID]
+		if cl.GenieDeviceID == "" || !ok {
+			// Device hilang dari GenieACS: status dibiarkan, binding akan muncul di review sebagai stale
+			continue
+		}
+
+		status, lastInform := OnuStatusFromInform(dev.LastInform, now, t)
+		if status == OnuUnknown || (status == cl.OnuStatus && sameTime(lastInform, cl.OnuLastInform)) {
+			continue
+		}
+
+		updates := map[string]interface{}{"onu_status": status, "onu_last_inform": lastInform}
+		if err := config.DB.Model(cl).Updates(updates).Error; err != nil {
+			return changed, err
+		}
+		cl.OnuLastInform = lastInform
+		if status == cl.OnuStatus {
+			continue
+		}
+
+		cl.OnuStatus = status
+		config.DB.Create(&models.OnuStatusEvent{
+			ClientID:   cl.ClientID,
+			DeviceID:   cl.GenieDeviceID,
+			Status:     status,
+			LastInform: lastInform,
+		})
+		changed++
+	}
+	return changed, nil
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// OutageGroup adalah ringkasan ONU offline pada satu ODP/ODC/OLT
+type OutageGroup struct {
+	Level        string   `json:"level"` // OLT, ODC, ODP
+	NodeID       int      `json:"node_id"`
+	Name         string   `json:"name"`
+	TotalOnus    int      `json:"total_onus"`
+	OfflineOnus  int      `json:"offline_onus"`
+	Percent      float64  `json:"percent"`
+	MassOutage   bool     `json:"mass_outage"`
+	OfflineNames []string `json:"offline_clients"`
+}
+
+// OutageSummary adalah hasil pengelompokan ONU offline per level topologi
+type OutageSummary struct {
+	Thresholds   OnuOfflineThresholds `json:"thresholds"`
+	TotalOffline int                  `json:"total_offline"`
+	Groups       []OutageGroup        `json:"groups"`
+	Unmapped     []string             `json:"unmapped_offline"` // Pelanggan offline yang FAT-nya belum dipetakan ke ODP/ODC/OLT
+}
+
+// AnalyzeOnuOutages mengelompokkan ONU offline per ODP, ODC dan OLT memakai TopologyMapping.
+// Banyak ONU offline bersamaan di bawah satu node biasanya kabel putus, bukan listrik rumah pelanggan.
+func AnalyzeOnuOutages(t OnuOfflineThresholds) (*OutageSummary, error) {
+	var clients []models.Client
+	if err := config.DB.Select("client_id", "name", "fat", "onu_status").
+		Where("genie_device_id <> '' AND onu_status IN ?", []string{OnuOnline, OnuOffline}).
+		Find(&clients).Error; err != nil {
+		return nil, err
+	}
+
+	var mappings []models.TopologyMapping
+	if err := config.DB.Preload("OLTNode").Preload("ODCNode").Preload("ODPNode").Find(&mappings).Error; err != nil {
+		return nil, err
+	}
+	mappingByODP := make(map[string]models.TopologyMapping, len(mappings))
+	for _, m := range mappings {
+		if m.ODPNode != nil {
+			mappingByODP[m.ODPNode.Name] = m
+		}
+	}
+
+	summary := &OutageSummary{Thresholds: t, Groups: []OutageGroup{}, Unmapped: []string{}}
+	groups := make(map[string]*OutageGroup)
+	addTo := func(level string, node *models.NetworkNode, cl models.Client) {
+		if node == nil {
+			return
+		}
+		key := fmt.Sprintf("%s:%d", level, node.NodeID)
+		g, ok := groups[key]
+		if !ok {
+			g = &OutageGroup{Level: level, NodeID: node.NodeID, Name: node.Name, OfflineNames: []string{}}
+			groups[key] = g
+		}
+		g.TotalOnus++
+		if cl.OnuStatus == OnuOffline {
+			g.OfflineOnus++
+			g.OfflineNames = append(g.OfflineNames, cl.Name)
+		}
+	}
+
+	for _, cl := range clients {
+		if cl.OnuStatus == OnuOffline {
+			summary.TotalOffline++
+		}
+		m, ok := mappingByODP[cl.Fat]
+		if !ok {
+			if cl.OnuStatus == OnuOffline {
+				summary.Unmapped = append(summary.Unmapped, cl.Name)
+			}
+			continue
+		}
+		addTo(OutageLevelODP, m.ODPNode, cl)
+		addTo(OutageLevelODC, m.ODCNode, cl)
+		addTo(OutageLevelOLT, m.OLTNode, cl)
+	}
+
+	for _, g := range groups {
+		if g.OfflineOnus == 0 {
+			continue
+		}
+		g.Percent = float64(g.OfflineOnus) * 100 / float64(g.TotalOnus)
+		g.MassOutage = g.OfflineOnus >= t.OutageMinOnus && g.Percent >= t.OutagePercent
+		summary.Groups = append(summary.Groups, *g)
+	}
+
+	// Urutkan: gangguan massal dulu, lalu dari level tertinggi (OLT) dan jumlah offline terbanyak
+	levelRank := map[string]int{OutageLevelOLT: 0, OutageLevelODC: 1, OutageLevelODP: 2}
+	sort.Slice(summary.Groups, func(i, j int) bool {
+		a, b := summary.Groups[i], summary.Groups[j]
+		if a.MassOutage != b.MassOutage {
+			return a.MassOutage
+		}
+		if levelRank[a.Level] != levelRank[b.Level] {
+			return levelRank[a.Level] < levelRank[b.Level]
+		}
+		return a.OfflineOnus > b.OfflineOnus
+	})
+	return summary, nil
+}
+
+// Serialisasi CheckMassOutages; state alert yang sudah dikirim ada di tabel onu_outage_alerts
+var outageAlertMu sync.Mutex
+
+// CheckMassOutages mengirim alert untuk gangguan massal baru dan notifikasi pulih untuk yang sudah normal.
+// Jika satu ODC/OLT sudah gangguan massal, ODP di bawahnya tidak dikirim terpisah agar alert tidak banjir.
+func CheckMassOutages(t OnuOfflineThresholds) error {
+	summary, err := AnalyzeOnuOutages(t)
+	if err != nil {
+		return err
+	}
+
+	var mappings []models.TopologyMapping
+	if err := config.DB.Find(&mappings).Error; err != nil {
+		return err
+	}
+	parents := make(map[string][]string) // key node anak -> key node induk
+	for _, m := range mappings {
+		odp := fmt.Sprintf("%s:%d", OutageLevelODP, m.ODPNodeID)
+		odc := fmt.Sprintf("%s:%d", OutageLevelODC, m.ODCNodeID)
+		olt := fmt.Sprintf("%s:%d", OutageLevelOLT, m.OLTNodeID)
+		parents[odp] = []string{odc, olt}
+		parents[odc] = append(parents[odc], olt)
+	}
+
+	active := make(map[string]OutageGroup)
+	for _, g := range summary.Groups {
+		if g.MassOutage {
+			active[fmt.Sprintf("%s:%d", g.Level, g.NodeID)] = g
+		}
+	}
+
+	outageAlertMu.Lock()
+	defer outageAlertMu.Unlock()
+
+	var alerted []models.OnuOutageAlert
+	if err := config.DB.Find(&alerted).Error; err != nil {
+		return err
+	}
+	alertedKeys := make(map[string]bool, len(alerted))
+	for _, a := range alerted {
+		alertedKeys[a.AlertKey] = true
+	}
+
+	var downLines, upLines []string
+	for key, g := range active {
+		if alertedKeys[key] {
+			continue
+		}
+		if err := config.DB.Create(&models.OnuOutageAlert{AlertKey: key, Label: g.Level + " " + g.Name}).Error; err != nil {
+			return err
+		}
+
+		covered := false
+		for _, p := range parents[key] {
+			if _, ok := active[p]; ok {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			downLines = append(downLines, fmt.Sprintf("🔴 %s %s: %d/%d ONU offline (%.0f%%)", g.Level, html.EscapeString(g.Name), g.OfflineOnus, g.TotalOnus, g.Percent))
+		}
+	}
+	for _, a := range alerted {
+		if _, ok := active[a.AlertKey]; ok {
+			continue
+		}
+		if err := config.DB.Delete(&models.OnuOutageAlert{}, "alert_key = ?", a.AlertKey).Error; err != nil {
+			return err
+		}
+		upLines = append(upLines, "🟢 "+html.EscapeString(a.Label)+" sudah pulih")
+	}
+
+	if len(downLines) > 0 || len(upLines) > 0 {
+		sort.Strings(downLines)
+		go sendOutageAlert(downLines, upLines)
+	}
+	return nil
+}
+
+func sendOutageAlert(downLines, upLines []string) {
+	var sb strings.Builder
+	if len(downLines) > 0 {
+		sb.WriteString("🚨 <b>GANGGUAN MASSAL ONU</b> 🚨\n")
+		sb.WriteString("<i>Banyak ONU offline bersamaan, kemungkinan kabel putus</i>\n\n")
+		sb.WriteString(strings.Join(downLines, "\n"))
+		sb.WriteString("\n\n")
+	}
+	if len(upLines) > 0 {
+		sb.WriteString(strings.Join(upLines, "\n"))
+		sb.WriteString("\n\n")
+	}
+	sb.WriteString("🕒 " + time.Now().Format("02 Jan 15:04"))
+	sendTelegramHTML(sb.String())
+}
+
+// syncOnuStatuses dipanggil dari job sinkronisasi GenieACS setelah binding diperbarui
+func syncOnuStatuses(clients []models.Client, deviceByID map[string]genieacs.DeviceInfo) {
+	t := GetOnuOfflineThresholds()
+	changed, err := UpdateOnuStatuses(clients, deviceByID, t)
+	if err != nil {
+		log.Printf("[CRON] Failed to update ONU status: %v", err)
+		return
+	}
+	if changed > 0 {
+		log.Printf("[CRON] %d ONU changed online/offline status", changed)
+	}
+	if err := CheckMassOutages(t); err != nil {
+		log.Printf("[CRON] Failed to check mass outages: %v", err)
+	}
+}
+
+// RunOnuStatusJob mengecek _lastInform saja (projection ringan) agar kabel putus terdeteksi
+// lebih cepat daripada sinkronisasi RX power per jam
+func RunOnuStatusJob() (int, error) {
+	acs, err := genieacs.NewFromEnv()
+	if errors.Is(err, genieacs.ErrNotConfigured) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	devices, err := acs.FindDevices(context.Background(), nil, genieacs.Projection{"_id", "_lastInform"})
+	if err != nil {
+		return 0, err
+	}
+	deviceByID := make(map[string]genieacs.DeviceInfo, len(devices))
+	for _, d := range devices {
+		deviceByID[d.ID] = genieacs.DeviceInfo{DeviceID: d.ID, LastInform: d.LastInform}
+	}
+
+	var clients []models.Client
+	if err := config.DB.Select("client_id", "name", "genie_device_id", "onu_status", "onu_last_inform").
+		Where("genie_device_id <> ''").Find(&clients).Error; err != nil {
+		return 0, err
+	}
+
+	syncOnuStatuses(clients, deviceByID)
+	return len(clients), nil
+}
@@ -0,0 +1,389 @@
+package services
+
+import (
+	"akane/be-ftth/config"
+	"akane/be-ftth/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ImpactSourceNode  = "node"
+	ImpactSourceCable = "cable"
+
+	IncidentOpen     = "open"
+	IncidentResolved = "resolved"
+)
+
+const defaultIncidentNotifyTemplate = "Yth. {name}, layanan internet Anda sedang terganggu karena gangguan jaringan ({title}). Teknisi kami sedang menangani. Mohon maaf atas ketidaknyamanannya."
+
+// ImpactedClient adalah node CLIENT yang kehilangan jalur ke OLT/router
+type ImpactedClient struct {
+	NodeID    int    `json:"node_id"`
+	NodeName  string `json:"node_name"`
+	ClientID  *int   `json:"client_id"` // nil jika node belum terhubung ke pelanggan CRM
+	Name      string `json:"name"`
+	Phone     string `json:"phone"`
+	Address   string `json:"address"`
+	OnuStatus string `json:"onu_status"`
+	ODPNodeID int    `json:"odp_node_id"`
+	ODPName   string `json:"odp_name"`
+}
+
+// ImpactODP adalah jumlah pelanggan terdampak per ODP
+type ImpactODP struct {
+	NodeID  int    `json:"node_id"` // 0 jika ODP hanya diketahui dari field fat pelanggan
+	Name    string `json:"name"`
+	Clients int    `json:"clients"`
+}
+
+// ImpactResult adalah hasil analisis dampak satu node/kabel yang rusak
+type ImpactResult struct {
+	SourceType    string           `json:"source_type"`
+	SourceID      int              `json:"source_id"`
+	SourceName    string           `json:"source_name"`
+	TotalClients  int              `json:"total_clients"`
+	LinkedClients int              `json:"linked_clients"` // Yang terhubung ke data pelanggan (punya nomor HP)
+	ODPs          []ImpactODP      `json:"odps"`
+	Clients       []ImpactedClient `json:"clients"`
+}
+
+// impactRoots mengembalikan titik sumber sinyal: semua ROUTER, ditambah OLT yang jalurnya tidak sampai ke router
+func (g *TopologyGraph) impactRoots() []int {
+	var roots []int
+	for id, n := range g.Nodes {
+		switch n.Type {
+		case models.TypeRouter:
+			roots = append(roots, id)
+		case models.TypeOLT:
+			if trace, err := g.TraceUpstream(id); err == nil && trace.Router == nil {
+				roots = append(roots, id)
+			}
+		}
+	}
+	return roots
+}
+
+// reachable menelusuri graf dari roots tanpa melewati node/kabel yang rusak (-1 = tidak ada)
+func (g *TopologyGraph) reachable(roots []int, failedNode, failedCable int) map[int]bool {
+	seen := make(map[int]bool, len(g.Nodes))
+	queue := make([]int, 0, len(roots))
+	for _, r := range roots {
+		if r != failedNode {
+			seen[r] = true
+			queue = append(queue, r)
+		}
+	}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, e := range g.Adj[cur] {
+			if e.Cable.CableID == failedCable || e.To == failedNode || seen[e.To] {
+				continue
+			}
+			seen[e.To] = true
+			queue = append(queue, e.To)
+		}
+	}
+	return seen
+}
+
+// AffectedNodes mengembalikan node yang tadinya terhubung ke OLT/router tetapi terputus jika
+// node/kabel tersebut rusak. Jalur cadangan (ring) otomatis diperhitungkan.
+func (g *TopologyGraph) AffectedNodes(failedNode, failedCable int) ([]int, error) {
+	roots := g.impactRoots()
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("topologi belum memiliki node OLT/ROUTER")
+	}
+
+	before := g.reachable(roots, -1, -1)
+	after := g.reachable(roots, failedNode, failedCable)
+
+	var affected []int
+	for id := range before {
+		if !after[id] {
+			affected = append(affected, id)
+		}
+	}
+	sort.Ints(affected)
+	return affected, nil
+}
+
+// nearestODP mencari ODP terdekat dari node CLIENT, menembus TB (terminal box) di antaranya
+func (g *TopologyGraph) nearestODP(nodeID int) (models.NetworkNode, bool) {
+	seen := map[int]bool{nodeID: true}
+	queue := []int{nodeID}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, e := range g.Adj[cur] {
+			if seen[e.To] {
+				continue
+			}
+			seen[e.To] = true
+			n := g.Nodes[e.To]
+			switch n.Type {
+			case models.TypeODP:
+				return n, true
+			case models.TypeTB:
+				queue = append(queue, e.To)
+			}
+		}
+	}
+	return models.NetworkNode{}, false
+}
+
+// AnalyzeImpact menghitung pelanggan yang terdampak jika node atau kabel rusak
+func AnalyzeImpact(sourceType string, sourceID int) (*ImpactResult, error) {
+	g, err := LoadTopologyGraph()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ImpactResult{SourceType: sourceType, SourceID: sourceID, ODPs: []ImpactODP{}, Clients: []ImpactedClient{}}
+	failedNode, failedCable := -1, -1
+	switch sourceType {
+	case ImpactSourceNode:
+		node, ok := g.Nodes[sourceID]
+		if !ok {
+			return nil, fmt.Errorf("node tidak ditemukan")
+		}
+		failedNode = sourceID
+		result.SourceName = node.Name
+	case ImpactSourceCable:
+		cable, ok := g.Cables[sourceID]
+		if !ok {
+			return nil, fmt.Errorf("kabel tidak ditemukan")
+		}
+		failedCable = sourceID
+		result.SourceName = fmt.Sprintf("%s - %s", g.Nodes[cable.SourceNodeID].Name, g.Nodes[cable.TargetNodeID].Name)
+	default:
+		return nil, fmt.Errorf("source_type harus node atau cable")
+	}
+
+	affected, err := g.AffectedNodes(failedNode, failedCable)
+	if err != nil {
+		return nil, err
+	}
+
+	var clientNodeIDs []int
+	for _, id := range affected {
+		if g.Nodes[id].Type == models.TypeClient {
+			clientNodeIDs = append(clientNodeIDs, id)
+		}
+	}
+	if len(clientNodeIDs) == 0 {
+		return result, nil
+	}
+
+	clients, err := clientsForNodes(clientNodeIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	odpIndex := make(map[string]int)
+	for _, nodeID := range clientNodeIDs {
+		node := g.Nodes[nodeID]
+		ic := ImpactedClient{NodeID: nodeID, NodeName: node.Name, Name: node.Name}
+
+		if cl, ok := clients[nodeID]; ok {
+			clientID := cl.ClientID
+			ic.ClientID = &clientID
+			ic.Name = cl.Name
+			ic.Phone = cl.Phone
+			ic.Address = cl.Address
+			ic.OnuStatus = cl.OnuStatus
+			ic.ODPName = cl.Fat
+			result.LinkedClients++
+		}
+		// ODP dari graf lebih akurat daripada field fat yang diisi manual
+		if odp, ok := g.nearestODP(nodeID); ok {
+			ic.ODPNodeID = odp.NodeID
+			ic.ODPName = odp.Name
+		}
+
+		key := ic.ODPName
+		if ic.ODPNodeID != 0 {
+			key = strconv.Itoa(ic.ODPNodeID)
+		}
+		idx, ok := odpIndex[key]
+		if !ok {
+			idx = len(result.ODPs)
+			odpIndex[key] = idx
+			result.ODPs = append(result.ODPs, ImpactODP{NodeID: ic.ODPNodeID, Name: ic.ODPName})
+		}
+		result.ODPs[idx].Clients++
+		result.Clients = append(result.Clients, ic)
+	}
+
+	result.TotalClients = len(result.Clients)
+	sort.Slice(result.ODPs, func(i, j int) bool { return result.ODPs[i].Clients > result.ODPs[j].Clients })
+	return result, nil
+}
+
+// IncidentRequest adalah data untuk membuat insiden dari analisis dampak
+type IncidentRequest struct {
+	SourceType  string `json:"source_type"` // node, cable
+	SourceID    int    `json:"source_id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Notify      bool   `json:"notify"` // Kirim notifikasi ke pelanggan terdampak
+}
+
+// CreateIncident menyimpan insiden beserta daftar pelanggan terdampak. Notifikasi pelanggan
+// (jika diminta) dikirim di background agar request tidak menunggu gateway.
+func CreateIncident(req IncidentRequest, executor string) (*models.Incident, error) {
+	impact, err := AnalyzeImpact(req.SourceType, req.SourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		title = "Gangguan " + impact.SourceName
+	}
+	incident := models.Incident{
+		Title:           title,
+		Description:     req.Description,
+		SourceType:      impact.SourceType,
+		SourceID:        impact.SourceID,
+		SourceName:      impact.SourceName,
+		Status:          IncidentOpen,
+		AffectedClients: impact.TotalClients,
+		AffectedODPs:    len(impact.ODPs),
+		NotifyCustomers: req.Notify,
+		CreatedBy:       executor,
+	}
+	for _, ic := range impact.Clients {
+		incident.Clients = append(incident.Clients, models.IncidentClient{
+			NodeID:    ic.NodeID,
+			ClientID:  ic.ClientID,
+			Name:      ic.Name,
+			Phone:     ic.Phone,
+			ODPNodeID: ic.ODPNodeID,
+			ODPName:   ic.ODPName,
+		})
+	}
+
+	if err := config.DB.Create(&incident).Error; err != nil {
+		return nil, err
+	}
+
+	sendIncidentAlert(incident, impact)
+	if req.Notify {
+		go notifyIncidentCustomers(incident)
+	}
+	return &incident, nil
+}
+
+// ResolveIncident menandai insiden selesai
+func ResolveIncident(incidentID int, executor string) (*models.Incident, error) {
+	var incident models.Incident
+	if err := config.DB.First(&incident, "incident_id = ?", incidentID).Error; err != nil {
+		return nil, fmt.Errorf("insiden tidak ditemukan")
+	}
+	if incident.Status == IncidentResolved {
+		return nil, fmt.Errorf("insiden sudah diselesaikan")
+	}
+
+	now := time.Now()
+	if err := config.DB.Model(&incident).Updates(map[string]interface{}{
+		"status":      IncidentResolved,
+		"resolved_by": executor,
+		"resolved_at": now,
+	}).Error; err != nil {
+		return nil, err
+	}
+	sendTelegramHTML(fmt.Sprintf("✅ <b>INSIDEN SELESAI</b> ✅\n\n%s\n👥 %d pelanggan\n🕒 %s",
+		html.EscapeString(incident.Title), incident.AffectedClients, now.Format("02 Jan 15:04")))
+	return &incident, nil
+}
+
+func sendIncidentAlert(incident models.Incident, impact *ImpactResult) {
+	lines := make([]string, 0, len(impact.ODPs))
+	for i, odp := range impact.ODPs {
+		if i == 10 {
+			lines = append(lines, fmt.Sprintf("... dan %d ODP lainnya", len(impact.ODPs)-10))
+			break
+		}
+		lines = append(lines, fmt.Sprintf("• %s: %d pelanggan", html.EscapeString(odp.Name), odp.Clients))
+	}
+	sendTelegramHTML(fmt.Sprintf("🚨 <b>INSIDEN JARINGAN</b> 🚨\n\n%s\n📍 %s %s\n👥 %d pelanggan terdampak\n\n%s\n\n🕒 %s",
+		html.EscapeString(incident.Title), html.EscapeString(incident.SourceType), html.EscapeString(incident.SourceName), incident.AffectedClients,
+		strings.Join(lines, "\n"), time.Now().Format("02 Jan 15:04")))
+}
+
+// notifyIncidentCustomers mengirim pesan ke setiap pelanggan terdampak lewat CUSTOMER_NOTIFY_WEBHOOK_URL
+// (gateway WhatsApp/SMS). Body webhook: {"phone": "...", "message": "..."}.
+func notifyIncidentCustomers(incident models.Incident) {
+	webhook := os.Getenv("CUSTOMER_NOTIFY_WEBHOOK_URL")
+	if webhook == "" {
+		log.Printf("[INCIDENT] CUSTOMER_NOTIFY_WEBHOOK_URL kosong, notifikasi insiden %d dilewati", incident.IncidentID)
+		return
+	}
+	template := os.Getenv("INCIDENT_NOTIFY_TEMPLATE")
+	if template == "" {
+		template = defaultIncidentNotifyTemplate
+	}
+
+	var targets []models.IncidentClient
+	for _, ic := range incident.Clients {
+		if ic.ClientID != nil && ic.Phone != "" {
+			targets = append(targets, ic)
+		}
+	}
+	if len(targets) == 0 {
+		return
+	}
+
+	opts := PoolOptions{Workers: envInt("CUSTOMER_NOTIFY_WORKERS", 5), Timeout: 15 * time.Second}
+	errs := RunBounded(targets, opts, func(ctx context.Context, ic models.IncidentClient) error {
+		msg := strings.NewReplacer("{name}", ic.Name, "{title}", incident.Title, "{odp}", ic.ODPName).Replace(template)
+		return postCustomerNotification(ctx, webhook, ic.Phone, msg)
+	})
+
+	notified := 0
+	for i, ic := range targets {
+		updates := map[string]interface{}{"notified": errs[i] == nil, "notify_error": ""}
+		if errs[i] != nil {
+			updates["notify_error"] = errs[i].Error()
+		} else {
+			notified++
+		}
+		config.DB.Model(&models.IncidentClient{}).Where("id = ?", ic.ID).Updates(updates)
+	}
+	config.DB.Model(&models.Incident{}).Where("incident_id = ?", incident.IncidentID).Update("notified_count", notified)
+	log.Printf("[INCIDENT] Notifikasi insiden %d terkirim ke %d/%d pelanggan", incident.IncidentID, notified, len(targets))
+}
+
+func postCustomerNotification(ctx context.Context, webhook, phone, message string) error {
+	body, _ := json.Marshal(map[string]string{"phone": phone, "message": message})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token := os.Getenv("CUSTOMER_NOTIFY_WEBHOOK_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("gateway notifikasi mengembalikan status %d", resp.StatusCode)
+	}
+	return nil
+}
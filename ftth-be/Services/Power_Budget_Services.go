@@ -0,0 +1,339 @@
+package services
+
+import (
+	"akane/be-ftth/config"
+	"akane/be-ftth/models"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	BudgetOK            = "ok"
+	BudgetMismatch      = "mismatch"       // RX terukur jauh lebih buruk dari perkiraan (konektor kotor, bending, sambungan jelek)
+	BudgetAboveExpected = "above_expected" // RX terukur jauh lebih baik, biasanya data rasio splitter/panjang kabel di peta salah
+	BudgetNoMeasurement = "no_measurement"
+	BudgetNoPath        = "no_path" // Jalur kabel tidak sampai ke OLT
+)
+
+const (
+	defaultFiberDbPerKm  = 0.35 // Redaman single mode 1490nm
+	defaultFiberAttenEnv = "BACKBONE=0.35,DISTRIBUSI=0.35,DROP CLIENT=0.4"
+)
+
+// Redaman splitter PLC standar (dB) per rasio
+var splitterLossTable = map[int]float64{2: 3.7, 4: 7.3, 8: 10.5, 16: 13.7, 32: 17.1, 64: 20.5}
+
+// PowerBudgetConfig adalah parameter perhitungan loss budget (bisa diatur lewat .env)
+type PowerBudgetConfig struct {
+	OLTTxDbm           float64            `json:"olt_tx_dbm"`        // Dipakai jika OLT tidak punya tx_power_dbm
+	ConnectorLossDB    float64            `json:"connector_loss_db"` // Per konektor
+	SpliceLossDB       float64            `json:"splice_loss_db"`    // Per sambungan (TB / joint closure)
+	MismatchDB         float64            `json:"mismatch_db"`       // Selisih terukur vs perkiraan yang dianggap bermasalah
+	DefaultDbPerKm     float64            `json:"default_db_per_km"`
+	AttenuationDbPerKm map[string]float64 `json:"attenuation_db_per_km"` // Per tipe kabel (huruf besar)
+}
+
+// GetPowerBudgetConfig membaca POWER_BUDGET_OLT_TX_DBM, POWER_BUDGET_CONNECTOR_LOSS_DB, POWER_BUDGET_SPLICE_LOSS_DB,
+// POWER_BUDGET_MISMATCH_DB, FIBER_ATTENUATION_DEFAULT_DB_PER_KM dan FIBER_ATTENUATION_DB_PER_KM ("TIPE=dB/km,...")
+func GetPowerBudgetConfig() PowerBudgetConfig {
+	cfg := PowerBudgetConfig{
+		OLTTxDbm:           envFloat("POWER_BUDGET_OLT_TX_DBM", 3),
+		ConnectorLossDB:    envFloat("POWER_BUDGET_CONNECTOR_LOSS_DB", 0.5),
+		SpliceLossDB:       envFloat("POWER_BUDGET_SPLICE_LOSS_DB", 0.1),
+		MismatchDB:         envFloat("POWER_BUDGET_MISMATCH_DB", 3),
+		DefaultDbPerKm:     envFloat("FIBER_ATTENUATION_DEFAULT_DB_PER_KM", defaultFiberDbPerKm),
+		AttenuationDbPerKm: make(map[string]float64),
+	}
+
+	raw := os.Getenv("FIBER_ATTENUATION_DB_PER_KM")
+	if strings.TrimSpace(raw) == "" {
+		raw = defaultFiberAttenEnv
+	}
+	for _, pair := range strings.Split(raw, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		if v, err := strconv.ParseFloat(strings.TrimSpace(kv[1]), 64); err == nil {
+			cfg.AttenuationDbPerKm[strings.ToUpper(strings.TrimSpace(kv[0]))] = v
+		}
+	}
+	return cfg
+}
+
+// attenuation mengembalikan dB/km untuk tipe kabel. Tipe kustom yang mengandung "DROP" memakai nilai DROP CLIENT.
+func (cfg PowerBudgetConfig) attenuation(cableType string) float64 {
+	t := strings.ToUpper(strings.TrimSpace(cableType))
+	if v, ok := cfg.AttenuationDbPerKm[t]; ok {
+		return v
+	}
+	if strings.Contains(t, "DROP") {
+		if v, ok := cfg.AttenuationDbPerKm["DROP CLIENT"]; ok {
+			return v
+		}
+	}
+	return cfg.DefaultDbPerKm
+}
+
+// SplitterLossDB mengembalikan redaman splitter 1:ratio. Rasio di luar tabel dihitung dari 10*log10(N) + excess loss.
+func SplitterLossDB(ratio int) float64 {
+	if ratio <= 1 {
+		return 0
+	}
+	if v, ok := splitterLossTable[ratio]; ok {
+		return v
+	}
+	return 10*math.Log10(float64(ratio)) + 1
+}
+
+// LossItem adalah satu komponen redaman pada jalur
+type LossItem struct {
+	Kind   string  `json:"kind"` // cable, splitter, connector, splice
+	NodeID int     `json:"node_id,omitempty"`
+	Cable  int     `json:"cable_id,omitempty"`
+	Label  string  `json:"label"`
+	LossDB float64 `json:"loss_db"`
+}
+
+// ClientPowerBudget adalah perkiraan RX satu node CLIENT dibanding RX terukur dari GenieACS
+type ClientPowerBudget struct {
+	NodeID        int        `json:"node_id"`
+	NodeName      string     `json:"node_name"`
+	ClientID      *int       `json:"client_id"`
+	ClientName    string     `json:"client_name"`
+	OLT           *TraceNode `json:"olt"`
+	ODP           *TraceNode `json:"odp"`
+	LengthMeter   float64    `json:"length_meter"`
+	TxPowerDbm    float64    `json:"tx_power_dbm"`
+	TotalLossDB   float64    `json:"total_loss_db"`
+	ExpectedRxDbm float64    `json:"expected_rx_dbm"`
+	MeasuredRxDbm *float64   `json:"measured_rx_dbm"`
+	DeltaDB       *float64   `json:"delta_db"` // Terukur - perkiraan (negatif = lebih buruk dari perkiraan)
+	Status        string     `json:"status"`
+	Losses        []LossItem `json:"losses,omitempty"`
+}
+
+// powerBudgetAssets adalah detail aset yang dibutuhkan perhitungan (per node_id)
+type powerBudgetAssets struct {
+	olts map[int]models.OLT
+	odcs map[int]models.ODC
+	odps map[int]models.ODP
+}
+
+func loadPowerBudgetAssets() (*powerBudgetAssets, error) {
+	a := &powerBudgetAssets{olts: map[int]models.OLT{}, odcs: map[int]models.ODC{}, odps: map[int]models.ODP{}}
+
+	var olts []models.OLT
+	if err := config.DB.Find(&olts).Error; err != nil {
+		return nil, err
+	}
+	for _, o := range olts {
+		a.olts[o.NodeID] = o
+	}
+	var odcs []models.ODC
+	if err := config.DB.Find(&odcs).Error; err != nil {
+		return nil, err
+	}
+	for _, o := range odcs {
+		a.odcs[o.NodeID] = o
+	}
+	var odps []models.ODP
+	if err := config.DB.Find(&odps).Error; err != nil {
+		return nil, err
+	}
+	for _, o := range odps {
+		a.odps[o.NodeID] = o
+	}
+	return a, nil
+}
+
+// nodeLosses mengembalikan redaman yang terjadi di dalam node (splitter, konektor masuk/keluar, sambungan)
+func (a *powerBudgetAssets) nodeLosses(n TraceNode, cfg PowerBudgetConfig) []LossItem {
+	connector := func(count int) LossItem {
+		return LossItem{Kind: "connector", NodeID: n.NodeID, Label: fmt.Sprintf("%d konektor %s", count, n.Name), LossDB: float64(count) * cfg.ConnectorLossDB}
+	}
+	switch n.Type {
+	case models.TypeOLT:
+		return []LossItem{connector(1)}
+	case models.TypeClient:
+		return []LossItem{connector(1)}
+	case models.TypeTB:
+		return []LossItem{{Kind: "splice", NodeID: n.NodeID, Label: "Sambungan " + n.Name, LossDB: cfg.SpliceLossDB}}
+	case models.TypeODC:
+		items := []LossItem{connector(2)}
+		if ratio := a.odcs[n.NodeID].SplitterRatio; ratio > 1 {
+			items = append(items, LossItem{Kind: "splitter", NodeID: n.NodeID, Label: fmt.Sprintf("Splitter 1:%d %s", ratio, n.Name), LossDB: SplitterLossDB(ratio)})
+		}
+		return items
+	case models.TypeODP:
+		items := []LossItem{connector(2)}
+		odp := a.odps[n.NodeID]
+		ratio := odp.SplitterRatio
+		if ratio == 0 {
+			ratio = odp.TotalPorts
+		}
+		if ratio > 1 {
+			items = append(items, LossItem{Kind: "splitter", NodeID: n.NodeID, Label: fmt.Sprintf("Splitter 1:%d %s", ratio, n.Name), LossDB: SplitterLossDB(ratio)})
+		}
+		return items
+	}
+	return nil
+}
+
+// budgetFromTrace menghitung perkiraan RX dari hasil trace node CLIENT sampai OLT
+func (a *powerBudgetAssets) budgetFromTrace(trace *TraceResult, cfg PowerBudgetConfig) ClientPowerBudget {
+	b := ClientPowerBudget{NodeID: trace.Start.NodeID, NodeName: trace.Start.Name, ODP: trace.ODP, OLT: trace.OLT}
+	if trace.OLT == nil {
+		b.Status = BudgetNoPath
+		return b
+	}
+
+	b.TxPowerDbm = cfg.OLTTxDbm
+	if tx := a.olts[trace.OLT.NodeID].TxPowerDbm; tx != nil {
+		b.TxPowerDbm = *tx
+	}
+
+	// Hop urut dari CLIENT ke hulu; berhenti di OLT (router di atasnya tidak ikut jalur optik)
+	for _, hop := range trace.Hops {
+		if hop.CableID != 0 {
+			b.LengthMeter += hop.LengthMeter
+			b.Losses = append(b.Losses, LossItem{
+				Kind:   "cable",
+				Cable:  hop.CableID,
+				Label:  fmt.Sprintf("%s %.0f m", hop.CableType, hop.LengthMeter),
+				LossDB: hop.LengthMeter / 1000 * cfg.attenuation(hop.CableType),
+			})
+		}
+		b.Losses = append(b.Losses, a.nodeLosses(hop.Node, cfg)...)
+		if hop.Node.NodeID == trace.OLT.NodeID {
+			break
+		}
+	}
+
+	for _, l := range b.Losses {
+		b.TotalLossDB += l.LossDB
+	}
+	b.TotalLossDB = math.Round(b.TotalLossDB*100) / 100
+	b.ExpectedRxDbm = math.Round((b.TxPowerDbm-b.TotalLossDB)*100) / 100
+	b.Status = BudgetNoMeasurement
+	return b
+}
+
+// compareMeasured mengisi RX terukur dan status perbandingan
+func (b *ClientPowerBudget) compareMeasured(rxPower string, cfg PowerBudgetConfig) {
+	if b.Status == BudgetNoPath {
+		return
+	}
+	var measured float64
+	if _, err := fmt.Sscanf(rxPower, "%f", &measured); err != nil || measured == 0 {
+		return
+	}
+	delta := math.Round((measured-b.ExpectedRxDbm)*100) / 100
+	b.MeasuredRxDbm = &measured
+	b.DeltaDB = &delta
+	switch {
+	case delta < -cfg.MismatchDB:
+		b.Status = BudgetMismatch
+	case delta > cfg.MismatchDB:
+		b.Status = BudgetAboveExpected
+	default:
+		b.Status = BudgetOK
+	}
+}
+
+// PowerBudgetReport adalah hasil perhitungan loss budget untuk semua node CLIENT
+type PowerBudgetReport struct {
+	Config     PowerBudgetConfig   `json:"config"`
+	Total      int                 `json:"total"`
+	Mismatches int                 `json:"mismatches"`
+	Clients    []ClientPowerBudget `json:"clients"`
+}
+
+// AnalyzePowerBudget menghitung perkiraan RX setiap node CLIENT dan membandingkannya dengan RX dari GenieACS
+func AnalyzePowerBudget() (*PowerBudgetReport, error) {
+	cfg := GetPowerBudgetConfig()
+	g, err := LoadTopologyGraph()
+	if err != nil {
+		return nil, err
+	}
+	assets, err := loadPowerBudgetAssets()
+	if err != nil {
+		return nil, err
+	}
+
+	var nodeIDs []int
+	for id, n := range g.Nodes {
+		if n.Type == models.TypeClient {
+			nodeIDs = append(nodeIDs, id)
+		}
+	}
+	sort.Ints(nodeIDs)
+	clients, err := clientsForNodes(nodeIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &PowerBudgetReport{Config: cfg, Clients: make([]ClientPowerBudget, 0, len(nodeIDs))}
+	for _, id := range nodeIDs {
+		trace, err := g.TraceUpstream(id)
+		if err != nil {
+			return nil, err
+		}
+		b := assets.budgetFromTrace(trace, cfg)
+		b.Losses = nil // Rincian hanya di endpoint per node agar respons tidak besar
+		if cl, ok := clients[id]; ok {
+			clientID := cl.ClientID
+			b.ClientID = &clientID
+			b.ClientName = cl.Name
+			b.compareMeasured(cl.RxPower, cfg)
+		}
+		if b.Status == BudgetMismatch {
+			report.Mismatches++
+		}
+		report.Clients = append(report.Clients, b)
+	}
+	report.Total = len(report.Clients)
+	return report, nil
+}
+
+// GetNodePowerBudget menghitung loss budget satu node CLIENT beserta rincian redamannya
+func GetNodePowerBudget(nodeID int) (*ClientPowerBudget, error) {
+	cfg := GetPowerBudgetConfig()
+	g, err := LoadTopologyGraph()
+	if err != nil {
+		return nil, err
+	}
+	node, ok := g.Nodes[nodeID]
+	if !ok {
+		return nil, fmt.Errorf("node tidak ditemukan")
+	}
+	if node.Type != models.TypeClient {
+		return nil, fmt.Errorf("loss budget hanya untuk node CLIENT")
+	}
+	assets, err := loadPowerBudgetAssets()
+	if err != nil {
+		return nil, err
+	}
+
+	trace, err := g.TraceUpstream(nodeID)
+	if err != nil {
+		return nil, err
+	}
+	b := assets.budgetFromTrace(trace, cfg)
+
+	clients, err := clientsForNodes([]int{nodeID})
+	if err != nil {
+		return nil, err
+	}
+	if cl, ok := clients[nodeID]; ok {
+		clientID := cl.ClientID
+		b.ClientID = &clientID
+		b.ClientName = cl.Name
+		b.compareMeasured(cl.RxPower, cfg)
+	}
+	return &b, nil
+}
@@ -0,0 +1,280 @@
+package services
+
+import (
+	"akane/be-ftth/config"
+	"akane/be-ftth/models"
+	"akane/be-ftth/utils"
+	"context"
+	"fmt"
+	"html"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-routeros/routeros"
+	"github.com/go-routeros/routeros/proto"
+)
+
+// Ambang batas default alert kesehatan router (bisa di-override lewat .env)
+const (
+	defaultCPUAlertPercent     = 90
+	defaultMemoryAlertPercent  = 10 // Sisa memori (persen) di bawah nilai ini dianggap kritis
+	defaultTempAlertCelsius    = 70
+	defaultVoltageAlertMinimum = 0 // 0 = alert voltase dimatikan
+)
+
+// HealthThresholds adalah ambang batas yang dipakai job untuk memicu alert
+type HealthThresholds struct {
+	CPUPercent     int     `json:"cpu_percent"`
+	MemoryPercent  int     `json:"memory_free_percent"`
+	TempCelsius    float64 `json:"temperature_celsius"`
+	VoltageMinimum float64 `json:"voltage_minimum"`
+}
+
+// Menyimpan daftar masalah terakhir per router agar alert tidak dikirim berulang tiap sampel
+var (
+	healthAlertMu    sync.Mutex
+	healthAlertState = make(map[string]string)
+)
+
+// GetHealthThresholds membaca ambang batas dari environment, fallback ke nilai default
+func GetHealthThresholds() HealthThresholds {
+	return HealthThresholds{
+		CPUPercent:     envInt("ROUTER_CPU_ALERT_PERCENT", defaultCPUAlertPercent),
+		MemoryPercent:  envInt("ROUTER_MEMORY_ALERT_PERCENT", defaultMemoryAlertPercent),
+		TempCelsius:    envFloat("ROUTER_TEMP_ALERT_CELSIUS", defaultTempAlertCelsius),
+		VoltageMinimum: envFloat("ROUTER_VOLTAGE_ALERT_MIN", defaultVoltageAlertMinimum),
+	}
+}
+
+// ==========================================
+// JOB: ROUTER HEALTH (CPU, Memory, Suhu, Voltase)
+// ==========================================
+func RunRouterHealthJob() (int, error) {
+	fmt.Println("[JOB-HEALTH] Memulai pengambilan health router...", time.Now())
+
+	var routers []models.Router
+	if err := config.DB.Where("is_deleted = ?", 0).Find(&routers).Error; err != nil {
+		return 0, err
+	}
+
+	thresholds := GetHealthThresholds()
+	var sampled int64
+
+	RunBounded(routers, RouterPoolOptions(), func(ctx context.Context, router models.Router) error {
+		pass, err := utils.DecryptAES(router.RouterPassword)
+		if err != nil {
+			return err
+		}
+
+		client, err := DialMikrotik(ctx, router.RouterAddress, router.RouterPort, router.RouterUsername, pass, router.RouterRemoteType)
+		if err != nil {
+			fmt.Printf("[JOB-HEALTH] Gagal konek %s: %v\n", router.RouterName, err)
+			recordRouterReachable(router.RouterID.String(), router.RouterName, false)
+			return err
+		}
+		recordRouterReachable(router.RouterID.String(), router.RouterName, true)
+
+		sample, err := collectRouterHealth(client)
+		client.Close()
+		if err != nil {
+			fmt.Printf("[JOB-HEALTH] Gagal ambil health %s: %v\n", router.RouterName, err)
+			return err
+		}
+
+		sample.RouterID = router.RouterID
+		sample.RecordedAt = time.Now()
+		if err := config.DB.Create(sample).Error; err != nil {
+			fmt.Printf("[JOB-HEALTH] Gagal simpan health %s: %v\n", router.RouterName, err)
+			return err
+		}
+
+		atomic.AddInt64(&sampled, 1)
+		checkHealthThresholds(router, sample, thresholds)
+		return nil
+	})
+
+	fmt.Println("[JOB-HEALTH] Selesai.")
+	return int(sampled), nil
+}
+
+// collectRouterHealth mengambil /system/resource dan /system/health dari satu koneksi router
+func collectRouterHealth(client *routeros.Client) (*models.RouterHealth, error) {
+	replyResource, err := client.Run("/system/resource/print")
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil resource: %v", err)
+	}
+
+	sample := &models.RouterHealth{}
+	if len(replyResource.Re) > 0 {
+		res := replyResource.Re[0].Map
+		sample.CPULoad, _ = strconv.Atoi(res["cpu-load"])
+		sample.FreeMemory, _ = strconv.ParseInt(res["free-memory"], 10, 64)
+		sample.TotalMemory, _ = strconv.ParseInt(res["total-memory"], 10, 64)
+		sample.FreeHDD, _ = strconv.ParseInt(res["free-hdd-space"], 10, 64)
+		sample.TotalHDD, _ = strconv.ParseInt(res["total-hdd-space"], 10, 64)
+		sample.Uptime = res["uptime"]
+	}
+
+	// /system/health tidak tersedia di semua board (misal CHR), jadi error di sini tidak fatal
+	replyHealth, err := client.Run("/system/health/print")
+	if err == nil {
+		parseSystemHealth(replyHealth.Re, sample)
+	}
+
+	return sample, nil
+}
+
+// parseSystemHealth mendukung dua format:
+// RouterOS v6 -> satu baris berisi key "temperature", "voltage", "psu1-state", ...
+// RouterOS v7 -> banyak baris berisi "name" dan "value" (misal name=cpu-temperature value=45)
+func parseSystemHealth(rows []*proto.Sentence, sample *models.RouterHealth) {
+	values := make(map[string]string)
+	for _, re := range rows {
+		if name, ok := re.Map["name"]; ok {
+			values[name] = re.Map["value"]
+			continue
+		}
+		for k, v := range re.Map {
+			values[k] = v
+		}
+	}
+
+	for _, key := range []string{"temperature", "cpu-temperature", "board-temperature1", "sfp-temperature"} {
+		if v, ok := values[key]; ok {
+			if t, err := strconv.ParseFloat(v, 64); err == nil {
+				sample.Temperature = t
+				break
+			}
+		}
+	}
+
+	if v, ok := values["voltage"]; ok {
+		sample.Voltage, _ = strconv.ParseFloat(v, 64)
+	}
+
+	var psu []string
+	for k, v := range values {
+		if strings.HasPrefix(k, "psu") && strings.HasSuffix(k, "-state") {
+			psu = append(psu, strings.TrimSuffix(k, "-state")+"="+v)
+		}
+	}
+	sort.Strings(psu)
+	sample.PSUState = strings.Join(psu, ",")
+}
+
+// HealthIssue adalah satu metrik yang melewati ambang batas
+type HealthIssue struct {
+	Metric  string `json:"metric"` // cpu, memory, temperature, voltage, psu1, ...
+	Message string `json:"message"`
+}
+
+// checkHealthThresholds mengirim alert Telegram jika ada metrik yang melewati ambang batas.
+// Alert hanya dikirim saat jenis masalah berubah, dan notifikasi pulih dikirim saat semua normal kembali.
+func checkHealthThresholds(router models.Router, sample *models.RouterHealth, t HealthThresholds) {
+	issues := EvaluateRouterHealth(sample, t)
+
+	var metrics, lines []string
+	for _, issue := range issues {
+		metrics = append(metrics, issue.Metric)
+		lines = append(lines, issue.Message)
+	}
+
+	key := router.RouterID.String()
+	current := strings.Join(metrics, ",")
+
+	healthAlertMu.Lock()
+	previous := healthAlertState[key]
+	healthAlertState[key] = current
+	healthAlertMu.Unlock()
+
+	if current == previous {
+		return
+	}
+
+	if len(issues) == 0 {
+		go sendRouterHealthRecovered(router.RouterName)
+		return
+	}
+	go sendRouterHealthAlert(router.RouterName, lines)
+}
+
+// EvaluateRouterHealth mengembalikan daftar masalah (kosong jika sehat) untuk satu sampel
+func EvaluateRouterHealth(sample *models.RouterHealth, t HealthThresholds) []HealthIssue {
+	var issues []HealthIssue
+
+	if t.CPUPercent > 0 && sample.CPULoad >= t.CPUPercent {
+		issues = append(issues, HealthIssue{"cpu", fmt.Sprintf("🔥 CPU Load %d%% (batas %d%%)", sample.CPULoad, t.CPUPercent)})
+	}
+
+	if t.MemoryPercent > 0 && sample.TotalMemory > 0 {
+		freePercent := int(sample.FreeMemory * 100 / sample.TotalMemory)
+		if freePercent < t.MemoryPercent {
+			issues = append(issues, HealthIssue{"memory", fmt.Sprintf("🧠 Sisa Memori %d%% (batas %d%%)", freePercent, t.MemoryPercent)})
+		}
+	}
+
+	if t.TempCelsius > 0 && sample.Temperature >= t.TempCelsius {
+		issues = append(issues, HealthIssue{"temperature", fmt.Sprintf("🌡️ Suhu %.1f°C (batas %.1f°C)", sample.Temperature, t.TempCelsius)})
+	}
+
+	if t.VoltageMinimum > 0 && sample.Voltage > 0 && sample.Voltage < t.VoltageMinimum {
+		issues = append(issues, HealthIssue{"voltage", fmt.Sprintf("🔋 Voltase %.1fV (minimal %.1fV)", sample.Voltage, t.VoltageMinimum)})
+	}
+
+	for _, psu := range strings.Split(sample.PSUState, ",") {
+		parts := strings.SplitN(psu, "=", 2)
+		if len(parts) == 2 && parts[1] != "" && parts[1] != "ok" {
+			issues = append(issues, HealthIssue{parts[0], fmt.Sprintf("⚡ %s: %s", strings.ToUpper(parts[0]), parts[1])})
+		}
+	}
+
+	return issues
+}
+
+func sendRouterHealthAlert(routerName string, issues []string) {
+	escaped := make([]string, len(issues))
+	for i, issue := range issues {
+		escaped[i] = html.EscapeString(issue)
+	}
+	msg := fmt.Sprintf(
+		"⚠️ <b>ROUTER HEALTH ALERT</b> ⚠️\n\n"+
+			"📡 <b>Router:</b> %s\n\n"+
+			"%s\n\n"+
+			"🕒 %s",
+		html.EscapeString(routerName),
+		strings.Join(escaped, "\n"),
+		time.Now().Format("02 Jan 15:04"),
+	)
+	sendTelegramHTML(msg)
+}
+
+func sendRouterHealthRecovered(routerName string) {
+	msg := fmt.Sprintf(
+		"✅ <b>ROUTER HEALTH NORMAL</b> ✅\n\n"+
+			"📡 <b>Router:</b> %s\n"+
+			"Semua metrik kembali di bawah ambang batas.\n\n"+
+			"🕒 %s",
+		html.EscapeString(routerName),
+		time.Now().Format("02 Jan 15:04"),
+	)
+	sendTelegramHTML(msg)
+}
+
+func envInt(key string, fallback int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return v
+	}
+	return fallback
+}
+
+func envFloat(key string, fallback float64) float64 {
+	if v, err := strconv.ParseFloat(os.Getenv(key), 64); err == nil {
+		return v
+	}
+	return fallback
+}
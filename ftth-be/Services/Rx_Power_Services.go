@@ -0,0 +1,328 @@
+package services
+
+import (
+	"akane/be-ftth/config"
+	"akane/be-ftth/models"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RxThresholds adalah ambang analisis redaman optik (bisa diatur lewat .env)
+type RxThresholds struct {
+	FloorDBm        float64 `json:"floor_dbm"`         // RX di bawah nilai ini dianggap lemah
+	DegradationDB   float64 `json:"degradation_db"`    // Penurunan dibanding baseline yang dianggap degradasi
+	WindowDays      int     `json:"window_days"`       // Rentang perbandingan baseline
+	FatAlertMinOnus int     `json:"fat_alert_min_onu"` // Minimal ONU bermasalah di satu FAT untuk dicurigai
+	FatAlertPercent float64 `json:"fat_alert_percent"` // Minimal persentase ONU bermasalah di satu FAT
+}
+
+// GetRxThresholds membaca RX_POWER_FLOOR_DBM, RX_POWER_DEGRADATION_DB, RX_POWER_WINDOW_DAYS,
+// RX_POWER_FAT_ALERT_MIN dan RX_POWER_FAT_ALERT_PERCENT
+func GetRxThresholds() RxThresholds {
+	return RxThresholds{
+		FloorDBm:        envFloat("RX_POWER_FLOOR_DBM", -27),
+		DegradationDB:   envFloat("RX_POWER_DEGRADATION_DB", 3),
+		WindowDays:      envInt("RX_POWER_WINDOW_DAYS", 7),
+		FatAlertMinOnus: envInt("RX_POWER_FAT_ALERT_MIN", 3),
+		FatAlertPercent: envFloat("RX_POWER_FAT_ALERT_PERCENT", 50),
+	}
+}
+
+// OnuRxStatus adalah hasil analisis RX satu ONU
+type OnuRxStatus struct {
+	DeviceSN   string    `json:"device_sn"`
+	DeviceID   string    `json:"device_id,omitempty"` // _id device GenieACS dari riwayat terakhir
+	ClientID   int       `json:"client_id,omitempty"`
+	ClientName string    `json:"client_name,omitempty"`
+	Fat        string    `json:"fat,omitempty"`
+	CurrentRx  float64   `json:"current_rx"`
+	BaselineRx *float64  `json:"baseline_rx"` // Rata-rata hari pertama window (nil jika data belum cukup)
+	Delta      float64   `json:"delta"`       // CurrentRx - BaselineRx (negatif = turun)
+	BelowFloor bool      `json:"below_floor"`
+	Degraded   bool      `json:"degraded"`
+	LastSeen   time.Time `json:"last_seen"`
+}
+
+// FatRxGroup adalah ringkasan RX per FAT/ODP untuk menemukan splitter/kabel bermasalah
+type FatRxGroup struct {
+	Fat        string  `json:"fat"`
+	TotalOnus  int     `json:"total_onus"`
+	WeakOnus   int     `json:"weak_onus"`
+	Degraded   int     `json:"degraded_onus"`
+	AverageRx  float64 `json:"average_rx"`
+	Suspicious bool    `json:"suspicious"`
+}
+
+// RxAnalysis adalah hasil lengkap analisis RX power
+type RxAnalysis struct {
+	Thresholds RxThresholds  `json:"thresholds"`
+	Onus       []OnuRxStatus `json:"onus"`
+	Fats       []FatRxGroup  `json:"fats"`
+}
+
+// AnalyzeRxPower membandingkan RX terakhir tiap ONU dengan ambang floor dan baseline window
+func AnalyzeRxPower(t RxThresholds) (*RxAnalysis, error) {
+	windowStart := time.Now().AddDate(0, 0, -t.WindowDays)
+
+	type latestRow struct {
+		DeviceSN   string
+		DeviceID   string
+		RxPower    float64
+		RecordedAt time.Time
+	}
+	var latest []latestRow
+	if err := config.DB.Raw(`
+		SELECT h.device_sn, h.device_id, h.rx_power, h.recorded_at
+		FROM rx_power_histories h
+		INNER JOIN (
+			SELECT device_sn, MAX(history_id) AS history_id
+			FROM rx_power_histories
+			WHERE recorded_at >= ?
+			GROUP BY device_sn
+		) l ON l.history_id = h.history_id
+	`, windowStart).Scan(&latest).Error; err != nil {
+		return nil, err
+	}
+
+	// Baseline = rata-rata hari pertama window agar satu sampel noise tidak memicu alert
+	type baselineRow struct {
+		DeviceSN string
+		AvgRx    float64
+	}
+	var baselines []baselineRow
+	if err := config.DB.Raw(`
+		SELECT device_sn, AVG(rx_power) AS avg_rx
+		FROM rx_power_histories
+		WHERE recorded_at >= ? AND recorded_at < ?
+		GROUP BY device_sn
+	`, windowStart, windowStart.Add(24*time.Hour)).Scan(&baselines).Error; err != nil {
+		return nil, err
+	}
+	baselineMap := make(map[string]float64, len(baselines))
+	for _, b := range baselines {
+		baselineMap[b.DeviceSN] = b.AvgRx
+	}
+
+	// Pelanggan dicocokkan lewat binding genie_device_id, SN ONU hanya cadangan (sama seperti GetDeviceACSInfo)
+	var clients []models.Client
+	if err := config.DB.Select("client_id", "name", "fat", "onu_sn", "genie_device_id").
+		Where("onu_sn <> '' OR genie_device_id <> ''").Find(&clients).Error; err != nil {
+		return nil, err
+	}
+	clientByDevice := make(map[string]models.Client, len(clients))
+	clientBySN := make(map[string]models.Client, len(clients))
+	for _, cl := range clients {
+		if cl.GenieDeviceID != "" {
+			clientByDevice[cl.GenieDeviceID] = cl
+		}
+	}
+	fatTotals := make(map[string]int)
+	for _, cl := range clients {
+		if cl.OnuSN != "" {
+			clientBySN[cl.OnuSN] = cl
+		}
+		if cl.Fat != "" {
+			fatTotals[cl.Fat]++
+		}
+	}
+
+	analysis := &RxAnalysis{Thresholds: t, Onus: make([]OnuRxStatus, 0, len(latest))}
+	fatGroups := make(map[string]*FatRxGroup)
+	fatSums := make(map[string]float64)
+	fatMeasured := make(map[string]int)
+
+	for _, row := range latest {
+		status := OnuRxStatus{
+			DeviceSN:   row.DeviceSN,
+			DeviceID:   row.DeviceID,
+			CurrentRx:  row.RxPower,
+			LastSeen:   row.RecordedAt,
+			BelowFloor: row.RxPower < t.FloorDBm,
+		}
+		if base, ok := baselineMap[row.DeviceSN]; ok {
+			b := base
+			status.BaselineRx = &b
+			status.Delta = row.RxPower - base
+			status.Degraded = -status.Delta > t.DegradationDB
+		}
+		cl, ok := clientByDevice[row.DeviceID]
+		if !ok || row.DeviceID == "" {
+			cl, ok = clientBySN[row.DeviceSN]
+			// SN yang sama dengan pelanggan yang sudah terikat ke device lain bukan ONU pelanggan tersebut
+			if ok && cl.GenieDeviceID != "" && row.DeviceID != "" && cl.GenieDeviceID != row.DeviceID {
+				ok = false
+			}
+		}
+		if ok {
+			status.ClientID = cl.ClientID
+			status.ClientName = cl.Name
+			status.Fat = cl.Fat
+		}
+		analysis.Onus = append(analysis.Onus, status)
+
+		if status.Fat == "" {
+			continue
+		}
+		g, ok := fatGroups[status.Fat]
+		if !ok {
+			g = &FatRxGroup{Fat: status.Fat, TotalOnus: fatTotals[status.Fat]}
+			fatGroups[status.Fat] = g
+		}
+		fatSums[status.Fat] += status.CurrentRx
+		fatMeasured[status.Fat]++
+		if status.BelowFloor {
+			g.WeakOnus++
+		}
+		if status.Degraded {
+			g.Degraded++
+		}
+	}
+
+	// Urutkan ONU dari RX terlemah
+	sort.Slice(analysis.Onus, func(i, j int) bool { return analysis.Onus[i].CurrentRx < analysis.Onus[j].CurrentRx })
+
+	for fat, g := range fatGroups {
+		measured := fatMeasured[fat]
+		g.AverageRx = fatSums[fat] / float64(measured)
+		if g.TotalOnus < measured {
+			g.TotalOnus = measured
+		}
+		g.Suspicious = isFatSuspicious(*g, t)
+		analysis.Fats = append(analysis.Fats, *g)
+	}
+	sort.Slice(analysis.Fats, func(i, j int) bool {
+		if analysis.Fats[i].Suspicious != analysis.Fats[j].Suspicious {
+			return analysis.Fats[i].Suspicious
+		}
+		return analysis.Fats[i].AverageRx < analysis.Fats[j].AverageRx
+	})
+	return analysis, nil
+}
+
+// isFatSuspicious: banyak ONU di satu FAT yang lemah/turun bersamaan biasanya masalah splitter atau kabel feeder
+func isFatSuspicious(g FatRxGroup, t RxThresholds) bool {
+	affected := g.WeakOnus
+	if g.Degraded > affected {
+		affected = g.Degraded
+	}
+	if affected < t.FatAlertMinOnus || g.TotalOnus == 0 {
+		return false
+	}
+	return float64(affected)*100/float64(g.TotalOnus) >= t.FatAlertPercent
+}
+
+// State alert terakhir agar ONU/FAT yang sama tidak dikirim berulang setiap jam
+var (
+	rxAlertMu    sync.Mutex
+	rxOnuAlerts  = make(map[string]string) // key: DeviceSN, value: "floor", "degraded", "floor,degraded"
+	rxFatAlerted = make(map[string]bool)
+)
+
+// RunRxPowerAnalysisJob menganalisis RX power dan mengirim alert untuk ONU/FAT yang baru bermasalah
+func RunRxPowerAnalysisJob() (int, error) {
+	t := GetRxThresholds()
+	analysis, err := AnalyzeRxPower(t)
+	if err != nil {
+		return 0, err
+	}
+
+	var onuLines, fatLines []string
+
+	rxAlertMu.Lock()
+	seen := make(map[string]bool)
+	for _, o := range analysis.Onus {
+		var flags []string
+		if o.BelowFloor {
+			flags = append(flags, "floor")
+		}
+		if o.Degraded {
+			flags = append(flags, "degraded")
+		}
+		if len(flags) == 0 {
+			continue
+		}
+		seen[o.DeviceSN] = true
+		current := strings.Join(flags, ",")
+		if rxOnuAlerts[o.DeviceSN] == current {
+			continue
+		}
+		rxOnuAlerts[o.DeviceSN] = current
+		onuLines = append(onuLines, formatRxAlertLine(o, t))
+	}
+	// ONU yang sudah normal dihapus dari state agar bisa alert lagi jika kambuh
+	for sn := range rxOnuAlerts {
+		if !seen[sn] {
+			delete(rxOnuAlerts, sn)
+		}
+	}
+
+	suspicious := make(map[string]bool)
+	for _, g := range analysis.Fats {
+		if !g.Suspicious {
+			continue
+		}
+		suspicious[g.Fat] = true
+		if rxFatAlerted[g.Fat] {
+			continue
+		}
+		rxFatAlerted[g.Fat] = true
+		fatLines = append(fatLines, fmt.Sprintf("🧩 %s: %d lemah, %d turun dari %d ONU (rata-rata %.2f dBm)", html.EscapeString(g.Fat), g.WeakOnus, g.Degraded, g.TotalOnus, g.AverageRx))
+	}
+	for fat := range rxFatAlerted {
+		if !suspicious[fat] {
+			delete(rxFatAlerted, fat)
+		}
+	}
+	rxAlertMu.Unlock()
+
+	if len(onuLines) > 0 || len(fatLines) > 0 {
+		go sendRxPowerAlert(onuLines, fatLines)
+	}
+	return len(analysis.Onus), nil
+}
+
+func formatRxAlertLine(o OnuRxStatus, t RxThresholds) string {
+	name := html.EscapeString(o.DeviceSN)
+	if o.ClientName != "" {
+		name = fmt.Sprintf("%s (%s)", html.EscapeString(o.ClientName), name)
+	}
+	line := fmt.Sprintf("🔻 %s: %.2f dBm", name, o.CurrentRx)
+	if o.Degraded && o.BaselineRx != nil {
+		line += fmt.Sprintf(", turun %.2f dB dalam %d hari", -o.Delta, t.WindowDays)
+	}
+	if o.Fat != "" {
+		line += " [" + html.EscapeString(o.Fat) + "]"
+	}
+	return line
+}
+
+// Batas baris per pesan Telegram agar tidak melewati limit 4096 karakter
+const maxRxAlertLines = 30
+
+func sendRxPowerAlert(onuLines, fatLines []string) {
+	var sb strings.Builder
+	sb.WriteString("📉 <b>RX POWER ALERT</b> 📉\n\n")
+	if len(fatLines) > 0 {
+		sb.WriteString("<b>FAT/ODP dicurigai bermasalah (splitter/kabel):</b>\n")
+		sb.WriteString(strings.Join(fatLines, "\n"))
+		sb.WriteString("\n\n")
+	}
+	if len(onuLines) > 0 {
+		sb.WriteString("<b>ONU lemah / menurun:</b>\n")
+		shown := onuLines
+		if len(shown) > maxRxAlertLines {
+			shown = shown[:maxRxAlertLines]
+		}
+		sb.WriteString(strings.Join(shown, "\n"))
+		if len(onuLines) > len(shown) {
+			sb.WriteString(fmt.Sprintf("\n... dan %d ONU lainnya", len(onuLines)-len(shown)))
+		}
+		sb.WriteString("\n\n")
+	}
+	sb.WriteString("🕒 " + time.Now().Format("02 Jan 15:04"))
+	sendTelegramHTML(sb.String())
+}
@@ -0,0 +1,312 @@
+package services
+
+import (
+	"akane/be-ftth/config"
+	"akane/be-ftth/models"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// loadExportTopology memuat node & kabel yang sama dengan GetNetworkTopology (CLIENT yang sudah diputus disembunyikan)
+func loadExportTopology() ([]models.NetworkNode, []models.NetworkCable, error) {
+	var nodes []models.NetworkNode
+	if err := config.DB.
+		Preload("ODPDetail").
+		Preload("OLTDetail").
+		Preload("ODCDetail").
+		Preload("ClientDetail").
+		Where(`
+			type != 'CLIENT'
+			OR NOT EXISTS (
+				SELECT 1 FROM client_nodes cn
+				INNER JOIN clients c ON c.client_id = CAST(cn.subscriber_id AS UNSIGNED)
+				WHERE cn.node_id = network_nodes.node_id AND c.deleted_at IS NOT NULL
+			)
+		`).
+		Order("node_id").
+		Find(&nodes).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var cables []models.NetworkCable
+	if err := config.DB.Order("cable_id").Find(&cables).Error; err != nil {
+		return nil, nil, err
+	}
+
+	byID := make(map[int]models.NetworkNode, len(nodes))
+	for _, n := range nodes {
+		byID[n.NodeID] = n
+	}
+	active := make([]models.NetworkCable, 0, len(cables))
+	for _, c := range cables {
+		src, ok1 := byID[c.SourceNodeID]
+		dst, ok2 := byID[c.TargetNodeID]
+		if ok1 && ok2 {
+			c.SourceNode, c.TargetNode = src, dst
+			active = append(active, c)
+		}
+	}
+	return nodes, active, nil
+}
+
+// cablePath mengembalikan jalur kabel [lat, lng]; jika Coordinates kosong dipakai garis lurus antar node
+func cablePath(c models.NetworkCable) [][2]float64 {
+	return effectiveCablePath(c, c.SourceNode, c.TargetNode)
+}
+
+// nodeExportProps berisi properti node yang ikut diekspor. Kunci "type" dipakai lagi saat impor.
+func nodeExportProps(n models.NetworkNode) map[string]string {
+	props := map[string]string{
+		"node_id":     strconv.Itoa(n.NodeID),
+		"name":        n.Name,
+		"type":        string(n.Type),
+		"status":      n.Status,
+		"description": n.Description,
+	}
+	if n.ODPDetail != nil {
+		props["total_ports"] = strconv.Itoa(n.ODPDetail.TotalPorts)
+		if n.ODPDetail.SplitterRatio > 0 {
+			props["splitter_ratio"] = strconv.Itoa(n.ODPDetail.SplitterRatio)
+		}
+	}
+	if n.ODCDetail != nil {
+		props["capacity"] = strconv.Itoa(n.ODCDetail.Capacity)
+	}
+	if n.OLTDetail != nil {
+		props["brand"] = n.OLTDetail.Brand
+		props["ip_address"] = n.OLTDetail.IPAddress
+	}
+	if n.ClientDetail != nil {
+		props["subscriber_id"] = n.ClientDetail.SubscriberID
+		props["packet_name"] = n.ClientDetail.PacketName
+	}
+	return props
+}
+
+func cableExportProps(c models.NetworkCable) map[string]string {
+	return map[string]string{
+		"cable_id":       strconv.Itoa(c.CableID),
+		"name":           cableExportName(c),
+		"cable_type":     c.CableType,
+		"description":    c.Description,
+		"source_node_id": strconv.Itoa(c.SourceNodeID),
+		"target_node_id": strconv.Itoa(c.TargetNodeID),
+		"length_meter":   strconv.FormatFloat(c.LengthMeter, 'f', -1, 64),
+		"core_count":     strconv.Itoa(c.CoreCount),
+	}
+}
+
+func cableExportName(c models.NetworkCable) string {
+	return fmt.Sprintf("%s - %s", c.SourceNode.Name, c.TargetNode.Name)
+}
+
+// ==========================================
+// GEOJSON
+// ==========================================
+
+// ExportTopologyGeoJSON mengekspor seluruh topologi sebagai GeoJSON FeatureCollection
+func ExportTopologyGeoJSON() ([]byte, error) {
+	nodes, cables, err := loadExportTopology()
+	if err != nil {
+		return nil, err
+	}
+
+	features := make([]map[string]interface{}, 0, len(nodes)+len(cables))
+	for _, n := range nodes {
+		features = append(features, map[string]interface{}{
+			"type":       "Feature",
+			"geometry":   map[string]interface{}{"type": "Point", "coordinates": []float64{n.Longitude, n.Latitude}},
+			"properties": nodeExportProps(n),
+		})
+	}
+	for _, c := range cables {
+		path := cablePath(c)
+		coords := make([][]float64, len(path))
+		for i, p := range path {
+			coords[i] = []float64{p[1], p[0]}
+		}
+		features = append(features, map[string]interface{}{
+			"type":       "Feature",
+			"geometry":   map[string]interface{}{"type": "LineString", "coordinates": coords},
+			"properties": cableExportProps(c),
+		})
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"type":     "FeatureCollection",
+		"features": features,
+	})
+}
+
+// ==========================================
+// KML
+// ==========================================
+
+// Warna KML berformat aabbggrr
+var kmlNodeColors = map[models.NodeType]string{
+	models.TypeRouter: "ff8b3d48",
+	models.TypeOLT:    "ff0000ff",
+	models.TypeODC:    "ff00a5ff",
+	models.TypeODP:    "ff00ff00",
+	models.TypeTB:     "ffff00ff",
+	models.TypeClient: "ffffff00",
+}
+
+var kmlCableColors = map[string]string{
+	"BACKBONE":    "ff0000ff",
+	"DISTRIBUSI":  "ff00a5ff",
+	"DROP CLIENT": "ffffff00",
+}
+
+type kmlExportData struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value"`
+}
+
+type kmlExportPlacemark struct {
+	Name        string          `xml:"name"`
+	Description string          `xml:"description,omitempty"`
+	StyleURL    string          `xml:"styleUrl"`
+	Data        []kmlExportData `xml:"ExtendedData>Data"`
+	Point       *kmlCoordinates `xml:"Point,omitempty"`
+	LineString  *kmlCoordinates `xml:"LineString,omitempty"`
+}
+
+type kmlExportFolder struct {
+	Name       string               `xml:"name"`
+	Placemarks []kmlExportPlacemark `xml:"Placemark"`
+}
+
+type kmlIconStyle struct {
+	Color string `xml:"color"`
+	Icon  string `xml:"Icon>href"`
+}
+
+type kmlLineStyle struct {
+	Color string `xml:"color"`
+	Width int    `xml:"width"`
+}
+
+type kmlExportStyle struct {
+	ID        string        `xml:"id,attr"`
+	IconStyle *kmlIconStyle `xml:"IconStyle,omitempty"`
+	LineStyle *kmlLineStyle `xml:"LineStyle,omitempty"`
+}
+
+type kmlExportDocument struct {
+	XMLName  xml.Name `xml:"kml"`
+	Xmlns    string   `xml:"xmlns,attr"`
+	Document struct {
+		Name    string            `xml:"name"`
+		Styles  []kmlExportStyle  `xml:"Style"`
+		Folders []kmlExportFolder `xml:"Folder"`
+	} `xml:"Document"`
+}
+
+func kmlExtendedData(props map[string]string) []kmlExportData {
+	keys := make([]string, 0, len(props))
+	for k, v := range props {
+		if v != "" && k != "name" && k != "description" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	data := make([]kmlExportData, len(keys))
+	for i, k := range keys {
+		data[i] = kmlExportData{Name: k, Value: props[k]}
+	}
+	return data
+}
+
+func kmlStyleID(prefix, name string) string {
+	return prefix + "-" + strings.ReplaceAll(strings.ToLower(name), " ", "-")
+}
+
+// ExportTopologyKML mengekspor seluruh topologi sebagai KML dengan satu folder per tipe node / kabel
+func ExportTopologyKML() ([]byte, error) {
+	nodes, cables, err := loadExportTopology()
+	if err != nil {
+		return nil, err
+	}
+
+	doc := kmlExportDocument{Xmlns: "http://www.opengis.net/kml/2.2"}
+	doc.Document.Name = "Topologi Jaringan FTTH"
+
+	nodeFolders := map[string]*kmlExportFolder{}
+	cableFolders := map[string]*kmlExportFolder{}
+	var nodeOrder, cableOrder []string
+
+	for _, n := range nodes {
+		key := string(n.Type)
+		folder, ok := nodeFolders[key]
+		if !ok {
+			folder = &kmlExportFolder{Name: key}
+			nodeFolders[key] = folder
+			nodeOrder = append(nodeOrder, key)
+		}
+		folder.Placemarks = append(folder.Placemarks, kmlExportPlacemark{
+			Name:        n.Name,
+			Description: n.Description,
+			StyleURL:    "#" + kmlStyleID("node", key),
+			Data:        kmlExtendedData(nodeExportProps(n)),
+			Point:       &kmlCoordinates{Coordinates: fmt.Sprintf("%f,%f,0", n.Longitude, n.Latitude)},
+		})
+	}
+
+	for _, c := range cables {
+		key := c.CableType
+		if key == "" {
+			key = defaultImportCableType
+		}
+		folder, ok := cableFolders[key]
+		if !ok {
+			folder = &kmlExportFolder{Name: "Kabel " + key}
+			cableFolders[key] = folder
+			cableOrder = append(cableOrder, key)
+		}
+		path := cablePath(c)
+		coords := make([]string, len(path))
+		for i, p := range path {
+			coords[i] = fmt.Sprintf("%f,%f,0", p[1], p[0])
+		}
+		folder.Placemarks = append(folder.Placemarks, kmlExportPlacemark{
+			Name:        cableExportName(c),
+			Description: c.Description,
+			StyleURL:    "#" + kmlStyleID("cable", key),
+			Data:        kmlExtendedData(cableExportProps(c)),
+			LineString:  &kmlCoordinates{Coordinates: strings.Join(coords, " ")},
+		})
+	}
+
+	sort.Strings(nodeOrder)
+	sort.Strings(cableOrder)
+	for _, key := range nodeOrder {
+		style := kmlExportStyle{ID: kmlStyleID("node", key)}
+		style.IconStyle = &kmlIconStyle{Color: kmlColor(kmlNodeColors[models.NodeType(key)]), Icon: "http://maps.google.com/mapfiles/kml/paddle/wht-blank.png"}
+		doc.Document.Styles = append(doc.Document.Styles, style)
+		doc.Document.Folders = append(doc.Document.Folders, *nodeFolders[key])
+	}
+	for _, key := range cableOrder {
+		style := kmlExportStyle{ID: kmlStyleID("cable", key)}
+		style.LineStyle = &kmlLineStyle{Color: kmlColor(kmlCableColors[key]), Width: 3}
+		doc.Document.Styles = append(doc.Document.Styles, style)
+		doc.Document.Folders = append(doc.Document.Folders, *cableFolders[key])
+	}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+func kmlColor(c string) string {
+	if c == "" {
+		return "ffffffff"
+	}
+	return c
+}
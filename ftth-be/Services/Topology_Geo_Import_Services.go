@@ -0,0 +1,722 @@
+package services
+
+import (
+	"akane/be-ftth/config"
+	"akane/be-ftth/models"
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const (
+	GeoActionCreate = "create" // Akan / sudah dibuat
+	GeoActionExists = "exists" // Sudah ada di database, dilewati
+	GeoActionSkip   = "skip"   // Tidak bisa diimpor, lihat Reason
+
+	defaultImportSnapMeter = 30
+	defaultImportCableType = "DISTRIBUSI"
+
+	// maxKMZEntryBytes membatasi ukuran dokumen KML hasil ekstraksi KMZ agar arsip "zip bomb" tidak menghabiskan memori
+	maxKMZEntryBytes = 50 << 20
+)
+
+// geoFeature adalah satu titik / garis hasil parsing file KML atau GeoJSON. Path berisi [lat, lng].
+type geoFeature struct {
+	Name        string
+	Description string
+	Folder      string
+	IsLine      bool
+	Path        [][2]float64
+	Props       map[string]string
+}
+
+// GeoImportOptions mengatur pemetaan fitur file ke tipe node / kabel
+type GeoImportOptions struct {
+	// Kata kunci (nama folder / nama placemark) -> tipe node, contoh {"Tiang ODP": "ODP"}
+	NodeTypeMap map[string]string `json:"node_type_map"`
+	// Kata kunci -> tipe kabel, contoh {"Feeder": "BACKBONE"}
+	CableTypeMap     map[string]string `json:"cable_type_map"`
+	DefaultNodeType  string            `json:"default_node_type"`  // Kosong = titik tanpa tipe dilewati
+	DefaultCableType string            `json:"default_cable_type"` // Kosong = DISTRIBUSI
+	SnapMeter        float64           `json:"snap_meter"`         // Jarak maksimal ujung kabel ke node, default 30 m
+}
+
+// GeoImportNode adalah rencana impor satu titik
+type GeoImportNode struct {
+	Index       int             `json:"index"`
+	Name        string          `json:"name"`
+	Folder      string          `json:"folder,omitempty"`
+	Type        models.NodeType `json:"type"`
+	Lat         float64         `json:"lat"`
+	Lng         float64         `json:"lng"`
+	Action      string          `json:"action"`
+	Reason      string          `json:"reason,omitempty"`
+	NodeID      int             `json:"node_id,omitempty"` // Node yang dibuat / sudah ada
+	totalPorts  int
+	capacity    int
+	description string
+}
+
+// GeoImportCable adalah rencana impor satu garis sebagai kabel
+type GeoImportCable struct {
+	Index        int     `json:"index"`
+	Name         string  `json:"name"`
+	Folder       string  `json:"folder,omitempty"`
+	CableType    string  `json:"cable_type"`
+	Points       int     `json:"points"`
+	LengthMeter  float64 `json:"length_meter"`
+	SourceName   string  `json:"source_name,omitempty"`
+	TargetName   string  `json:"target_name,omitempty"`
+	SourceNodeID int     `json:"source_node_id,omitempty"` // 0 = node baru dari file yang sama
+	TargetNodeID int     `json:"target_node_id,omitempty"`
+	Action       string  `json:"action"`
+	Reason       string  `json:"reason,omitempty"`
+	CableID      int     `json:"cable_id,omitempty"`
+	sourceRef    *GeoImportNode
+	targetRef    *GeoImportNode
+	path         [][2]float64
+	description  string
+	coreCount    int
+}
+
+// GeoImportResult adalah hasil preview / impor file peta
+type GeoImportResult struct {
+	Preview bool             `json:"preview"`
+	Nodes   []GeoImportNode  `json:"nodes"`
+	Cables  []GeoImportCable `json:"cables"`
+	Summary map[string]int   `json:"summary"`
+}
+
+// ==========================================
+// PARSING FILE
+// ==========================================
+
+// parseGeoFile membaca file .kml, .kmz, .geojson atau .json
+func parseGeoFile(filename string, data []byte) ([]geoFeature, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".kml":
+		return parseKML(data)
+	case ".kmz":
+		kml, err := extractKMZ(data)
+		if err != nil {
+			return nil, err
+		}
+		return parseKML(kml)
+	case ".geojson", ".json":
+		return parseGeoJSON(data)
+	}
+	return nil, fmt.Errorf("format file tidak didukung, gunakan .kml, .kmz atau .geojson")
+}
+
+// extractKMZ mengambil doc.kml (atau file .kml pertama) dari arsip KMZ
+func extractKMZ(data []byte) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("file KMZ tidak valid: %v", err)
+	}
+	var kmlFile *zip.File
+	for _, f := range zr.File {
+		if !strings.EqualFold(filepath.Ext(f.Name), ".kml") {
+			continue
+		}
+		if strings.EqualFold(filepath.Base(f.Name), "doc.kml") {
+			kmlFile = f
+			break
+		}
+		if kmlFile == nil {
+			kmlFile = f
+		}
+	}
+	if kmlFile == nil {
+		return nil, fmt.Errorf("file KMZ tidak berisi dokumen .kml")
+	}
+	if kmlFile.UncompressedSize64 > maxKMZEntryBytes {
+		return nil, fmt.Errorf("dokumen KML di dalam KMZ melebihi batas %d MB", maxKMZEntryBytes>>20)
+	}
+	rc, err := kmlFile.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	// Ukuran di header zip bisa dipalsukan, jadi pembacaan tetap dibatasi
+	kml, err := io.ReadAll(io.LimitReader(rc, maxKMZEntryBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(kml) > maxKMZEntryBytes {
+		return nil, fmt.Errorf("dokumen KML di dalam KMZ melebihi batas %d MB", maxKMZEntryBytes>>20)
+	}
+	return kml, nil
+}
+
+type kmlCoordinates struct {
+	Coordinates string `xml:"coordinates"`
+}
+
+type kmlPlacemark struct {
+	Name          string          `xml:"name"`
+	Description   string          `xml:"description"`
+	Point         *kmlCoordinates `xml:"Point"`
+	LineString    *kmlCoordinates `xml:"LineString"`
+	MultiGeometry *struct {
+		Points []kmlCoordinates `xml:"Point"`
+		Lines  []kmlCoordinates `xml:"LineString"`
+	} `xml:"MultiGeometry"`
+	Data []struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:"value"`
+	} `xml:"ExtendedData>Data"`
+}
+
+// kmlContainer mewakili <kml>, <Document> dan <Folder> yang bisa bersarang
+type kmlContainer struct {
+	Name       string         `xml:"name"`
+	Documents  []kmlContainer `xml:"Document"`
+	Folders    []kmlContainer `xml:"Folder"`
+	Placemarks []kmlPlacemark `xml:"Placemark"`
+}
+
+func parseKML(data []byte) ([]geoFeature, error) {
+	var root kmlContainer
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("file KML tidak valid: %v", err)
+	}
+	var features []geoFeature
+	var walk func(c kmlContainer, folder string)
+	walk = func(c kmlContainer, folder string) {
+		for _, pm := range c.Placemarks {
+			features = append(features, kmlPlacemarkFeatures(pm, folder)...)
+		}
+		for _, d := range c.Documents {
+			walk(d, folder)
+		}
+		for _, f := range c.Folders {
+			name := strings.TrimSpace(f.Name)
+			if folder != "" && name != "" {
+				name = folder + "/" + name
+			} else if name == "" {
+				name = folder
+			}
+			walk(f, name)
+		}
+	}
+	walk(root, "")
+	return features, nil
+}
+
+func kmlPlacemarkFeatures(pm kmlPlacemark, folder string) []geoFeature {
+	props := make(map[string]string, len(pm.Data))
+	for _, d := range pm.Data {
+		props[strings.ToLower(d.Name)] = strings.TrimSpace(d.Value)
+	}
+	base := geoFeature{
+		Name:        strings.TrimSpace(pm.Name),
+		Description: strings.TrimSpace(pm.Description),
+		Folder:      folder,
+		Props:       props,
+	}
+
+	var points, lines []kmlCoordinates
+	if pm.Point != nil {
+		points = append(points, *pm.Point)
+	}
+	if pm.LineString != nil {
+		lines = append(lines, *pm.LineString)
+	}
+	if pm.MultiGeometry != nil {
+		points = append(points, pm.MultiGeometry.Points...)
+		lines = append(lines, pm.MultiGeometry.Lines...)
+	}
+
+	var features []geoFeature
+	for _, p := range points {
+		if path := parseKMLCoordinates(p.Coordinates); len(path) > 0 {
+			f := base
+			f.Path = path[:1]
+			features = append(features, f)
+		}
+	}
+	for _, l := range lines {
+		if path := parseKMLCoordinates(l.Coordinates); len(path) >= 2 {
+			f := base
+			f.IsLine = true
+			f.Path = path
+			features = append(features, f)
+		}
+	}
+	return features
+}
+
+// parseKMLCoordinates mengubah "lng,lat[,alt] lng,lat[,alt] ..." menjadi daftar [lat, lng]
+func parseKMLCoordinates(s string) [][2]float64 {
+	var path [][2]float64
+	for _, tuple := range strings.Fields(s) {
+		parts := strings.Split(tuple, ",")
+		if len(parts) < 2 {
+			continue
+		}
+		lng, err1 := strconv.ParseFloat(parts[0], 64)
+		lat, err2 := strconv.ParseFloat(parts[1], 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		path = append(path, [2]float64{lat, lng})
+	}
+	return path
+}
+
+type geoJSONGeometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   *geoJSONGeometry       `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+	Features   []geoJSONFeature       `json:"features"` // Hanya untuk FeatureCollection
+}
+
+func parseGeoJSON(data []byte) ([]geoFeature, error) {
+	var root geoJSONFeature
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("file GeoJSON tidak valid: %v", err)
+	}
+
+	var items []geoJSONFeature
+	switch root.Type {
+	case "FeatureCollection":
+		items = root.Features
+	case "Feature":
+		items = []geoJSONFeature{root}
+	default:
+		return nil, fmt.Errorf("GeoJSON harus berupa FeatureCollection atau Feature")
+	}
+
+	var features []geoFeature
+	for _, item := range items {
+		if item.Geometry == nil {
+			continue
+		}
+		props := make(map[string]string, len(item.Properties))
+		for k, v := range item.Properties {
+			if v != nil {
+				props[strings.ToLower(k)] = strings.TrimSpace(fmt.Sprint(v))
+			}
+		}
+		base := geoFeature{
+			Name:        props["name"],
+			Description: props["description"],
+			Folder:      props["folder"],
+			Props:       props,
+		}
+
+		var points [][]float64
+		var lines [][][]float64
+		switch item.Geometry.Type {
+		case "Point":
+			var p []float64
+			if json.Unmarshal(item.Geometry.Coordinates, &p) == nil {
+				points = append(points, p)
+			}
+		case "MultiPoint":
+			json.Unmarshal(item.Geometry.Coordinates, &points)
+		case "LineString":
+			var l [][]float64
+			if json.Unmarshal(item.Geometry.Coordinates, &l) == nil {
+				lines = append(lines, l)
+			}
+		case "MultiLineString":
+			json.Unmarshal(item.Geometry.Coordinates, &lines)
+		}
+
+		for _, p := range points {
+			if len(p) >= 2 {
+				f := base
+				f.Path = [][2]float64{{p[1], p[0]}}
+				features = append(features, f)
+			}
+		}
+		for _, l := range lines {
+			f := base
+			f.IsLine = true
+			for _, p := range l {
+				if len(p) >= 2 {
+					f.Path = append(f.Path, [2]float64{p[1], p[0]})
+				}
+			}
+			if len(f.Path) >= 2 {
+				features = append(features, f)
+			}
+		}
+	}
+	return features, nil
+}
+
+// ==========================================
+// PEMETAAN TIPE
+// ==========================================
+
+var importableNodeTypes = map[models.NodeType]bool{
+	models.TypeOLT:    true,
+	models.TypeODC:    true,
+	models.TypeODP:    true,
+	models.TypeTB:     true,
+	models.TypeRouter: true,
+}
+
+// matchKeyword mencari kata kunci terpanjang yang muncul di teks (tanpa membedakan huruf besar/kecil)
+func matchKeyword(text string, mapping map[string]string) (string, bool) {
+	keys := make([]string, 0, len(mapping))
+	for k := range mapping {
+		if strings.TrimSpace(k) != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) > len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+	upper := strings.ToUpper(text)
+	for _, k := range keys {
+		if strings.Contains(upper, strings.ToUpper(strings.TrimSpace(k))) {
+			return mapping[k], true
+		}
+	}
+	return "", false
+}
+
+// resolveNodeType: properti "type" > node_type_map > nama tipe di folder/nama > default_node_type
+func resolveNodeType(f geoFeature, opts GeoImportOptions) models.NodeType {
+	if t := strings.ToUpper(f.Props["type"]); t != "" {
+		return models.NodeType(t)
+	}
+	text := f.Folder + " " + f.Name
+	if t, ok := matchKeyword(text, opts.NodeTypeMap); ok {
+		return models.NodeType(strings.ToUpper(strings.TrimSpace(t)))
+	}
+	builtin := map[string]string{}
+	for t := range importableNodeTypes {
+		builtin[string(t)] = string(t)
+	}
+	builtin[string(models.TypeClient)] = string(models.TypeClient)
+	if t, ok := matchKeyword(text, builtin); ok {
+		return models.NodeType(t)
+	}
+	return models.NodeType(strings.ToUpper(strings.TrimSpace(opts.DefaultNodeType)))
+}
+
+// resolveCableType: properti "cable_type" > cable_type_map > kata kunci bawaan > default_cable_type
+func resolveCableType(f geoFeature, opts GeoImportOptions) string {
+	if t := f.Props["cable_type"]; t != "" {
+		return strings.ToUpper(t)
+	}
+	text := f.Folder + " " + f.Name
+	if t, ok := matchKeyword(text, opts.CableTypeMap); ok {
+		return strings.ToUpper(strings.TrimSpace(t))
+	}
+	if t, ok := matchKeyword(text, map[string]string{
+		"BACKBONE":   "BACKBONE",
+		"FEEDER":     "BACKBONE",
+		"DISTRIBUSI": "DISTRIBUSI",
+		"DROP":       "DROP CLIENT",
+	}); ok {
+		return t
+	}
+	if opts.DefaultCableType != "" {
+		return strings.ToUpper(opts.DefaultCableType)
+	}
+	return defaultImportCableType
+}
+
+// ==========================================
+// RENCANA & EKSEKUSI IMPOR
+// ==========================================
+
+// snapTarget adalah kandidat ujung kabel: node lama (nodeID) atau node baru dari file (ref)
+type snapTarget struct {
+	name   string
+	pos    [2]float64
+	nodeID int
+	ref    *GeoImportNode
+}
+
+func nearestSnap(p [2]float64, targets []snapTarget, maxMeter float64) *snapTarget {
+	var best *snapTarget
+	bestDist := maxMeter
+	for i := range targets {
+		if d := haversineMeter(p, targets[i].pos); d <= bestDist {
+			best, bestDist = &targets[i], d
+		}
+	}
+	return best
+}
+
+// planGeoImport menentukan tindakan untuk setiap fitur tanpa menulis ke database
+func planGeoImport(features []geoFeature, opts GeoImportOptions) (*GeoImportResult, error) {
+	var existingNodes []models.NetworkNode
+	if err := config.DB.Find(&existingNodes).Error; err != nil {
+		return nil, err
+	}
+	var existingCables []models.NetworkCable
+	if err := config.DB.Select("source_node_id, target_node_id").Find(&existingCables).Error; err != nil {
+		return nil, err
+	}
+	return planGeoFeatures(features, opts, existingNodes, existingCables, CableSlackPercent()), nil
+}
+
+// planGeoFeatures adalah inti planGeoImport: mencocokkan fitur dengan node & kabel yang sudah dimuat
+func planGeoFeatures(features []geoFeature, opts GeoImportOptions, existingNodes []models.NetworkNode, existingCables []models.NetworkCable, slackPercent float64) *GeoImportResult {
+	if opts.SnapMeter <= 0 {
+		opts.SnapMeter = defaultImportSnapMeter
+	}
+
+	cablePairs := make(map[[2]int]bool, len(existingCables))
+	for _, c := range existingCables {
+		cablePairs[[2]int{c.SourceNodeID, c.TargetNodeID}] = true
+		cablePairs[[2]int{c.TargetNodeID, c.SourceNodeID}] = true
+	}
+
+	result := &GeoImportResult{Nodes: []GeoImportNode{}, Cables: []GeoImportCable{}}
+	nodeRows := make([]*GeoImportNode, 0)
+	var lines []geoFeature
+
+	// 1. Titik -> node. Titik dengan nama & tipe sama di dekat node lama dianggap sudah ada.
+	for _, f := range features {
+		if f.IsLine {
+			lines = append(lines, f)
+			continue
+		}
+		row := &GeoImportNode{
+			Index:       len(nodeRows) + 1,
+			Name:        f.Name,
+			Folder:      f.Folder,
+			Type:        resolveNodeType(f, opts),
+			Lat:         f.Path[0][0],
+			Lng:         f.Path[0][1],
+			Action:      GeoActionCreate,
+			description: f.Description,
+		}
+		row.totalPorts, _ = strconv.Atoi(f.Props["total_ports"])
+		row.capacity, _ = strconv.Atoi(f.Props["capacity"])
+		nodeRows = append(nodeRows, row)
+
+		switch {
+		case row.Name == "":
+			row.Action, row.Reason = GeoActionSkip, "Nama titik kosong"
+		case row.Type == "":
+			row.Action, row.Reason = GeoActionSkip, "Tipe node tidak dikenali, tambahkan ke node_type_map"
+		case row.Type == models.TypeClient:
+			row.Action, row.Reason = GeoActionSkip, "Node CLIENT dibuat dari data pelanggan, bukan dari file peta"
+		case !importableNodeTypes[row.Type]:
+			row.Action, row.Reason = GeoActionSkip, fmt.Sprintf("Tipe node %s tidak valid", row.Type)
+		case row.Lat < -90 || row.Lat > 90 || row.Lng < -180 || row.Lng > 180 || (row.Lat == 0 && row.Lng == 0):
+			row.Action, row.Reason = GeoActionSkip, "Koordinat tidak valid"
+		}
+		if row.Action != GeoActionCreate {
+			continue
+		}
+		for _, n := range existingNodes {
+			if n.Type == row.Type && strings.EqualFold(n.Name, row.Name) &&
+				haversineMeter([2]float64{n.Latitude, n.Longitude}, [2]float64{row.Lat, row.Lng}) <= opts.SnapMeter {
+				row.Action, row.NodeID, row.Reason = GeoActionExists, n.NodeID, "Node dengan nama & tipe sama sudah ada"
+				break
+			}
+		}
+	}
+
+	// 2. Ujung kabel boleh menempel ke node lama maupun node baru dari file ini
+	targets := make([]snapTarget, 0, len(existingNodes)+len(nodeRows))
+	for _, n := range existingNodes {
+		targets = append(targets, snapTarget{name: n.Name, pos: [2]float64{n.Latitude, n.Longitude}, nodeID: n.NodeID})
+	}
+	for _, row := range nodeRows {
+		if row.Action == GeoActionCreate {
+			targets = append(targets, snapTarget{name: row.Name, pos: [2]float64{row.Lat, row.Lng}, ref: row})
+		}
+	}
+
+	newPairs := make(map[[2]*GeoImportNode]bool)
+	for i, f := range lines {
+		cable := GeoImportCable{
+			Index:       i + 1,
+			Name:        f.Name,
+			Folder:      f.Folder,
+			CableType:   resolveCableType(f, opts),
+			Points:      len(f.Path),
+			LengthMeter: computedLengthMeter(f.Path, slackPercent),
+			Action:      GeoActionCreate,
+			path:        f.Path,
+			description: f.Description,
+		}
+		cable.coreCount, _ = strconv.Atoi(f.Props["core_count"])
+		if cable.description == "" {
+			cable.description = f.Name
+		}
+
+		src := nearestSnap(f.Path[0], targets, opts.SnapMeter)
+		dst := nearestSnap(f.Path[len(f.Path)-1], targets, opts.SnapMeter)
+		switch {
+		case src == nil:
+			cable.Action, cable.Reason = GeoActionSkip, fmt.Sprintf("Ujung awal tidak berada dalam %.0f m dari node mana pun", opts.SnapMeter)
+		case dst == nil:
+			cable.Action, cable.Reason = GeoActionSkip, fmt.Sprintf("Ujung akhir tidak berada dalam %.0f m dari node mana pun", opts.SnapMeter)
+		case src == dst:
+			cable.Action, cable.Reason = GeoActionSkip, "Kedua ujung menempel ke node yang sama"
+		}
+		if src != nil {
+			cable.SourceName, cable.SourceNodeID, cable.sourceRef = src.name, src.nodeID, src.ref
+		}
+		if dst != nil {
+			cable.TargetName, cable.TargetNodeID, cable.targetRef = dst.name, dst.nodeID, dst.ref
+		}
+
+		if cable.Action == GeoActionCreate {
+			if src.ref == nil && dst.ref == nil {
+				if cablePairs[[2]int{src.nodeID, dst.nodeID}] {
+					cable.Action, cable.Reason = GeoActionExists, "Kabel antara kedua node sudah ada"
+				} else {
+					cablePairs[[2]int{src.nodeID, dst.nodeID}] = true
+					cablePairs[[2]int{dst.nodeID, src.nodeID}] = true
+				}
+			} else if src.ref != nil && dst.ref != nil {
+				if newPairs[[2]*GeoImportNode{src.ref, dst.ref}] {
+					cable.Action, cable.Reason = GeoActionSkip, "Kabel ganda di dalam file"
+				} else {
+					newPairs[[2]*GeoImportNode{src.ref, dst.ref}] = true
+					newPairs[[2]*GeoImportNode{dst.ref, src.ref}] = true
+				}
+			}
+		}
+		result.Cables = append(result.Cables, cable)
+	}
+
+	for _, row := range nodeRows {
+		result.Nodes = append(result.Nodes, *row)
+	}
+	result.Summary = summarizeGeoImport(result)
+	return result
+}
+
+func summarizeGeoImport(r *GeoImportResult) map[string]int {
+	summary := map[string]int{}
+	for _, n := range r.Nodes {
+		summary["node_"+n.Action]++
+	}
+	for _, c := range r.Cables {
+		summary["cable_"+c.Action]++
+	}
+	return summary
+}
+
+// ImportTopologyFile mem-parsing file peta lalu membuat node & kabel. preview=true hanya mengembalikan rencana.
+func ImportTopologyFile(filename string, data []byte, opts GeoImportOptions, preview bool) (*GeoImportResult, error) {
+	features, err := parseGeoFile(filename, data)
+	if err != nil {
+		return nil, err
+	}
+	if len(features) == 0 {
+		return nil, fmt.Errorf("file tidak berisi titik atau garis")
+	}
+
+	plan, err := planGeoImport(features, opts)
+	if err != nil {
+		return nil, err
+	}
+	plan.Preview = preview
+	if preview {
+		return plan, nil
+	}
+
+	// Baris rencana disalin ke slice hasil, jadi referensi ujung kabel dipetakan ulang lewat Index
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		createdIDs := make(map[int]int)
+		for i := range plan.Nodes {
+			row := &plan.Nodes[i]
+			if row.Action != GeoActionCreate {
+				continue
+			}
+			node := models.NetworkNode{
+				Name:        row.Name,
+				Type:        row.Type,
+				Latitude:    row.Lat,
+				Longitude:   row.Lng,
+				Description: row.description,
+			}
+			if err := tx.Create(&node).Error; err != nil {
+				return fmt.Errorf("gagal membuat node %s: %v", row.Name, err)
+			}
+			if err := createImportedNodeDetail(tx, node, *row); err != nil {
+				return fmt.Errorf("gagal membuat detail node %s: %v", row.Name, err)
+			}
+			row.NodeID = node.NodeID
+			createdIDs[row.Index] = node.NodeID
+		}
+
+		for i := range plan.Cables {
+			cable := &plan.Cables[i]
+			if cable.Action != GeoActionCreate {
+				continue
+			}
+			if cable.sourceRef != nil {
+				cable.SourceNodeID = createdIDs[cable.sourceRef.Index]
+			}
+			if cable.targetRef != nil {
+				cable.TargetNodeID = createdIDs[cable.targetRef.Index]
+			}
+			coords, _ := json.Marshal(cable.path)
+			record := models.NetworkCable{
+				SourceNodeID: cable.SourceNodeID,
+				TargetNodeID: cable.TargetNodeID,
+				CableType:    cable.CableType,
+				Description:  cable.description,
+				LengthMeter:  cable.LengthMeter,
+				Coordinates:  string(coords),
+				CoreCount:    cable.coreCount,
+
+				ComputedLengthMeter: cable.LengthMeter,
+			}
+			if err := tx.Create(&record).Error; err != nil {
+				return fmt.Errorf("gagal membuat kabel %s: %v", cable.Name, err)
+			}
+			cable.CableID = record.CableID
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// createImportedNodeDetail membuat baris aset dengan default yang sama seperti AddNetworkNode
+func createImportedNodeDetail(tx *gorm.DB, node models.NetworkNode, row GeoImportNode) error {
+	switch node.Type {
+	case models.TypeODP:
+		ports := 8
+		if row.totalPorts > 0 {
+			ports = row.totalPorts
+		}
+		return tx.Create(&models.ODP{NodeID: node.NodeID, TotalPorts: ports}).Error
+	case models.TypeODC:
+		capacity := 144
+		if row.capacity > 0 {
+			capacity = row.capacity
+		}
+		return tx.Create(&models.ODC{NodeID: node.NodeID, Capacity: capacity}).Error
+	case models.TypeOLT:
+		return tx.Create(&models.OLT{NodeID: node.NodeID}).Error
+	}
+	return nil
+}
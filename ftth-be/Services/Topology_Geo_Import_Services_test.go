@@ -0,0 +1,222 @@
+package services
+
+import (
+	"akane/be-ftth/models"
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParseKML(t *testing.T) {
+	doc := `<?xml version="1.0" encoding="UTF-8"?>
+<kml xmlns="http://www.opengis.net/kml/2.2"><Document>
+  <Placemark><name>OLT Pusat</name><Point><coordinates>110.1,-7.1,0</coordinates></Point></Placemark>
+  <Folder><name>Area A</name>
+    <Folder><name>ODP</name>
+      <Placemark>
+        <name> ODP-01 </name>
+        <ExtendedData><Data name="Total_Ports"><value>16</value></Data></ExtendedData>
+        <Point><coordinates>110.2,-7.2</coordinates></Point>
+      </Placemark>
+    </Folder>
+    <Placemark><name>Feeder 1</name><LineString><coordinates>110.1,-7.1 110.2,-7.2</coordinates></LineString></Placemark>
+    <Placemark><name>Rusak</name><LineString><coordinates>110.1,-7.1</coordinates></LineString></Placemark>
+    <Placemark><name>Multi</name><MultiGeometry>
+      <Point><coordinates>110.3,-7.3</coordinates></Point>
+      <LineString><coordinates>110.3,-7.3 110.4,-7.4 110.5,-7.5</coordinates></LineString>
+    </MultiGeometry></Placemark>
+  </Folder>
+</Document></kml>`
+
+	// Placemark di satu container dibaca sebelum isi subfoldernya
+	features, err := parseKML([]byte(doc))
+	if err != nil {
+		t.Fatalf("parseKML: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		folder string
+		isLine bool
+		points int
+		first  [2]float64
+	}{
+		{"OLT Pusat", "", false, 1, [2]float64{-7.1, 110.1}},
+		{"Feeder 1", "Area A", true, 2, [2]float64{-7.1, 110.1}},
+		{"Multi", "Area A", false, 1, [2]float64{-7.3, 110.3}},
+		{"Multi", "Area A", true, 3, [2]float64{-7.3, 110.3}},
+		{"ODP-01", "Area A/ODP", false, 1, [2]float64{-7.2, 110.2}},
+	}
+	if len(features) != len(tests) {
+		t.Fatalf("expected %d features, got %d: %+v", len(tests), len(features), features)
+	}
+	for i, tt := range tests {
+		f := features[i]
+		if f.Name != tt.name || f.Folder != tt.folder || f.IsLine != tt.isLine || len(f.Path) != tt.points || f.Path[0] != tt.first {
+			t.Fatalf("feature %d: got %+v, want %+v", i, f, tt)
+		}
+	}
+	if features[4].Props["total_ports"] != "16" {
+		t.Fatalf("ExtendedData not mapped to lowercase props: %+v", features[4].Props)
+	}
+
+	if _, err := parseKML([]byte("<kml><Document>")); err == nil {
+		t.Fatalf("expected error for malformed KML")
+	}
+}
+
+func TestParseGeoJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		wantErr bool
+		want    []geoFeature
+	}{
+		{
+			name: "feature collection",
+			doc: `{"type":"FeatureCollection","features":[
+				{"type":"Feature","properties":{"Name":"ODP-01","type":"odp","folder":"Area A"},"geometry":{"type":"Point","coordinates":[110.2,-7.2]}},
+				{"type":"Feature","properties":{"name":"Drop"},"geometry":{"type":"LineString","coordinates":[[110.1,-7.1],[110.2,-7.2]]}},
+				{"type":"Feature","properties":{"name":"Kosong"},"geometry":null},
+				{"type":"Feature","properties":{"name":"Pendek"},"geometry":{"type":"LineString","coordinates":[[110.1,-7.1]]}}
+			]}`,
+			want: []geoFeature{
+				{Name: "ODP-01", Folder: "Area A", Path: [][2]float64{{-7.2, 110.2}}},
+				{Name: "Drop", IsLine: true, Path: [][2]float64{{-7.1, 110.1}, {-7.2, 110.2}}},
+			},
+		},
+		{
+			name: "single feature multi line",
+			doc:  `{"type":"Feature","properties":{"name":"Backbone"},"geometry":{"type":"MultiLineString","coordinates":[[[110,-7],[111,-8]],[[112,-9],[113,-10]]]}}`,
+			want: []geoFeature{
+				{Name: "Backbone", IsLine: true, Path: [][2]float64{{-7, 110}, {-8, 111}}},
+				{Name: "Backbone", IsLine: true, Path: [][2]float64{{-9, 112}, {-10, 113}}},
+			},
+		},
+		{name: "bare geometry", doc: `{"type":"Point","coordinates":[110,-7]}`, wantErr: true},
+		{name: "invalid json", doc: `{"type":`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseGeoJSON([]byte(tt.doc))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseGeoJSON: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %d features, got %+v", len(tt.want), got)
+			}
+			for i, w := range tt.want {
+				g := got[i]
+				if g.Name != w.Name || g.Folder != w.Folder || g.IsLine != w.IsLine || len(g.Path) != len(w.Path) {
+					t.Fatalf("feature %d: got %+v, want %+v", i, g, w)
+				}
+				for j := range w.Path {
+					if g.Path[j] != w.Path[j] {
+						t.Fatalf("feature %d point %d: got %v, want %v", i, j, g.Path[j], w.Path[j])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestPlanGeoFeaturesSnapping(t *testing.T) {
+	// 0.0001 derajat lintang ~ 11 m
+	existingNodes := []models.NetworkNode{
+		{NodeID: 1, Name: "ODP-A", Type: models.TypeODP, Latitude: -7.0, Longitude: 110.0},
+		{NodeID: 2, Name: "ODP-B", Type: models.TypeODP, Latitude: -7.002, Longitude: 110.0},
+	}
+	existingCables := []models.NetworkCable{{SourceNodeID: 1, TargetNodeID: 2}}
+
+	features := []geoFeature{
+		{Name: "TB-1", Path: [][2]float64{{-7.001, 110.0}}},
+		{Name: "odp-a", Path: [][2]float64{{-7.0001, 110.0}}},
+		{Name: "Tanpa Tipe", Path: [][2]float64{{-7.005, 110.0}}},
+		{Name: "Kabel Baru", IsLine: true, Path: [][2]float64{{-7.0001, 110.0}, {-7.0011, 110.0}}},
+		{Name: "Kabel Lama", IsLine: true, Path: [][2]float64{{-6.9999, 110.0}, {-7.0021, 110.0}}},
+		{Name: "Terlalu Jauh", IsLine: true, Path: [][2]float64{{-7.0, 110.0}, {-7.0035, 110.0}}},
+		{Name: "Simpul Sama", IsLine: true, Path: [][2]float64{{-7.0, 110.0}, {-7.0001, 110.0}}},
+	}
+
+	result := planGeoFeatures(features, GeoImportOptions{}, existingNodes, existingCables, 0)
+
+	nodeTests := []struct {
+		name   string
+		action string
+		nodeID int
+	}{
+		{"TB-1", GeoActionCreate, 0},
+		{"odp-a", GeoActionExists, 1},
+		{"Tanpa Tipe", GeoActionSkip, 0},
+	}
+	if len(result.Nodes) != len(nodeTests) {
+		t.Fatalf("expected %d nodes, got %+v", len(nodeTests), result.Nodes)
+	}
+	for i, tt := range nodeTests {
+		n := result.Nodes[i]
+		if n.Name != tt.name || n.Action != tt.action || n.NodeID != tt.nodeID {
+			t.Fatalf("node %d: got %s action=%s node_id=%d, want %+v", i, n.Name, n.Action, n.NodeID, tt)
+		}
+	}
+
+	cableTests := []struct {
+		name       string
+		action     string
+		sourceID   int
+		targetName string
+	}{
+		{"Kabel Baru", GeoActionCreate, 1, "TB-1"},
+		{"Kabel Lama", GeoActionExists, 1, "ODP-B"},
+		{"Terlalu Jauh", GeoActionSkip, 1, ""},
+		{"Simpul Sama", GeoActionSkip, 1, "ODP-A"},
+	}
+	if len(result.Cables) != len(cableTests) {
+		t.Fatalf("expected %d cables, got %+v", len(cableTests), result.Cables)
+	}
+	for i, tt := range cableTests {
+		c := result.Cables[i]
+		if c.Name != tt.name || c.Action != tt.action || c.SourceNodeID != tt.sourceID || c.TargetName != tt.targetName {
+			t.Fatalf("cable %d: got %s action=%s source=%d target=%q (%s), want %+v",
+				i, c.Name, c.Action, c.SourceNodeID, c.TargetName, c.Reason, tt)
+		}
+	}
+	if result.Cables[0].targetRef == nil || result.Cables[0].targetRef.Name != "TB-1" {
+		t.Fatalf("new cable should reference the new TB node from the file")
+	}
+	if result.Cables[0].LengthMeter <= 100 || result.Cables[0].LengthMeter >= 120 {
+		t.Fatalf("unexpected computed length %.1f", result.Cables[0].LengthMeter)
+	}
+	if result.Summary["cable_create"] != 1 || result.Summary["node_exists"] != 1 {
+		t.Fatalf("unexpected summary %+v", result.Summary)
+	}
+}
+
+func TestExtractKMZRejectsOversizedEntry(t *testing.T) {
+	build := func(name string, size int) []byte {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("zip create: %v", err)
+		}
+		w.Write([]byte(strings.Repeat(" ", size)))
+		zw.Close()
+		return buf.Bytes()
+	}
+
+	kml, err := extractKMZ(build("doc.kml", 64))
+	if err != nil || len(kml) != 64 {
+		t.Fatalf("expected small KML to be extracted, got %d bytes, err %v", len(kml), err)
+	}
+	if _, err := extractKMZ(build("doc.kml", maxKMZEntryBytes+1)); err == nil {
+		t.Fatalf("expected oversized KML entry to be rejected")
+	}
+}
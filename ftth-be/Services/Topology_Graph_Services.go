@@ -0,0 +1,308 @@
+package services
+
+import (
+	"akane/be-ftth/config"
+	"akane/be-ftth/models"
+	"container/heap"
+	"fmt"
+	"strconv"
+)
+
+// Peringkat node dari hulu (router) ke hilir (rumah pelanggan). TB (terminal box / joint closure)
+// tidak punya peringkat sendiri dan mewarisi peringkat node sebelumnya.
+var nodeRank = map[models.NodeType]int{
+	models.TypeRouter: 0,
+	models.TypeOLT:    1,
+	models.TypeODC:    2,
+	models.TypeODP:    3,
+	models.TypeClient: 4,
+}
+
+// TopologyEdge adalah satu kabel dilihat dari salah satu ujungnya
+type TopologyEdge struct {
+	Cable models.NetworkCable
+	To    int
+}
+
+// TopologyGraph adalah graf tak berarah node & kabel. Arah source/target kabel di peta
+// tidak konsisten (tergantung cara teknisi menggambar), jadi arah hulu ditentukan dari jenis node.
+type TopologyGraph struct {
+	Nodes  map[int]models.NetworkNode
+	Cables map[int]models.NetworkCable
+	Adj    map[int][]TopologyEdge
+}
+
+// LoadTopologyGraph memuat semua node dan kabel ke memori
+func LoadTopologyGraph() (*TopologyGraph, error) {
+	var nodes []models.NetworkNode
+	if err := config.DB.Find(&nodes).Error; err != nil {
+		return nil, err
+	}
+	var cables []models.NetworkCable
+	if err := config.DB.Find(&cables).Error; err != nil {
+		return nil, err
+	}
+	return NewTopologyGraph(nodes, cables), nil
+}
+
+// NewTopologyGraph membangun graf dari node & kabel yang sudah dimuat
+func NewTopologyGraph(nodes []models.NetworkNode, cables []models.NetworkCable) *TopologyGraph {
+	g := &TopologyGraph{
+		Nodes:  make(map[int]models.NetworkNode, len(nodes)),
+		Cables: make(map[int]models.NetworkCable, len(cables)),
+		Adj:    make(map[int][]TopologyEdge, len(nodes)),
+	}
+	for _, n := range nodes {
+		g.Nodes[n.NodeID] = n
+	}
+	for _, c := range cables {
+		if _, ok := g.Nodes[c.SourceNodeID]; !ok {
+			continue
+		}
+		if _, ok := g.Nodes[c.TargetNodeID]; !ok {
+			continue
+		}
+		g.Cables[c.CableID] = c
+		g.Adj[c.SourceNodeID] = append(g.Adj[c.SourceNodeID], TopologyEdge{Cable: c, To: c.TargetNodeID})
+		g.Adj[c.TargetNodeID] = append(g.Adj[c.TargetNodeID], TopologyEdge{Cable: c, To: c.SourceNodeID})
+	}
+	return g
+}
+
+// TraceNode adalah ringkasan node pada hasil trace
+type TraceNode struct {
+	NodeID         int             `json:"node_id"`
+	Name           string          `json:"name"`
+	Type           models.NodeType `json:"type"`
+	Lat            float64         `json:"lat"`
+	Lng            float64         `json:"lng"`
+	LinkedRouterID string          `json:"linked_router_id,omitempty"`
+}
+
+// TraceHop adalah satu langkah jalur: node beserta kabel yang menghubungkannya ke node sebelumnya
+type TraceHop struct {
+	Node        TraceNode `json:"node"`
+	CableID     int       `json:"cable_id,omitempty"`
+	CableType   string    `json:"cable_type,omitempty"`
+	Description string    `json:"cable_description,omitempty"`
+	LengthMeter float64   `json:"length_meter"`
+	Cumulative  float64   `json:"cumulative_meter"`       // Total panjang kabel dari node awal sampai node ini
+	OltPonPort  string    `json:"olt_pon_port,omitempty"` // Port PON OLT pada kabel ini (jika kabel menempel ke OLT)
+}
+
+// TraceResult adalah jalur dari node awal ke hulu (OLT/router), urut dari node awal
+type TraceResult struct {
+	Start            TraceNode  `json:"start"`
+	Hops             []TraceHop `json:"hops"`
+	TotalLengthMeter float64    `json:"total_length_meter"`
+	ODP              *TraceNode `json:"odp"`
+	ODC              *TraceNode `json:"odc"`
+	OLT              *TraceNode `json:"olt"`
+	OLTPonPort       string     `json:"olt_pon_port,omitempty"` // Port PON OLT dari kabel yang masuk ke OLT, kosong jika belum didata
+	Router           *TraceNode `json:"router"`
+	RouterID         string     `json:"router_id,omitempty"` // Router Mikrotik dari linked_router_id node ROUTER/OLT
+	Complete         bool       `json:"complete"`            // true jika jalur sampai ke OLT atau router
+}
+
+func toTraceNode(n models.NetworkNode) TraceNode {
+	t := TraceNode{NodeID: n.NodeID, Name: n.Name, Type: n.Type, Lat: n.Latitude, Lng: n.Longitude}
+	if n.LinkedRouterID != nil {
+		t.LinkedRouterID = n.LinkedRouterID.String()
+	}
+	return t
+}
+
+// traceItem adalah state antrean prioritas: hop paling sedikit dulu, lalu kabel terpendek
+type traceItem struct {
+	node   int
+	rank   int
+	hops   int
+	length float64
+	from   int
+	cable  models.NetworkCable
+	index  int
+}
+
+type traceQueue []*traceItem
+
+func (q traceQueue) Len() int { return len(q) }
+func (q traceQueue) Less(i, j int) bool {
+	if q[i].hops != q[j].hops {
+		return q[i].hops < q[j].hops
+	}
+	return q[i].length < q[j].length
+}
+func (q traceQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i]; q[i].index = i; q[j].index = j }
+func (q *traceQueue) Push(x interface{}) {
+	it := x.(*traceItem)
+	it.index = len(*q)
+	*q = append(*q, it)
+}
+func (q *traceQueue) Pop() interface{} {
+	old := *q
+	it := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return it
+}
+
+// TraceUpstream mencari jalur dari node ke router (atau OLT jika tidak ada router) dengan hanya
+// bergerak ke node yang peringkatnya sama atau lebih hulu, sehingga tidak menyasar ke cabang pelanggan lain.
+func (g *TopologyGraph) TraceUpstream(nodeID int) (*TraceResult, error) {
+	start, ok := g.Nodes[nodeID]
+	if !ok {
+		return nil, fmt.Errorf("node tidak ditemukan")
+	}
+
+	startRank, ok := nodeRank[start.Type]
+	if !ok {
+		startRank = nodeRank[models.TypeClient]
+	}
+
+	type prevStep struct {
+		from  int
+		cable models.NetworkCable
+	}
+	prev := make(map[int]prevStep)
+	visited := make(map[int]bool)
+
+	q := &traceQueue{}
+	heap.Push(q, &traceItem{node: nodeID, rank: startRank, from: -1})
+
+	target, bestOLT := -1, -1
+	for q.Len() > 0 {
+		it := heap.Pop(q).(*traceItem)
+		if visited[it.node] {
+			continue
+		}
+		visited[it.node] = true
+		if it.from != -1 {
+			prev[it.node] = prevStep{from: it.from, cable: it.cable}
+		}
+
+		n := g.Nodes[it.node]
+		if n.Type == models.TypeRouter && it.node != nodeID {
+			target = it.node
+			break
+		}
+		if n.Type == models.TypeOLT && bestOLT == -1 {
+			bestOLT = it.node
+		}
+
+		for _, e := range g.Adj[it.node] {
+			if visited[e.To] {
+				continue
+			}
+			rank, ranked := nodeRank[g.Nodes[e.To].Type]
+			if !ranked {
+				rank = it.rank // TB meneruskan peringkat
+			}
+			if rank > it.rank {
+				continue
+			}
+			heap.Push(q, &traceItem{
+				node: e.To, rank: rank, hops: it.hops + 1, length: it.length + e.Cable.LengthMeter,
+				from: it.node, cable: e.Cable,
+			})
+		}
+	}
+	if target == -1 {
+		target = bestOLT
+	}
+
+	result := &TraceResult{Start: toTraceNode(start), Hops: []TraceHop{}}
+	if target == -1 {
+		// Tidak sampai OLT/router: kembalikan node awal saja agar UI bisa menandai jalur terputus
+		result.Hops = append(result.Hops, TraceHop{Node: result.Start})
+		return result, nil
+	}
+	result.Complete = true
+
+	// Susun jalur dari target mundur ke node awal, lalu dibalik
+	var reversed []TraceHop
+	for cur := target; cur != nodeID; cur = prev[cur].from {
+		step := prev[cur]
+		reversed = append(reversed, TraceHop{
+			Node:        toTraceNode(g.Nodes[cur]),
+			CableID:     step.cable.CableID,
+			CableType:   step.cable.CableType,
+			Description: step.cable.Description,
+			LengthMeter: step.cable.LengthMeter,
+			OltPonPort:  step.cable.OltPonPort,
+		})
+	}
+	result.Hops = append(result.Hops, TraceHop{Node: result.Start})
+	for i := len(reversed) - 1; i >= 0; i-- {
+		result.Hops = append(result.Hops, reversed[i])
+	}
+
+	for i := range result.Hops {
+		h := &result.Hops[i]
+		result.TotalLengthMeter += h.LengthMeter
+		h.Cumulative = result.TotalLengthMeter
+
+		node := h.Node
+		switch node.Type {
+		case models.TypeODP:
+			if result.ODP == nil {
+				result.ODP = &node
+			}
+		case models.TypeODC:
+			if result.ODC == nil {
+				result.ODC = &node
+			}
+		case models.TypeOLT:
+			if result.OLT == nil {
+				result.OLT = &node
+				result.OLTPonPort = h.OltPonPort
+			}
+		case models.TypeRouter:
+			result.Router = &node
+		}
+		if result.RouterID == "" && node.LinkedRouterID != "" && (node.Type == models.TypeRouter || node.Type == models.TypeOLT) {
+			result.RouterID = node.LinkedRouterID
+		}
+	}
+	return result, nil
+}
+
+// TraceNodeUpstream memuat graf lalu melakukan trace untuk satu node
+func TraceNodeUpstream(nodeID int) (*TraceResult, error) {
+	g, err := LoadTopologyGraph()
+	if err != nil {
+		return nil, err
+	}
+	return g.TraceUpstream(nodeID)
+}
+
+// clientsForNodes memetakan node CLIENT ke pelanggan CRM (client_nodes.subscriber_id = clients.client_id)
+func clientsForNodes(nodeIDs []int) (map[int]models.Client, error) {
+	result := make(map[int]models.Client, len(nodeIDs))
+	if len(nodeIDs) == 0 {
+		return result, nil
+	}
+
+	var details []models.ClientNode
+	if err := config.DB.Where("node_id IN ?", nodeIDs).Find(&details).Error; err != nil {
+		return nil, err
+	}
+	nodeByClient := make(map[int]int, len(details))
+	var clientIDs []int
+	for _, d := range details {
+		if id, err := strconv.Atoi(d.SubscriberID); err == nil {
+			nodeByClient[id] = d.NodeID
+			clientIDs = append(clientIDs, id)
+		}
+	}
+	if len(clientIDs) == 0 {
+		return result, nil
+	}
+
+	var clients []models.Client
+	if err := config.DB.Select("client_id", "name", "phone", "address", "fat", "onu_status", "rx_power").Where("client_id IN ?", clientIDs).Find(&clients).Error; err != nil {
+		return nil, err
+	}
+	for _, cl := range clients {
+		result[nodeByClient[cl.ClientID]] = cl
+	}
+	return result, nil
+}
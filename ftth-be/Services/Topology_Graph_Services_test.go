@@ -0,0 +1,34 @@
+package services
+
+import (
+	"akane/be-ftth/models"
+	"testing"
+)
+
+func TestTraceUpstreamReportsOltPonPort(t *testing.T) {
+	nodes := []models.NetworkNode{
+		{NodeID: 1, Name: "OLT-1", Type: models.TypeOLT},
+		{NodeID: 2, Name: "ODC-1", Type: models.TypeODC},
+		{NodeID: 3, Name: "ODP-1", Type: models.TypeODP},
+		{NodeID: 4, Name: "Rumah A", Type: models.TypeClient},
+	}
+	cables := []models.NetworkCable{
+		{CableID: 10, SourceNodeID: 1, TargetNodeID: 2, CableType: "BACKBONE", LengthMeter: 1000, OltPonPort: "1/1/3"},
+		{CableID: 20, SourceNodeID: 2, TargetNodeID: 3, CableType: "DISTRIBUSI", LengthMeter: 300},
+		{CableID: 30, SourceNodeID: 3, TargetNodeID: 4, CableType: "DROP CLIENT", LengthMeter: 50},
+	}
+
+	result, err := NewTopologyGraph(nodes, cables).TraceUpstream(4)
+	if err != nil {
+		t.Fatalf("TraceUpstream: %v", err)
+	}
+	if !result.Complete || len(result.Hops) != 4 || result.TotalLengthMeter != 1350 {
+		t.Fatalf("unexpected trace %+v", result)
+	}
+	if result.ODP == nil || result.ODP.NodeID != 3 || result.ODC == nil || result.ODC.NodeID != 2 || result.OLT == nil || result.OLT.NodeID != 1 {
+		t.Fatalf("unexpected ODP/ODC/OLT %+v %+v %+v", result.ODP, result.ODC, result.OLT)
+	}
+	if result.OLTPonPort != "1/1/3" || result.Hops[3].OltPonPort != "1/1/3" {
+		t.Fatalf("expected OLT PON port 1/1/3, got %q", result.OLTPonPort)
+	}
+}
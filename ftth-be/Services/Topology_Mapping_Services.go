@@ -0,0 +1,224 @@
+package services
+
+import (
+	"akane/be-ftth/config"
+	"akane/be-ftth/models"
+	"fmt"
+	"log"
+	"sort"
+)
+
+const (
+	MappingNew        = "new"        // Belum ada TopologyMapping untuk ODP ini
+	MappingMatch      = "match"      // Sama dengan TopologyMapping yang tersimpan
+	MappingConflict   = "conflict"   // Berbeda dengan TopologyMapping yang tersimpan, perlu persetujuan
+	MappingIncomplete = "incomplete" // Jalur kabel tidak sampai ke ODC/OLT/router yang terdaftar
+)
+
+// DerivedMapping adalah jalur router-OLT-ODC hasil penelusuran kabel dari satu ODP
+type DerivedMapping struct {
+	ODPNodeID int      `json:"odp_node_id"`
+	ODPName   string   `json:"odp_name"`
+	ODCNodeID int      `json:"odc_node_id"`
+	ODCName   string   `json:"odc_name"`
+	OLTNodeID int      `json:"olt_node_id"`
+	OLTName   string   `json:"olt_name"`
+	OLTPort   string   `json:"olt_pon_port,omitempty"`
+	RouterID  string   `json:"router_id"`
+	Missing   []string `json:"missing,omitempty"` // Bagian jalur yang tidak ditemukan
+}
+
+// MappingProposal membandingkan hasil penelusuran dengan TopologyMapping yang tersimpan
+type MappingProposal struct {
+	Status      string                  `json:"status"`
+	Derived     DerivedMapping          `json:"derived"`
+	Existing    *models.TopologyMapping `json:"existing,omitempty"`
+	Differences []string                `json:"differences,omitempty"`
+}
+
+// deriveMapping menelusuri ODP ke hulu. RouterID hanya dipakai jika router terdaftar di tabel routers.
+func (g *TopologyGraph) deriveMapping(odpNodeID int, routers map[string]bool) (DerivedMapping, error) {
+	odp := g.Nodes[odpNodeID]
+	derived := DerivedMapping{ODPNodeID: odpNodeID, ODPName: odp.Name}
+
+	trace, err := g.TraceUpstream(odpNodeID)
+	if err != nil {
+		return derived, err
+	}
+	if trace.ODC != nil {
+		derived.ODCNodeID, derived.ODCName = trace.ODC.NodeID, trace.ODC.Name
+	} else {
+		derived.Missing = append(derived.Missing, "ODC")
+	}
+	if trace.OLT != nil {
+		derived.OLTNodeID, derived.OLTName, derived.OLTPort = trace.OLT.NodeID, trace.OLT.Name, trace.OLTPonPort
+	} else {
+		derived.Missing = append(derived.Missing, "OLT")
+	}
+	if trace.RouterID != "" && routers[trace.RouterID] {
+		derived.RouterID = trace.RouterID
+	} else {
+		derived.Missing = append(derived.Missing, "router")
+	}
+	return derived, nil
+}
+
+func mappingDifferences(d DerivedMapping, m models.TopologyMapping) []string {
+	var diffs []string
+	if d.RouterID != m.RouterID {
+		diffs = append(diffs, fmt.Sprintf("router: %s -> %s", m.RouterID, d.RouterID))
+	}
+	if d.OLTNodeID != m.OLTNodeID {
+		diffs = append(diffs, fmt.Sprintf("olt_node_id: %d -> %d", m.OLTNodeID, d.OLTNodeID))
+	}
+	if d.ODCNodeID != m.ODCNodeID {
+		diffs = append(diffs, fmt.Sprintf("odc_node_id: %d -> %d", m.ODCNodeID, d.ODCNodeID))
+	}
+	return diffs
+}
+
+// DeriveTopologyMappings menghitung jalur setiap ODP dari kabel dan membandingkannya dengan TopologyMapping
+func DeriveTopologyMappings() ([]MappingProposal, error) {
+	g, err := LoadTopologyGraph()
+	if err != nil {
+		return nil, err
+	}
+
+	var routerRows []models.Router
+	if err := config.DB.Select("router_id").Find(&routerRows).Error; err != nil {
+		return nil, err
+	}
+	routers := make(map[string]bool, len(routerRows))
+	for _, r := range routerRows {
+		routers[r.RouterID.String()] = true
+	}
+
+	var mappings []models.TopologyMapping
+	if err := config.DB.Find(&mappings).Error; err != nil {
+		return nil, err
+	}
+	existingByODP := make(map[int]models.TopologyMapping, len(mappings))
+	for _, m := range mappings {
+		existingByODP[m.ODPNodeID] = m
+	}
+
+	proposals := []MappingProposal{}
+	for id, n := range g.Nodes {
+		if n.Type != models.TypeODP {
+			continue
+		}
+		derived, err := g.deriveMapping(id, routers)
+		if err != nil {
+			return nil, err
+		}
+
+		p := MappingProposal{Derived: derived}
+		if existing, ok := existingByODP[id]; ok {
+			m := existing
+			p.Existing = &m
+		}
+		switch {
+		case len(derived.Missing) > 0:
+			p.Status = MappingIncomplete
+		case p.Existing == nil:
+			p.Status = MappingNew
+		default:
+			p.Differences = mappingDifferences(derived, *p.Existing)
+			p.Status = MappingMatch
+			if len(p.Differences) > 0 {
+				p.Status = MappingConflict
+			}
+		}
+		proposals = append(proposals, p)
+	}
+
+	sort.Slice(proposals, func(i, j int) bool { return proposals[i].Derived.ODPName < proposals[j].Derived.ODPName })
+	return proposals, nil
+}
+
+// ApplyDerivedMappings menyimpan hasil penelusuran untuk ODP yang disetujui (membuat baru atau
+// menimpa mapping yang konflik). ODP yang jalurnya tidak lengkap dilewati.
+func ApplyDerivedMappings(odpNodeIDs []int) ([]DerivedMapping, error) {
+	proposals, err := DeriveTopologyMappings()
+	if err != nil {
+		return nil, err
+	}
+	approved := make(map[int]bool, len(odpNodeIDs))
+	for _, id := range odpNodeIDs {
+		approved[id] = true
+	}
+
+	applied := []DerivedMapping{}
+	for _, p := range proposals {
+		if !approved[p.Derived.ODPNodeID] || (p.Status != MappingNew && p.Status != MappingConflict) {
+			continue
+		}
+		if err := saveDerivedMapping(p); err != nil {
+			return applied, err
+		}
+		applied = append(applied, p.Derived)
+	}
+	return applied, nil
+}
+
+func saveDerivedMapping(p MappingProposal) error {
+	d := p.Derived
+	if p.Existing == nil {
+		return config.DB.Create(&models.TopologyMapping{
+			RouterID:  d.RouterID,
+			OLTNodeID: d.OLTNodeID,
+			ODCNodeID: d.ODCNodeID,
+			ODPNodeID: d.ODPNodeID,
+		}).Error
+	}
+	return config.DB.Model(&models.TopologyMapping{}).Where("mapping_id = ?", p.Existing.MappingID).
+		Updates(map[string]interface{}{
+			"router_id":   d.RouterID,
+			"olt_node_id": d.OLTNodeID,
+			"odc_node_id": d.ODCNodeID,
+		}).Error
+}
+
+// DeriveRouterForODPName menelusuri kabel dari ODP dengan nama tersebut untuk mendapatkan router ID
+func DeriveRouterForODPName(name string) (string, error) {
+	var odp models.NetworkNode
+	if err := config.DB.Where("type = ? AND name = ?", models.TypeODP, name).First(&odp).Error; err != nil {
+		return "", fmt.Errorf("ODP %s tidak ditemukan di peta", name)
+	}
+
+	trace, err := TraceNodeUpstream(odp.NodeID)
+	if err != nil {
+		return "", err
+	}
+	if trace.RouterID == "" {
+		return "", fmt.Errorf("jalur kabel ODP %s belum sampai ke router", name)
+	}
+	return trace.RouterID, nil
+}
+
+// RunTopologyMappingJob membuat TopologyMapping untuk ODP yang belum punya mapping.
+// Mapping yang berbeda dengan hasil penelusuran tidak diubah, hanya dilaporkan untuk disetujui admin.
+func RunTopologyMappingJob() (int, error) {
+	proposals, err := DeriveTopologyMappings()
+	if err != nil {
+		return 0, err
+	}
+
+	created, conflicts := 0, 0
+	for _, p := range proposals {
+		switch p.Status {
+		case MappingNew:
+			if err := saveDerivedMapping(p); err != nil {
+				log.Printf("[JOB-MAPPING] Gagal membuat mapping ODP %s: %v", p.Derived.ODPName, err)
+				continue
+			}
+			created++
+		case MappingConflict:
+			conflicts++
+		}
+	}
+	if conflicts > 0 {
+		log.Printf("[JOB-MAPPING] %d mapping berbeda dengan jalur kabel, menunggu persetujuan admin", conflicts)
+	}
+	return created, nil
+}
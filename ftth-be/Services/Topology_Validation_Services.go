@@ -0,0 +1,443 @@
+package services
+
+import (
+	"akane/be-ftth/config"
+	"akane/be-ftth/models"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	SeverityError   = "error"   // Topologi pasti salah, mengganggu trace / mapping / loss budget
+	SeverityWarning = "warning" // Tidak lazim, perlu dicek di lapangan
+)
+
+// Aturan validasi (kolom rule pada TopologyViolation)
+const (
+	RuleInvalidNodeType   = "invalid_node_type"
+	RuleInvalidCoordinate = "invalid_coordinates"
+	RuleInvalidLink       = "invalid_link"
+	RuleSelfLoop          = "self_loop"
+	RuleDanglingCable     = "dangling_cable"
+	RuleDuplicateCable    = "duplicate_cable"
+	RuleInvalidCablePath  = "invalid_cable_path"
+	RuleCycle             = "cycle"
+	RuleODPOverCapacity   = "odp_over_capacity"
+	RuleMissingDetail     = "missing_detail"
+	RuleClientDeleted     = "client_deleted"
+	RuleClientNotFound    = "client_not_found"
+	RuleOrphanNode        = "orphan_node"
+	RuleNoUpstream        = "no_upstream"
+)
+
+// topologyLinkRules adalah aturan kabel per pasangan tipe node (urutan abjad). "" = sah,
+// pasangan yang tidak terdaftar dianggap tidak lazim (warning).
+var topologyLinkRules = map[[2]models.NodeType]string{
+	linkKey(models.TypeRouter, models.TypeRouter): "",
+	linkKey(models.TypeRouter, models.TypeOLT):    "",
+	linkKey(models.TypeRouter, models.TypeTB):     "",
+	linkKey(models.TypeOLT, models.TypeODC):       "",
+	linkKey(models.TypeOLT, models.TypeODP):       "",
+	linkKey(models.TypeOLT, models.TypeTB):        "",
+	linkKey(models.TypeODC, models.TypeODC):       "",
+	linkKey(models.TypeODC, models.TypeODP):       "",
+	linkKey(models.TypeODC, models.TypeTB):        "",
+	linkKey(models.TypeODP, models.TypeODP):       "",
+	linkKey(models.TypeODP, models.TypeTB):        "",
+	linkKey(models.TypeODP, models.TypeClient):    "",
+	linkKey(models.TypeTB, models.TypeTB):         "",
+	linkKey(models.TypeTB, models.TypeClient):     "",
+	linkKey(models.TypeClient, models.TypeClient): SeverityError,
+	linkKey(models.TypeClient, models.TypeOLT):    SeverityError,
+	linkKey(models.TypeClient, models.TypeODC):    SeverityError,
+	linkKey(models.TypeClient, models.TypeRouter): SeverityError,
+}
+
+func linkKey(a, b models.NodeType) [2]models.NodeType {
+	if a > b {
+		a, b = b, a
+	}
+	return [2]models.NodeType{a, b}
+}
+
+// linkSeverity mengembalikan severity kabel antara dua tipe node ("" = sah)
+func linkSeverity(a, b models.NodeType) string {
+	if sev, ok := topologyLinkRules[linkKey(a, b)]; ok {
+		return sev
+	}
+	return SeverityWarning
+}
+
+// TopologyLinkRule adalah satu baris aturan pasangan tipe node untuk ditampilkan di laporan
+type TopologyLinkRule struct {
+	A        models.NodeType `json:"a"`
+	B        models.NodeType `json:"b"`
+	Severity string          `json:"severity"` // "" = sah
+}
+
+// TopologyViolation adalah satu pelanggaran aturan topologi
+type TopologyViolation struct {
+	Rule     string  `json:"rule"`
+	Severity string  `json:"severity"`
+	Message  string  `json:"message"`
+	NodeID   int     `json:"node_id,omitempty"`
+	CableID  int     `json:"cable_id,omitempty"`
+	NodeIDs  []int   `json:"node_ids,omitempty"` // Node yang membentuk siklus
+	Lat      float64 `json:"lat,omitempty"`
+	Lng      float64 `json:"lng,omitempty"`
+	Link     string  `json:"link"` // Halaman peta yang menyorot node / kabel
+}
+
+// TopologyValidationReport adalah hasil validasi seluruh topologi
+type TopologyValidationReport struct {
+	GeneratedAt time.Time           `json:"generated_at"`
+	TotalNodes  int                 `json:"total_nodes"`
+	TotalCables int                 `json:"total_cables"`
+	Errors      int                 `json:"errors"`
+	Warnings    int                 `json:"warnings"`
+	ByRule      map[string]int      `json:"by_rule"`
+	LinkRules   []TopologyLinkRule  `json:"link_rules"`
+	Violations  []TopologyViolation `json:"violations"`
+}
+
+type topologyValidator struct {
+	nodes      map[int]models.NetworkNode
+	cables     []models.NetworkCable
+	graph      *TopologyGraph
+	violations []TopologyViolation
+}
+
+func (v *topologyValidator) addNode(rule, severity string, n models.NetworkNode, format string, args ...interface{}) {
+	v.violations = append(v.violations, TopologyViolation{
+		Rule:     rule,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+		NodeID:   n.NodeID,
+		Lat:      n.Latitude,
+		Lng:      n.Longitude,
+		Link:     fmt.Sprintf("/admin/network-map?node=%d", n.NodeID),
+	})
+}
+
+func (v *topologyValidator) addCable(rule, severity string, c models.NetworkCable, format string, args ...interface{}) {
+	v.violations = append(v.violations, TopologyViolation{
+		Rule:     rule,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+		CableID:  c.CableID,
+		Link:     fmt.Sprintf("/admin/network-map?cable=%d", c.CableID),
+	})
+}
+
+func (v *topologyValidator) cableLabel(c models.NetworkCable) string {
+	return fmt.Sprintf("%s (%s) - %s (%s)",
+		v.nodes[c.SourceNodeID].Name, v.nodes[c.SourceNodeID].Type,
+		v.nodes[c.TargetNodeID].Name, v.nodes[c.TargetNodeID].Type)
+}
+
+// checkNodes: tipe node, koordinat dan detail aset
+func (v *topologyValidator) checkNodes() {
+	for _, n := range v.nodes {
+		if !importableNodeTypes[n.Type] && n.Type != models.TypeClient {
+			v.addNode(RuleInvalidNodeType, SeverityError, n, "Node %s memiliki tipe tidak dikenal: %q", n.Name, n.Type)
+		}
+		if n.Latitude < -90 || n.Latitude > 90 || n.Longitude < -180 || n.Longitude > 180 || (n.Latitude == 0 && n.Longitude == 0) {
+			v.addNode(RuleInvalidCoordinate, SeverityWarning, n, "Koordinat node %s tidak valid (%f, %f)", n.Name, n.Latitude, n.Longitude)
+		}
+		switch {
+		case n.Type == models.TypeODP && n.ODPDetail == nil,
+			n.Type == models.TypeODC && n.ODCDetail == nil,
+			n.Type == models.TypeOLT && n.OLTDetail == nil:
+			v.addNode(RuleMissingDetail, SeverityWarning, n, "Node %s %s belum punya data aset", n.Type, n.Name)
+		case n.Type == models.TypeClient && n.ClientDetail == nil:
+			v.addNode(RuleMissingDetail, SeverityError, n, "Node CLIENT %s tidak terhubung ke data pelanggan", n.Name)
+		}
+	}
+}
+
+// checkCables: kabel ke node yang tidak ada, loop ke diri sendiri, kabel ganda, pasangan tipe dan jalur koordinat
+func (v *topologyValidator) checkCables() {
+	seenPair := make(map[[2]int]int)
+	for _, c := range v.cables {
+		_, okSrc := v.nodes[c.SourceNodeID]
+		_, okDst := v.nodes[c.TargetNodeID]
+		if !okSrc || !okDst {
+			v.addCable(RuleDanglingCable, SeverityError, c, "Kabel %d terhubung ke node yang tidak ada (%d - %d)", c.CableID, c.SourceNodeID, c.TargetNodeID)
+			continue
+		}
+		if c.SourceNodeID == c.TargetNodeID {
+			v.addCable(RuleSelfLoop, SeverityError, c, "Kabel %d berawal dan berakhir di node yang sama (%s)", c.CableID, v.nodes[c.SourceNodeID].Name)
+			continue
+		}
+
+		pair := [2]int{c.SourceNodeID, c.TargetNodeID}
+		if pair[0] > pair[1] {
+			pair[0], pair[1] = pair[1], pair[0]
+		}
+		if first, ok := seenPair[pair]; ok {
+			v.addCable(RuleDuplicateCable, SeverityWarning, c, "Kabel %d duplikat dengan kabel %d: %s", c.CableID, first, v.cableLabel(c))
+		} else {
+			seenPair[pair] = c.CableID
+		}
+
+		src, dst := v.nodes[c.SourceNodeID], v.nodes[c.TargetNodeID]
+		if sev := linkSeverity(src.Type, dst.Type); sev != "" {
+			v.addCable(RuleInvalidLink, sev, c, "Kabel %s -> %s tidak sesuai aturan: %s", src.Type, dst.Type, v.cableLabel(c))
+		}
+		if _, err := ParseCableCoordinates(c.Coordinates); err != nil {
+			v.addCable(RuleInvalidCablePath, SeverityWarning, c, "Jalur kabel %s tidak bisa dibaca: %v", v.cableLabel(c), err)
+		}
+	}
+}
+
+// checkCycles mencari kabel yang menutup siklus memakai union-find; kabel ganda dan loop sudah dilaporkan terpisah.
+// Siklus yang melewati CLIENT dianggap error, ring di jaringan distribusi / backbone cukup warning.
+func (v *topologyValidator) checkCycles() {
+	parent := make(map[int]int, len(v.nodes))
+	var find func(int) int
+	find = func(x int) int {
+		if p, ok := parent[x]; ok && p != x {
+			parent[x] = find(p)
+			return parent[x]
+		}
+		parent[x] = x
+		return x
+	}
+
+	tree := make(map[int][]int)
+	seenPair := make(map[[2]int]bool)
+	cables := make([]models.NetworkCable, 0, len(v.graph.Cables))
+	for _, c := range v.graph.Cables {
+		cables = append(cables, c)
+	}
+	sort.Slice(cables, func(i, j int) bool { return cables[i].CableID < cables[j].CableID })
+
+	for _, c := range cables {
+		a, b := c.SourceNodeID, c.TargetNodeID
+		pair := [2]int{a, b}
+		if a > b {
+			pair = [2]int{b, a}
+		}
+		if a == b || seenPair[pair] {
+			continue
+		}
+		seenPair[pair] = true
+
+		ra, rb := find(a), find(b)
+		if ra != rb {
+			parent[ra] = rb
+			tree[a] = append(tree[a], b)
+			tree[b] = append(tree[b], a)
+			continue
+		}
+
+		cycle := treePath(tree, a, b)
+		severity := SeverityWarning
+		for _, id := range cycle {
+			if v.nodes[id].Type == models.TypeClient {
+				severity = SeverityError
+				break
+			}
+		}
+		v.violations = append(v.violations, TopologyViolation{
+			Rule:     RuleCycle,
+			Severity: severity,
+			Message:  fmt.Sprintf("Kabel %s menutup siklus melalui %d node", v.cableLabel(c), len(cycle)),
+			CableID:  c.CableID,
+			NodeIDs:  cycle,
+			Link:     fmt.Sprintf("/admin/network-map?cable=%d", c.CableID),
+		})
+	}
+}
+
+// treePath mencari jalur dari a ke b di spanning forest (BFS)
+func treePath(tree map[int][]int, a, b int) []int {
+	prev := map[int]int{a: a}
+	queue := []int{a}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		if cur == b {
+			break
+		}
+		for _, next := range tree[cur] {
+			if _, ok := prev[next]; !ok {
+				prev[next] = cur
+				queue = append(queue, next)
+			}
+		}
+	}
+	if _, ok := prev[b]; !ok {
+		return []int{a, b}
+	}
+	path := []int{b}
+	for cur := b; cur != a; {
+		cur = prev[cur]
+		path = append(path, cur)
+	}
+	return path
+}
+
+// checkODPCapacity: kabel drop ke CLIENT (atau port terpakai) melebihi TotalPorts
+func (v *topologyValidator) checkODPCapacity(portCounts map[int]int) {
+	for id, n := range v.nodes {
+		if n.Type != models.TypeODP || n.ODPDetail == nil {
+			continue
+		}
+		total := n.ODPDetail.TotalPorts
+		drops, links := 0, len(v.graph.Adj[id])
+		for _, e := range v.graph.Adj[id] {
+			if v.nodes[e.To].Type == models.TypeClient {
+				drops++
+			}
+		}
+		if used, ok := portCounts[id]; ok && used > drops {
+			drops = used
+		}
+		switch {
+		case drops > total:
+			v.addNode(RuleODPOverCapacity, SeverityError, n, "ODP %s melayani %d pelanggan, kapasitas %d port", n.Name, drops, total)
+		case links-1 > total:
+			// Satu kabel dianggap feeder dari hulu, sisanya memakai port splitter
+			v.addNode(RuleODPOverCapacity, SeverityWarning, n, "ODP %s memiliki %d kabel keluar, kapasitas %d port", n.Name, links-1, total)
+		}
+	}
+}
+
+// checkClients: SubscriberID harus menunjuk pelanggan CRM yang ada dan belum dihapus
+func (v *topologyValidator) checkClients() error {
+	clientIDs := make([]int, 0)
+	nodeOf := make(map[int][]models.NetworkNode)
+	for _, n := range v.nodes {
+		if n.Type != models.TypeClient || n.ClientDetail == nil {
+			continue
+		}
+		id, err := strconv.Atoi(n.ClientDetail.SubscriberID)
+		if err != nil {
+			v.addNode(RuleClientNotFound, SeverityError, n, "Node CLIENT %s memiliki subscriber_id tidak valid: %q", n.Name, n.ClientDetail.SubscriberID)
+			continue
+		}
+		clientIDs = append(clientIDs, id)
+		nodeOf[id] = append(nodeOf[id], n)
+	}
+	if len(clientIDs) == 0 {
+		return nil
+	}
+
+	var clients []models.Client
+	if err := config.DB.Unscoped().Select("client_id", "name", "deleted_at").Where("client_id IN ?", clientIDs).Find(&clients).Error; err != nil {
+		return err
+	}
+	found := make(map[int]models.Client, len(clients))
+	for _, cl := range clients {
+		found[cl.ClientID] = cl
+	}
+	for id, nodes := range nodeOf {
+		cl, ok := found[id]
+		for _, n := range nodes {
+			switch {
+			case !ok:
+				v.addNode(RuleClientNotFound, SeverityError, n, "Node CLIENT %s menunjuk pelanggan ID %d yang tidak ada", n.Name, id)
+			case cl.DeletedAt.Valid:
+				v.addNode(RuleClientDeleted, SeverityError, n, "Node CLIENT %s menunjuk pelanggan %s (ID %d) yang sudah dihapus", n.Name, cl.Name, id)
+			}
+		}
+	}
+	return nil
+}
+
+// checkConnectivity: node tanpa kabel, dan node yang terhubung tetapi tidak sampai ke router / OLT
+func (v *topologyValidator) checkConnectivity() {
+	reached := v.graph.reachable(v.graph.impactRoots(), -1, -1)
+	for id, n := range v.nodes {
+		if len(v.graph.Adj[id]) == 0 {
+			if n.Type != models.TypeRouter {
+				v.addNode(RuleOrphanNode, SeverityWarning, n, "Node %s %s tidak memiliki kabel", n.Type, n.Name)
+			}
+			continue
+		}
+		if !reached[id] {
+			v.addNode(RuleNoUpstream, SeverityWarning, n, "Node %s %s tidak tersambung ke OLT / router mana pun", n.Type, n.Name)
+		}
+	}
+}
+
+// ValidateTopology menjalankan semua aturan validasi terhadap node & kabel di database
+func ValidateTopology() (*TopologyValidationReport, error) {
+	var nodes []models.NetworkNode
+	if err := config.DB.Preload("ODPDetail").Preload("OLTDetail").Preload("ODCDetail").Preload("ClientDetail").Find(&nodes).Error; err != nil {
+		return nil, err
+	}
+	var cables []models.NetworkCable
+	if err := config.DB.Order("cable_id").Find(&cables).Error; err != nil {
+		return nil, err
+	}
+	portCounts, err := ODPUsedPortCounts()
+	if err != nil {
+		return nil, err
+	}
+
+	v := &topologyValidator{
+		nodes:  make(map[int]models.NetworkNode, len(nodes)),
+		cables: cables,
+		graph:  NewTopologyGraph(nodes, cables),
+	}
+	for _, n := range nodes {
+		v.nodes[n.NodeID] = n
+	}
+
+	v.checkNodes()
+	v.checkCables()
+	v.checkCycles()
+	v.checkODPCapacity(portCounts)
+	if err := v.checkClients(); err != nil {
+		return nil, err
+	}
+	v.checkConnectivity()
+
+	sort.SliceStable(v.violations, func(i, j int) bool {
+		a, b := v.violations[i], v.violations[j]
+		if a.Severity != b.Severity {
+			return a.Severity == SeverityError
+		}
+		if a.Rule != b.Rule {
+			return a.Rule < b.Rule
+		}
+		if a.NodeID != b.NodeID {
+			return a.NodeID < b.NodeID
+		}
+		return a.CableID < b.CableID
+	})
+
+	report := &TopologyValidationReport{
+		GeneratedAt: time.Now(),
+		TotalNodes:  len(nodes),
+		TotalCables: len(cables),
+		ByRule:      make(map[string]int),
+		LinkRules:   make([]TopologyLinkRule, 0, len(topologyLinkRules)),
+		Violations:  v.violations,
+	}
+	if report.Violations == nil {
+		report.Violations = []TopologyViolation{}
+	}
+	for _, vi := range report.Violations {
+		report.ByRule[vi.Rule]++
+		if vi.Severity == SeverityError {
+			report.Errors++
+		} else {
+			report.Warnings++
+		}
+	}
+	for pair, sev := range topologyLinkRules {
+		report.LinkRules = append(report.LinkRules, TopologyLinkRule{A: pair[0], B: pair[1], Severity: sev})
+	}
+	sort.Slice(report.LinkRules, func(i, j int) bool {
+		if report.LinkRules[i].A != report.LinkRules[j].A {
+			return report.LinkRules[i].A < report.LinkRules[j].A
+		}
+		return report.LinkRules[i].B < report.LinkRules[j].B
+	})
+	return report, nil
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"akane/be-ftth/models"
+	"reflect"
+	"testing"
+)
+
+func TestLinkSeverity(t *testing.T) {
+	tests := []struct {
+		a, b models.NodeType
+		want string
+	}{
+		{models.TypeOLT, models.TypeODC, ""},
+		{models.TypeODC, models.TypeOLT, ""}, // Urutan tipe tidak berpengaruh
+		{models.TypeODP, models.TypeClient, ""},
+		{models.TypeClient, models.TypeTB, ""},
+		{models.TypeRouter, models.TypeRouter, ""},
+		{models.TypeClient, models.TypeClient, SeverityError},
+		{models.TypeOLT, models.TypeClient, SeverityError},
+		{models.TypeClient, models.TypeODC, SeverityError},
+		{models.TypeRouter, models.TypeClient, SeverityError},
+		{models.TypeRouter, models.TypeODP, SeverityWarning}, // Tidak terdaftar = tidak lazim
+		{models.TypeOLT, models.TypeOLT, SeverityWarning},
+		{models.NodeType("SPLITTER"), models.TypeODP, SeverityWarning},
+	}
+	for _, tt := range tests {
+		if got := linkSeverity(tt.a, tt.b); got != tt.want {
+			t.Fatalf("linkSeverity(%s, %s) = %q, want %q", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestTreePath(t *testing.T) {
+	// 1 - 2 - 3 - 4, cabang 2 - 5 - 6, pulau terpisah 7 - 8
+	tree := map[int][]int{}
+	for _, e := range [][2]int{{1, 2}, {2, 3}, {3, 4}, {2, 5}, {5, 6}, {7, 8}} {
+		tree[e[0]] = append(tree[e[0]], e[1])
+		tree[e[1]] = append(tree[e[1]], e[0])
+	}
+
+	tests := []struct {
+		name string
+		a, b int
+		want []int // Jalur dari b kembali ke a
+	}{
+		{"neighbours", 1, 2, []int{2, 1}},
+		{"along branch", 4, 6, []int{6, 5, 2, 3, 4}},
+		{"reverse direction", 6, 4, []int{4, 3, 2, 5, 6}},
+		{"same node", 3, 3, []int{3}},
+		{"different islands", 1, 8, []int{1, 8}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := treePath(tree, tt.a, tt.b); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("treePath(%d, %d) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
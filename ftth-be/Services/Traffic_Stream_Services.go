@@ -0,0 +1,80 @@
+package services
+
+import (
+	"akane/be-ftth/models"
+	"akane/be-ftth/utils"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Batas maksimal satu sesi live traffic agar sesi yang terlupa tidak membebani router.
+// Frontend cukup membuka ulang stream jika masih dibutuhkan.
+const maxTrafficStreamDuration = 15 * time.Minute
+
+// ErrStreamClosed dikembalikan callback onSample saat browser sudah menutup koneksi
+var ErrStreamClosed = errors.New("stream ditutup oleh client")
+
+// TrafficSample adalah satu sampel rx/tx dari /interface/monitor-traffic
+type TrafficSample struct {
+	InterfaceName string    `json:"interface_name"`
+	RxBps         float64   `json:"rx_bps"`
+	TxBps         float64   `json:"tx_bps"`
+	RxPps         float64   `json:"rx_pps"`
+	TxPps         float64   `json:"tx_pps"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// StreamInterfaceTraffic membuka /interface/monitor-traffic (tanpa once) di router dan
+// memanggil onSample untuk setiap sampel (RouterOS mengirim 1 sampel per detik).
+// Sesi router selalu di-cancel dan ditutup saat onSample mengembalikan error,
+// router memutus koneksi, atau durasi maksimal tercapai.
+func StreamInterfaceTraffic(router models.Router, interfaceName string, onSample func(TrafficSample) error) error {
+	pass, err := utils.DecryptAES(router.RouterPassword)
+	if err != nil {
+		return fmt.Errorf("gagal mendekripsi password router")
+	}
+
+	client, err := connectMikrotik(router.RouterAddress, router.RouterPort, router.RouterUsername, pass, router.RouterRemoteType)
+	if err != nil {
+		return fmt.Errorf("gagal koneksi ke router: %v", err)
+	}
+	defer client.Close()
+
+	listen, err := client.ListenArgs([]string{"/interface/monitor-traffic", "=interface=" + interfaceName})
+	if err != nil {
+		return fmt.Errorf("gagal memulai monitor-traffic: %v", err)
+	}
+	defer listen.Cancel()
+
+	deadline := time.NewTimer(maxTrafficStreamDuration)
+	defer deadline.Stop()
+
+	for {
+		select {
+		case sen, ok := <-listen.Chan():
+			if !ok {
+				if err := listen.Err(); err != nil {
+					return fmt.Errorf("monitor-traffic terhenti: %v", err)
+				}
+				return nil
+			}
+
+			sample := TrafficSample{InterfaceName: interfaceName, Timestamp: time.Now()}
+			sample.RxBps, _ = strconv.ParseFloat(sen.Map["rx-bits-per-second"], 64)
+			sample.TxBps, _ = strconv.ParseFloat(sen.Map["tx-bits-per-second"], 64)
+			sample.RxPps, _ = strconv.ParseFloat(sen.Map["rx-packets-per-second"], 64)
+			sample.TxPps, _ = strconv.ParseFloat(sen.Map["tx-packets-per-second"], 64)
+
+			if err := onSample(sample); err != nil {
+				if errors.Is(err, ErrStreamClosed) {
+					return nil
+				}
+				return err
+			}
+		case <-deadline.C:
+			return nil
+		}
+	}
+}
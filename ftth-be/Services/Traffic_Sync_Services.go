@@ -4,13 +4,16 @@ import (
 	"akane/be-ftth/config"
 	"akane/be-ftth/models"
 	"akane/be-ftth/utils"
+	"context"
 	"crypto/tls"
 	"fmt"
+	"html"
 	"net/http"
 	"net/url"
 	"os"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-routeros/routeros"
@@ -19,40 +22,39 @@ import (
 // ==========================================
 // JOB 1: TRAFFIC SYNC (Jalan Tiap Jam/Menit)
 // ==========================================
-func RunTrafficSyncJob() error {
+func RunTrafficSyncJob() (int, error) {
 	fmt.Println("[JOB-TRAFFIC] Memulai sinkronisasi traffic...", time.Now())
 
 	var interfaces []models.InterfaceMonitoring
 	if err := config.DB.Preload("Router").Where("is_deleted = ?", 0).Find(&interfaces).Error; err != nil {
-		return err
+		return 0, err
 	}
 
-	// Grouping per Router
-	interfaceMap := make(map[string][]models.InterfaceMonitoring)
-	for _, iface := range interfaces {
-		interfaceMap[iface.RouterID.String()] = append(interfaceMap[iface.RouterID.String()], iface)
-	}
+	var saved int64
 
-	for _, ifaceList := range interfaceMap {
-		if len(ifaceList) == 0 {
-			continue
-		}
+	// Setiap router diproses paralel (dibatasi worker pool) dengan deadline masing-masing
+	errs := RunBounded(groupInterfacesByRouter(interfaces), RouterPoolOptions(), func(ctx context.Context, ifaceList []models.InterfaceMonitoring) error {
 		router := ifaceList[0].Router
 		if router.IsDeleted == 1 {
-			continue
+			return nil
 		}
 
 		pass, err := utils.DecryptAES(router.RouterPassword)
 		if err != nil {
-			continue
+			return fmt.Errorf("%s: gagal decrypt password", router.RouterName)
 		}
 
-		client, err := connectMikrotik(router.RouterAddress, router.RouterPort, router.RouterUsername, pass, router.RouterRemoteType)
+		client, err := DialMikrotik(ctx, router.RouterAddress, router.RouterPort, router.RouterUsername, pass, router.RouterRemoteType)
 		if err != nil {
-			continue
+			return fmt.Errorf("%s: %v", router.RouterName, err)
 		}
+		defer client.Close()
 
 		for _, targetIface := range ifaceList {
+			if ctx.Err() != nil {
+				return fmt.Errorf("%s: melewati batas waktu", router.RouterName)
+			}
+
 			// [FIX] Traffic selalu direkam meskipun IsExcluded = 1 (hanya Ping yang diskip)
 			rx, tx, err := getInterfaceTraffic(client, targetIface.InterfaceName)
 			if err != nil {
@@ -67,63 +69,66 @@ func RunTrafficSyncJob() error {
 			}
 
 			// Save DB
-			config.DB.Create(&models.InterfaceTraffic{
+			if err := config.DB.Create(&models.InterfaceTraffic{
 				InterfaceID:   targetIface.InterfaceID,
 				DownloadSpeed: rx,
 				UploadSpeed:   tx,
 				Timestamp:     time.Now(),
-			})
+			}).Error; err == nil {
+				atomic.AddInt64(&saved, 1)
+			}
+		}
+		return nil
+	})
+
+	for _, err := range errs {
+		if err != nil {
+			fmt.Println("[JOB-TRAFFIC] Router dilewati:", err)
 		}
-		client.Close()
 	}
+
 	fmt.Println("[JOB-TRAFFIC] Selesai.")
-	return nil
+	return int(saved), nil
 }
 
 // ==========================================
 // JOB 2: PING CHECK (Jalan Tiap 30 Menit)
 // ==========================================
-func RunPingCheckJob() error {
+func RunPingCheckJob() (int, error) {
 	fmt.Println("------------------------------------------------")
 	fmt.Println("[JOB-PING] Memulai Ping Check...", time.Now().Format("15:04:05"))
 
 	var interfaces []models.InterfaceMonitoring
 	if err := config.DB.Preload("Router").Where("is_deleted = ?", 0).Find(&interfaces).Error; err != nil {
 		fmt.Println("[JOB-PING] Error DB:", err)
-		return err
+		return 0, err
 	}
 
 	if len(interfaces) == 0 {
-		return nil
+		return 0, nil
 	}
 
-	// Grouping per Router
-	interfaceMap := make(map[string][]models.InterfaceMonitoring)
-	for _, iface := range interfaces {
-		interfaceMap[iface.RouterID.String()] = append(interfaceMap[iface.RouterID.String()], iface)
-	}
+	var pinged int64
 
-	// Loop per Router
-	for _, ifaceList := range interfaceMap {
-		if len(ifaceList) == 0 {
-			continue
-		}
+	// Setiap router diproses paralel (dibatasi worker pool) dengan deadline masing-masing
+	RunBounded(groupInterfacesByRouter(interfaces), RouterPoolOptions(), func(ctx context.Context, ifaceList []models.InterfaceMonitoring) error {
 		router := ifaceList[0].Router
 
-		fmt.Printf("[JOB-PING] Checking Router: %s... ", router.RouterName)
-
 		pass, err := utils.DecryptAES(router.RouterPassword)
 		if err != nil {
-			fmt.Println("Gagal decrypt password.")
-			continue
+			fmt.Printf("[JOB-PING] %s: Gagal decrypt password.\n", router.RouterName)
+			return err
 		}
 
-		client, err := connectMikrotik(router.RouterAddress, router.RouterPort, router.RouterUsername, pass, router.RouterRemoteType)
+		client, err := DialMikrotik(ctx, router.RouterAddress, router.RouterPort, router.RouterUsername, pass, router.RouterRemoteType)
 		if err != nil {
-			fmt.Printf("Gagal konek Mikrotik: %v\n", err)
+			fmt.Printf("[JOB-PING] %s: Gagal konek Mikrotik: %v\n", router.RouterName, err)
+			recordRouterReachable(router.RouterID.String(), router.RouterName, false)
 			go sendRouterDownAlert(router.RouterName, err)
-			continue
+			return err
 		}
+		defer client.Close()
+		recordRouterReachable(router.RouterID.String(), router.RouterName, true)
 
 		var reportLines []string
 		hasError := false // Flag penentu kirim telegram
@@ -134,7 +139,21 @@ func RunPingCheckJob() error {
 				// reportLines = append(reportLines, fmt.Sprintf("⏸️ %s: Excluded", targetIface.InterfaceName))
 				continue
 			}
+			if ctx.Err() != nil {
+				reportLines = append(reportLines, fmt.Sprintf("⏱️ %s: Dilewati (timeout router)", targetIface.InterfaceName))
+				hasError = true
+				continue
+			}
+
 			packetLoss, avgRtt, err := pingInterface(client, targetIface.InterfaceName, "1.1.1.1")
+			atomic.AddInt64(&pinged, 1)
+			recordPingResult(targetIface.InterfaceID, PingStat{
+				RouterName:    router.RouterName,
+				InterfaceName: targetIface.InterfaceName,
+				PacketLoss:    packetLoss,
+				AvgRtt:        avgRtt,
+				Failed:        err != nil,
+			})
 
 			statusIcon := "✅"
 			statusText := fmt.Sprintf("%dms", avgRtt)
@@ -163,21 +182,37 @@ func RunPingCheckJob() error {
 			reportLines = append(reportLines, line)
 		}
 
-		client.Close()
-
 		// LOGIKA PENGIRIMAN:
 		// Hanya kirim ke Telegram jika hasError == true
 		if hasError {
-			fmt.Println(" [ALERT SENT] 🚨 Ditemukan masalah ping.")
+			fmt.Printf("[JOB-PING] %s: [ALERT SENT] 🚨 Ditemukan masalah ping.\n", router.RouterName)
 			go sendPingReport(router.RouterName, reportLines, hasError)
 		} else {
-			fmt.Println(" [OK] Semua aman. Tidak kirim notif.")
+			fmt.Printf("[JOB-PING] %s: [OK] Semua aman. Tidak kirim notif.\n", router.RouterName)
 		}
-	}
+		return nil
+	})
 
 	fmt.Println("[JOB-PING] Selesai.")
 	fmt.Println("------------------------------------------------")
-	return nil
+	return int(pinged), nil
+}
+
+// groupInterfacesByRouter mengelompokkan interface per router (setiap grup minimal 1 interface)
+func groupInterfacesByRouter(interfaces []models.InterfaceMonitoring) [][]models.InterfaceMonitoring {
+	index := make(map[string]int)
+	var groups [][]models.InterfaceMonitoring
+	for _, iface := range interfaces {
+		key := iface.RouterID.String()
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], iface)
+	}
+	return groups
 }
 
 // ---------------------------------------------------------
@@ -257,6 +292,16 @@ func pingInterface(client *routeros.Client, interfaceName, targetIP string) (int
 // ---------------------------------------------------------
 
 func sendTelegramMessage(text string) {
+	sendTelegram(text, "Markdown")
+}
+
+// sendTelegramHTML mengirim pesan dengan parse_mode HTML. Nilai dari user/DB (nama
+// pelanggan, FAT, judul insiden) wajib di-escape dengan html.EscapeString.
+func sendTelegramHTML(text string) {
+	sendTelegram(text, "HTML")
+}
+
+func sendTelegram(text, parseMode string) {
 	botToken := os.Getenv("TELEGRAM_BOT_TOKEN")
 	chatID := os.Getenv("TELEGRAM_CHAT_ID")
 	if botToken == "" || chatID == "" {
@@ -267,7 +312,7 @@ func sendTelegramMessage(text string) {
 	data := url.Values{}
 	data.Set("chat_id", chatID)
 	data.Set("text", text)
-	data.Set("parse_mode", "Markdown")
+	data.Set("parse_mode", parseMode)
 
 	http.PostForm(apiURL, data)
 }
@@ -321,13 +366,13 @@ func sendRouterDownAlert(routerName string, errReason error) {
 		"%s\n\n"+
 			"📡 <b>Router:</b> %s\n"+
 			"❌ <b>Status:</b> CONNECTION LOST / DROP\n"+
-			"📝 <b>Error:</b> %v\n\n"+
+			"📝 <b>Error:</b> %s\n\n"+
 			"🕒 %s",
-		header,                            // Masuk ke %s pertama
-		routerName,                        // Masuk ke %s kedua
-		errReason,                         // Masuk ke %v (menampilkan pesan error)
-		time.Now().Format("02 Jan 15:04"), // Masuk ke %s terakhir
+		header,                                   // Masuk ke %s pertama
+		html.EscapeString(routerName),            // Masuk ke %s kedua
+		html.EscapeString(fmt.Sprint(errReason)), // Masuk ke %s ketiga (pesan error)
+		time.Now().Format("02 Jan 15:04"),        // Masuk ke %s terakhir
 	)
 
-	sendTelegramMessage(msg)
+	sendTelegramHTML(msg)
 }
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/go-routeros/routeros"
+)
+
+// Default worker pool untuk job per-router (bisa di-override lewat .env)
+const (
+	defaultRouterWorkers   = 5
+	defaultRouterTimeout   = 60 * time.Second
+	defaultRouterDialLimit = 10 * time.Second
+)
+
+// PoolOptions mengatur jumlah worker dan batas waktu setiap item
+type PoolOptions struct {
+	Workers int           // Jumlah item yang diproses bersamaan
+	Timeout time.Duration // Deadline per item (0 = tanpa deadline)
+}
+
+// RouterPoolOptions membaca ROUTER_JOB_WORKERS dan ROUTER_JOB_TIMEOUT_SECONDS dari environment
+func RouterPoolOptions() PoolOptions {
+	return PoolOptions{
+		Workers: envInt("ROUTER_JOB_WORKERS", defaultRouterWorkers),
+		Timeout: time.Duration(envInt("ROUTER_JOB_TIMEOUT_SECONDS", int(defaultRouterTimeout/time.Second))) * time.Second,
+	}
+}
+
+// RunBounded memproses items secara paralel dengan maksimal opts.Workers goroutine.
+// Setiap item mendapat context dengan deadline sendiri sehingga satu router yang hang
+// tidak menahan item lain. Hasil error dikembalikan sesuai urutan items.
+func RunBounded[T any](items []T, opts PoolOptions, fn func(ctx context.Context, item T) error) []error {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+	if workers > len(items) {
+		workers = len(items)
+	}
+
+	errs := make([]error, len(items))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				errs[idx] = runPoolItem(items[idx], opts.Timeout, fn)
+			}
+		}()
+	}
+
+	for idx := range items {
+		jobs <- idx
+	}
+	close(jobs)
+	wg.Wait()
+
+	return errs
+}
+
+func runPoolItem[T any](item T, timeout time.Duration, fn func(ctx context.Context, item T) error) (err error) {
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	// Panic di satu item tidak boleh mematikan worker lain
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn(ctx, item)
+}
+
+// DialMikrotik membuka koneksi API Mikrotik dengan timeout dial dan mengikat deadline ctx
+// ke socket, sehingga perintah yang menggantung akan gagal saat deadline tercapai.
+func DialMikrotik(ctx context.Context, ip string, port int, user, pass, remoteType string) (*routeros.Client, error) {
+	addr := fmt.Sprintf("%s:%d", ip, port)
+	dialer := &net.Dialer{Timeout: defaultRouterDialLimit}
+
+	var conn net.Conn
+	var err error
+	if remoteType == "API-SSL" {
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: &tls.Config{InsecureSkipVerify: true}}
+		conn, err = tlsDialer.DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := routeros.NewClient(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := client.Login(user, pass); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return client, nil
+}
@@ -2,49 +2,63 @@ package services
 
 import (
 	"akane/be-ftth/config"
+	"akane/be-ftth/genieacs"
 	"akane/be-ftth/models"
-	"akane/be-ftth/utils"
+	"context"
+	"errors"
 	"fmt"
 	"log"
-	"os"
 )
 
-func RunGenieACSSyncJob() {
-	urlAcs := os.Getenv("GENIE_ACS_URL")
-	if urlAcs == "" {
+func RunGenieACSSyncJob() (int, error) {
+	acs, err := genieacs.NewFromEnv()
+	if errors.Is(err, genieacs.ErrNotConfigured) {
 		log.Println("[CRON] GenieACS URL not configured, skipping sync.")
-		return
+		return 0, nil
+	} else if err != nil {
+		return 0, err
 	}
 
-	devices, err := utils.GetAllDevices(urlAcs)
+	devices, err := acs.ListDevices(context.Background())
 	if err != nil {
 		log.Printf("[CRON] Failed to fetch GenieACS devices: %v", err)
-		return
+		return 0, err
 	}
 
 	var clients []models.Client
 	if err := config.DB.Find(&clients).Error; err != nil {
 		log.Printf("[CRON] Failed to get clients from DB: %v", err)
-		return
+		return 0, err
 	}
 
-	for _, client := range clients {
-		for _, dev := range devices {
-			// Cocokkan berdasarkan IP, SN, atau PPPoE
-			if (dev.IPAddress != "" && dev.IPAddress != "0.0.0.0" && dev.IPAddress == client.IPAddress) || 
-			   (dev.DeviceSN != "" && dev.DeviceSN == client.OnuSN) ||
-			   (client.PppoeUsername != "" && (dev.IPAddress == client.PppoeUsername)) {
-				
-				if client.RxPower != dev.RXPower {
-					client.RxPower = dev.RXPower
-					config.DB.Save(&client)
-					log.Printf("[CRON] Updated RxPower for client %s to %s", client.Name, dev.RXPower)
-				}
-				break
-			}
-		}
+	// Ikat pelanggan baru ke device berdasarkan SN/PPPoE yang pasti, sisanya ditinjau manual
+	if bound, err := AutoBindClients(clients, devices); err != nil {
+		log.Printf("[CRON] Failed to bind GenieACS devices: %v", err)
+	} else if bound > 0 {
+		log.Printf("[CRON] Bound %d clients to GenieACS devices", bound)
+	}
+
+	deviceByID := make(map[string]genieacs.DeviceInfo, len(devices))
+	for _, dev := range devices {
+		deviceByID[dev.DeviceID] = dev
 	}
 
+	// Update per client diproses paralel memakai worker pool yang sama dengan job router
+	RunBounded(clients, PoolOptions{Workers: envInt("GENIEACS_SYNC_WORKERS", defaultRouterWorkers)}, func(ctx context.Context, client models.Client) error {
+		dev, ok := deviceByID[client.GenieDeviceID]
+		if client.GenieDeviceID == "" || !ok || client.RxPower == dev.RXPower {
+			return nil
+		}
+		if err := config.DB.Model(&client).Update("rx_power", dev.RXPower).Error; err != nil {
+			return err
+		}
+		log.Printf("[CRON] Updated RxPower for client %s to %s", client.Name, dev.RXPower)
+		return nil
+	})
+
+	// Status online/offline dari _lastInform dan deteksi gangguan massal per ODP/ODC/OLT
+	syncOnuStatuses(clients, deviceByID)
+
 	// Simpan riwayat history untuk setiap perangkat yang memiliki SNR valid
 	for _, dev := range devices {
 		if dev.DeviceSN != "" && dev.RXPower != "" {
@@ -54,6 +68,7 @@ func RunGenieACSSyncJob() {
 			// Jika sinyal bukan 0 atau kosong, simpan
 			if parsedRxPower != 0 {
 				history := models.RxPowerHistory{
+					DeviceID: dev.DeviceID,
 					DeviceSN: dev.DeviceSN,
 					RxPower:  parsedRxPower,
 				}
@@ -63,4 +78,5 @@ func RunGenieACSSyncJob() {
 	}
 
 	log.Println("[CRON] GenieACS Sync Job Completed.")
+	return len(devices), nil
 }
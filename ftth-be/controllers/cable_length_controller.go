@@ -0,0 +1,31 @@
+package controllers
+
+import (
+	services "akane/be-ftth/Services"
+	"akane/be-ftth/utils"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RecomputeCableLengths menghitung ulang panjang semua kabel dari koordinat jalurnya.
+// ?slack_percent= menimpa CABLE_SLACK_PERCENT, ?dry_run=true hanya menampilkan perubahan.
+func RecomputeCableLengths(c *fiber.Ctx) error {
+	slack := c.QueryFloat("slack_percent", services.CableSlackPercent())
+	if slack < 0 || slack > 100 {
+		return utils.Failed(c, "slack_percent harus antara 0 dan 100")
+	}
+	dryRun := c.QueryBool("dry_run")
+
+	result, err := services.RecomputeCableLengths(slack, dryRun)
+	if err != nil {
+		return utils.Error(c, "Gagal menghitung ulang panjang kabel: "+err.Error())
+	}
+	if dryRun {
+		return utils.Success(c, "Preview hitung ulang panjang kabel", result)
+	}
+
+	pelaku := utils.GetUserFromContext(c)
+	utils.CreateLog(pelaku, "TOPOLOGY", "UPDATE", fmt.Sprintf("Hitung ulang panjang kabel (slack %.1f%%): %d dari %d kabel berubah", slack, result.Changed, result.Total))
+	return utils.Success(c, "Panjang kabel berhasil dihitung ulang", result)
+}
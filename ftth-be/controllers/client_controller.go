@@ -272,8 +272,22 @@ func CreateClient(c *fiber.Ctx) error {
 		}
 	}
 
+	// Provisioning ONU via GenieACS (PPPoE WAN + WiFi default), bisa dimatikan dengan provision_onu=false
+	var provision *services.OnuProvisionResult
+	if onuSN != "" && c.FormValue("provision_onu", "true") == "true" {
+		provision = startClientOnuProvision(client, services.OnuProvisionOptions{PPPoE: true, WiFi: true}, adminPelaku, &msg)
+	}
+
+	// Pasang ke port ODP sesuai FAT (odp_port kosong = port kosong pertama)
+	odpPort, _ := strconv.Atoi(c.FormValue("odp_port", "0"))
+	if port, err := services.AssignClientPortByFat(client, odpPort, adminPelaku); err != nil {
+		msg += " (Peringatan: Gagal memasang port ODP - " + err.Error() + ")"
+	} else if port != nil {
+		msg += fmt.Sprintf(" (Port ODP %d)", port.PortNumber)
+	}
+
 	utils.CreateLog(adminPelaku, "CLIENT", "INFO", fmt.Sprintf("Membuat pelanggan baru: %s di area FAT: %s", name, fat))
-	return utils.Success(c, msg, client)
+	return utils.Success(c, msg, clientSaveResponse{Client: client, OnuProvision: provision})
 }
 
 // UpdateClient memperbarui data pelanggan beserta foto rumah
@@ -392,6 +406,10 @@ func UpdateClient(c *fiber.Ctx) error {
 		}
 	}
 
+	// Simpan nilai lama untuk menentukan perlu tidaknya provisioning ulang ONU
+	prevOnuSN, prevPppoeUser, prevPppoePass := client.OnuSN, client.PppoeUsername, client.PppoePassword
+	prevFat := client.Fat
+
 	client.Name = name
 	client.Phone = phone
 	client.Address = address
@@ -413,6 +431,13 @@ func UpdateClient(c *fiber.Ctx) error {
 		client.PppoeProfile = pppoeProfile
 	}
 
+	// ONU diganti: binding GenieACS lama tidak berlaku lagi, akan diikat ulang saat provisioning/sinkronisasi
+	if client.OnuSN != prevOnuSN {
+		client.GenieDeviceID = ""
+		client.GenieBindVia = ""
+		client.GenieBoundAt = nil
+	}
+
 	err = config.DB.Transaction(func(tx *gorm.DB) error {
 		if err := tx.Save(&client).Error; err != nil {
 			return err
@@ -524,8 +549,71 @@ func UpdateClient(c *fiber.Ctx) error {
 		}
 	}
 
+	// PPPoE dikirim ulang jika ONU atau kredensial berubah. WiFi default hanya untuk ONU baru
+	// (atau provision_wifi=true) agar SSID yang sudah diganti pelanggan tidak tertimpa.
+	var provision *services.OnuProvisionResult
+	if client.OnuSN != "" && c.FormValue("provision_onu", "true") == "true" {
+		onuChanged := client.OnuSN != prevOnuSN
+		opts := services.OnuProvisionOptions{
+			PPPoE: onuChanged || client.PppoeUsername != prevPppoeUser || client.PppoePassword != prevPppoePass,
+			WiFi:  onuChanged || c.FormValue("provision_wifi") == "true",
+		}
+		if opts.PPPoE || opts.WiFi {
+			provision = startClientOnuProvision(client, opts, adminPelaku, &msg)
+		}
+	}
+
+	// Pindah FAT: port di ODP lama dibebaskan dan pelanggan dipasang ke ODP baru
+	if client.Fat != prevFat && !client.DeletedAt.Valid {
+		odpPort, _ := strconv.Atoi(c.FormValue("odp_port", "0"))
+		if port, err := services.AssignClientPortByFat(client, odpPort, adminPelaku); err != nil {
+			msg += " (Peringatan: Gagal memindahkan port ODP - " + err.Error() + ")"
+		} else if port != nil {
+			msg += fmt.Sprintf(" (Port ODP %d)", port.PortNumber)
+		}
+	}
+
 	utils.CreateLog(adminPelaku, "CLIENT", "INFO", fmt.Sprintf("Memperbarui data pelanggan ID %d: %s di area FAT: %s", id, name, fat))
-	return utils.Success(c, msg, client)
+	return utils.Success(c, msg, clientSaveResponse{Client: client, OnuProvision: provision})
+}
+
+// clientSaveResponse adalah data pelanggan beserta aksi provisioning ONU (jika ada) yang bisa dipolling
+// di /clients/:id/onu/actions/:action_id
+type clientSaveResponse struct {
+	models.Client
+	OnuProvision *services.OnuProvisionResult `json:"onu_provision,omitempty"`
+}
+
+// startClientOnuProvision mencatat aksi provisioning (pending) lalu menjalankannya di background,
+// hasilnya ditambahkan ke pesan respon di samping hasil sinkron Mikrotik
+func startClientOnuProvision(client models.Client, opts services.OnuProvisionOptions, pelaku string, msg *string) *services.OnuProvisionResult {
+	result := services.StartClientOnuProvision(client, opts, pelaku)
+	switch result.Status {
+	case "skipped":
+		return nil
+	case "failed":
+		*msg += " (Peringatan: Gagal provisioning ONU - " + result.Message + ")"
+	default:
+		*msg += fmt.Sprintf(" (Provisioning ONU %s, action_id %d)", result.Status, result.ActionID)
+	}
+	return &result
+}
+
+// GetClientWifiCredentials menampilkan SSID & password WiFi yang dikirim saat provisioning ONU (khusus admin)
+func GetClientWifiCredentials(c *fiber.Ctx) error {
+	adminPelaku := utils.GetUserFromContext(c)
+
+	var client models.Client
+	if err := config.DB.First(&client, c.Params("id")).Error; err != nil {
+		return utils.Failed(c, "Pelanggan tidak ditemukan.")
+	}
+	creds, err := services.GetClientWifiCredentials(client)
+	if err != nil {
+		return utils.Failed(c, err.Error())
+	}
+
+	utils.CreateLog(adminPelaku, "CLIENT", "INFO", fmt.Sprintf("Melihat password WiFi pelanggan ID %d", client.ClientID))
+	return utils.Success(c, "Berhasil mengambil kredensial WiFi pelanggan", creds)
 }
 
 // DeleteClient menonaktifkan/memutus pelanggan (Soft Delete) agar bisa direstore kembali
@@ -567,6 +655,11 @@ func DeleteClient(c *fiber.Ctx) error {
 		}
 	}
 
+	// Port ODP dibebaskan agar bisa dipakai pelanggan lain
+	if err := services.ReleaseClientPorts(client.ClientID, adminPelaku); err != nil {
+		log.Println("Peringatan: Gagal membebaskan port ODP:", err)
+	}
+
 	utils.CreateLog(adminPelaku, "CLIENT", "INFO", fmt.Sprintf("Menonaktifkan pelanggan (soft delete): %s", client.Name))
 	return utils.Success(c, "Pelanggan berhasil dinonaktifkan (soft delete).", nil)
 }
@@ -614,8 +707,16 @@ func RestoreClient(c *fiber.Ctx) error {
 		}
 	}
 
+	msg := "Pelanggan berhasil diaktifkan kembali."
+	// Port dibebaskan saat pelanggan dinonaktifkan, pasang lagi ke port kosong di ODP sesuai FAT
+	if port, err := services.AssignClientPortByFat(client, 0, adminPelaku); err != nil {
+		msg += " (Peringatan: Gagal memasang port ODP - " + err.Error() + ")"
+	} else if port != nil {
+		msg += fmt.Sprintf(" (Port ODP %d)", port.PortNumber)
+	}
+
 	utils.CreateLog(adminPelaku, "CLIENT", "INFO", fmt.Sprintf("Mengaktifkan kembali pelanggan (restore): %s", client.Name))
-	return utils.Success(c, "Pelanggan berhasil diaktifkan kembali.", client)
+	return utils.Success(c, msg, client)
 }
 
 // SyncClientToMikrotik mensinkronisasikan satu pelanggan ke Router Mikrotik
@@ -1138,6 +1239,8 @@ func ImportClients(c *fiber.Ctx) error {
 	}
 
 	var importedCount int
+	// Pelanggan baru / yang FAT-nya berubah, dipasang ke port ODP setelah transaksi selesai
+	var portClients []models.Client
 
 	err = config.DB.Transaction(func(tx *gorm.DB) error {
 		for _, pr := range parsedRows {
@@ -1195,8 +1298,9 @@ func ImportClients(c *fiber.Ctx) error {
 				if pr.Address != "" {
 					existingClient.Address = pr.Address
 				}
-				if pr.FatVal != "" {
+				if pr.FatVal != "" && pr.FatVal != existingClient.Fat {
 					existingClient.Fat = pr.FatVal
+					portClients = append(portClients, existingClient)
 				}
 				if routerID != "" {
 					existingClient.RouterID = routerID
@@ -1255,6 +1359,9 @@ func ImportClients(c *fiber.Ctx) error {
 					return err
 				}
 				currentClientID = client.ClientID
+				if client.Fat != "" {
+					portClients = append(portClients, client)
+				}
 			}
 
 			// SINKRONKAN CRM -> MAP SECARA REAL-TIME PADA SAAT IMPORT EXCEL
@@ -1379,8 +1486,20 @@ func ImportClients(c *fiber.Ctx) error {
 		return utils.Error(c, "Gagal mengimpor pelanggan: "+err.Error())
 	}
 
+	msg := fmt.Sprintf("Berhasil mengimpor %d pelanggan.", importedCount)
+	portFailed := 0
+	for _, cl := range portClients {
+		if _, err := services.AssignClientPortByFat(cl, 0, adminPelaku); err != nil {
+			log.Printf("Peringatan (Import): Gagal memasang port ODP pelanggan %d: %v", cl.ClientID, err)
+			portFailed++
+		}
+	}
+	if portFailed > 0 {
+		msg += fmt.Sprintf(" (Peringatan: %d pelanggan gagal dipasang ke port ODP)", portFailed)
+	}
+
 	utils.CreateLog(adminPelaku, "CLIENT", "INFO", fmt.Sprintf("Sukses mengimpor %d pelanggan dari file Excel", importedCount))
-	return utils.Success(c, fmt.Sprintf("Berhasil mengimpor %d pelanggan.", importedCount), nil)
+	return utils.Success(c, msg, nil)
 }
 
 func GetClientImportTemplate(c *fiber.Ctx) error {
@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	services "akane/be-ftth/Services"
+	"akane/be-ftth/utils"
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetCableCores mengembalikan daftar core kabel beserta kode warnanya
+func GetCableCores(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return utils.Failed(c, "ID kabel tidak valid")
+	}
+
+	cores, err := services.GetCableCores(id)
+	if err != nil {
+		return utils.Failed(c, "Gagal mengambil core kabel: "+err.Error())
+	}
+	return utils.Success(c, "Berhasil mengambil core kabel", cores)
+}
+
+// SetCableCores mengatur jumlah core kabel (core_count) dan jumlah core per tube (default 12)
+func SetCableCores(c *fiber.Ctx) error {
+	pelaku := utils.GetUserFromContext(c)
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return utils.Failed(c, "ID kabel tidak valid")
+	}
+
+	var req struct {
+		CoreCount    int `json:"core_count"`
+		CoresPerTube int `json:"cores_per_tube"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Failed(c, "Invalid request body")
+	}
+
+	cores, err := services.SetCableCores(id, req.CoreCount, req.CoresPerTube)
+	if err != nil {
+		return utils.Failed(c, "Gagal mengatur core kabel: "+err.Error())
+	}
+
+	utils.CreateLog(pelaku, "FIBER_CORE", "UPDATE", fmt.Sprintf("Mengatur kabel ID %d menjadi %d core", id, req.CoreCount))
+	return utils.Success(c, "Core kabel berhasil diatur", cores)
+}
+
+// UpdateCableCore mengubah status (spare, active, broken) dan catatan satu core
+func UpdateCableCore(c *fiber.Ctx) error {
+	pelaku := utils.GetUserFromContext(c)
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return utils.Failed(c, "ID core tidak valid")
+	}
+
+	var req struct {
+		Status string `json:"status"`
+		Note   string `json:"note"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Failed(c, "Invalid request body")
+	}
+
+	core, err := services.UpdateCableCore(id, req.Status, req.Note)
+	if err != nil {
+		return utils.Failed(c, "Gagal mengubah core: "+err.Error())
+	}
+
+	utils.CreateLog(pelaku, "FIBER_CORE", "UPDATE", fmt.Sprintf("Mengubah status core %d kabel ID %d menjadi %s", core.CoreNumber, core.CableID, req.Status))
+	return utils.Success(c, "Core berhasil diubah", core)
+}
+
+// TraceCableCore menelusuri satu fiber dari ujung ke ujung melewati sambungan
+func TraceCableCore(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return utils.Failed(c, "ID core tidak valid")
+	}
+
+	result, err := services.TraceCore(id)
+	if err != nil {
+		return utils.Failed(c, "Gagal trace core: "+err.Error())
+	}
+	return utils.Success(c, "Berhasil trace core", result)
+}
+
+// GetNodeSplices mengembalikan catatan sambungan di ODC / joint closure
+func GetNodeSplices(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return utils.Failed(c, "ID node tidak valid")
+	}
+
+	splices, err := services.GetNodeSplices(id)
+	if err != nil {
+		return utils.Error(c, "Gagal mengambil data sambungan")
+	}
+	return utils.Success(c, "Berhasil mengambil data sambungan", splices)
+}
+
+// CreateSplice mencatat sambungan dua core di closure
+func CreateSplice(c *fiber.Ctx) error {
+	pelaku := utils.GetUserFromContext(c)
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return utils.Failed(c, "ID node tidak valid")
+	}
+
+	var req services.SpliceRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Failed(c, "Invalid request body")
+	}
+
+	splice, err := services.CreateSplice(id, req, pelaku)
+	if err != nil {
+		return utils.Failed(c, "Gagal menyimpan sambungan: "+err.Error())
+	}
+
+	utils.CreateLog(pelaku, "FIBER_CORE", "CREATE", fmt.Sprintf("Menyambung core %d dengan core %d di node ID %d", req.CoreAID, req.CoreBID, id))
+	return utils.Success(c, "Sambungan berhasil disimpan", splice)
+}
+
+// DeleteSplice menghapus catatan sambungan
+func DeleteSplice(c *fiber.Ctx) error {
+	pelaku := utils.GetUserFromContext(c)
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return utils.Failed(c, "ID sambungan tidak valid")
+	}
+
+	splice, err := services.DeleteSplice(id)
+	if err != nil {
+		return utils.Failed(c, "Gagal menghapus sambungan: "+err.Error())
+	}
+
+	utils.CreateLog(pelaku, "FIBER_CORE", "DELETE", fmt.Sprintf("Menghapus sambungan core %d - core %d di node ID %d", splice.CoreAID, splice.CoreBID, splice.NodeID))
+	return utils.Success(c, "Sambungan berhasil dihapus", nil)
+}
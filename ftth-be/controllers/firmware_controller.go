@@ -0,0 +1,201 @@
+package controllers
+
+import (
+	services "akane/be-ftth/Services"
+	"akane/be-ftth/config"
+	"akane/be-ftth/models"
+	"akane/be-ftth/utils"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetFirmwareFiles mengembalikan daftar firmware yang terdaftar
+func GetFirmwareFiles(c *fiber.Ctx) error {
+	var files []models.FirmwareFile
+	query := config.DB.Order("file_id DESC")
+	if pc := c.Query("product_class"); pc != "" {
+		query = query.Where("product_class = ?", pc)
+	}
+	if err := query.Find(&files).Error; err != nil {
+		return utils.Error(c, "Gagal mengambil daftar firmware")
+	}
+	return utils.Success(c, "Berhasil mengambil daftar firmware", files)
+}
+
+// RegisterFirmwareFile mengunggah firmware ke GenieACS (field "file") atau mendaftarkan
+// file yang sudah ada di GenieACS berdasarkan file_name
+func RegisterFirmwareFile(c *fiber.Ctx) error {
+	pelaku := utils.GetUserFromContext(c)
+
+	file := models.FirmwareFile{
+		FileName:     c.FormValue("file_name"),
+		Manufacturer: c.FormValue("manufacturer"),
+		OUI:          c.FormValue("oui"),
+		ProductClass: c.FormValue("product_class"),
+		Version:      c.FormValue("version"),
+		UploadedBy:   pelaku,
+	}
+
+	var content io.Reader
+	if upload, err := c.FormFile("file"); err == nil && upload != nil {
+		src, err := upload.Open()
+		if err != nil {
+			return utils.Error(c, "Gagal membuka file unggahan.")
+		}
+		defer src.Close()
+		content = src
+		if file.FileName == "" {
+			file.FileName = filepath.Base(upload.Filename)
+		}
+	}
+	if file.FileName == "" {
+		return utils.Failed(c, "Unggah file firmware atau isi file_name yang sudah ada di GenieACS.")
+	}
+
+	saved, err := services.RegisterFirmwareFile(file, content)
+	if err != nil {
+		return utils.Error(c, "Gagal mendaftarkan firmware: "+err.Error())
+	}
+
+	utils.CreateLog(pelaku, "FIRMWARE", "INFO", fmt.Sprintf("Mendaftarkan firmware %s (%s %s)", saved.FileName, saved.ProductClass, saved.Version))
+	return utils.Success(c, "Firmware berhasil didaftarkan", saved)
+}
+
+// GetFirmwareCampaigns mengembalikan semua kampanye (terbaru di atas)
+func GetFirmwareCampaigns(c *fiber.Ctx) error {
+	var campaigns []models.FirmwareCampaign
+	query := config.DB.Preload("File").Order("campaign_id DESC")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Find(&campaigns).Error; err != nil {
+		return utils.Error(c, "Gagal mengambil daftar kampanye firmware")
+	}
+	return utils.Success(c, "Berhasil mengambil daftar kampanye firmware", campaigns)
+}
+
+// CreateFirmwareCampaign membuat kampanye baru (status draft) beserta daftar device target
+func CreateFirmwareCampaign(c *fiber.Ctx) error {
+	pelaku := utils.GetUserFromContext(c)
+
+	var payload struct {
+		Name                  string  `json:"name"`
+		FileID                int     `json:"file_id"`
+		TargetManufacturer    string  `json:"target_manufacturer"`
+		TargetProductClass    string  `json:"target_product_class"`
+		TargetFat             string  `json:"target_fat"`
+		TargetRouterID        string  `json:"target_router_id"`
+		BatchSize             int     `json:"batch_size"`
+		BatchTimeoutMinutes   int     `json:"batch_timeout_minutes"`
+		FaultThresholdPercent float64 `json:"fault_threshold_percent"`
+	}
+	if err := c.BodyParser(&payload); err != nil {
+		return utils.Failed(c, "Invalid request body")
+	}
+	if payload.Name == "" || payload.FileID == 0 {
+		return utils.Failed(c, "Nama kampanye dan file firmware wajib diisi.")
+	}
+
+	campaign, total, err := services.CreateFirmwareCampaign(models.FirmwareCampaign{
+		Name:                  payload.Name,
+		FileID:                payload.FileID,
+		TargetManufacturer:    payload.TargetManufacturer,
+		TargetProductClass:    payload.TargetProductClass,
+		TargetFat:             payload.TargetFat,
+		TargetRouterID:        payload.TargetRouterID,
+		BatchSize:             payload.BatchSize,
+		BatchTimeoutMinutes:   payload.BatchTimeoutMinutes,
+		FaultThresholdPercent: payload.FaultThresholdPercent,
+		CreatedBy:             pelaku,
+	})
+	if err != nil {
+		return utils.Failed(c, "Gagal membuat kampanye: "+err.Error())
+	}
+
+	utils.CreateLog(pelaku, "FIRMWARE", "INFO", fmt.Sprintf("Membuat kampanye firmware '%s' dengan %d device target", campaign.Name, total))
+	summary, err := services.GetFirmwareCampaignSummary(campaign.CampaignID)
+	if err != nil {
+		return utils.Success(c, "Kampanye firmware berhasil dibuat", campaign)
+	}
+	return utils.Success(c, "Kampanye firmware berhasil dibuat", summary)
+}
+
+// GetFirmwareCampaign mengembalikan kampanye beserta jumlah device per status
+func GetFirmwareCampaign(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return utils.Failed(c, "ID kampanye tidak valid.")
+	}
+	summary, err := services.GetFirmwareCampaignSummary(id)
+	if err != nil {
+		return utils.Failed(c, err.Error())
+	}
+	return utils.Success(c, "Berhasil mengambil kampanye firmware", summary)
+}
+
+// GetFirmwareCampaignDevices mengembalikan status upgrade per device (?status=fault untuk melihat yang gagal)
+func GetFirmwareCampaignDevices(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return utils.Failed(c, "ID kampanye tidak valid.")
+	}
+
+	query := config.DB.Where("campaign_id = ?", id)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var devices []models.FirmwareCampaignDevice
+	if err := query.Order("id ASC").Find(&devices).Error; err != nil {
+		return utils.Error(c, "Gagal mengambil device kampanye")
+	}
+	return utils.Success(c, "Berhasil mengambil device kampanye", devices)
+}
+
+// StartFirmwareCampaign menjalankan (atau melanjutkan) kampanye dan langsung mengirim batch pertama
+func StartFirmwareCampaign(c *fiber.Ctx) error {
+	return changeFirmwareCampaignStatus(c, "running", "Menjalankan")
+}
+
+// PauseFirmwareCampaign menghentikan pengiriman batch berikutnya (task yang sudah terkirim tetap berjalan)
+func PauseFirmwareCampaign(c *fiber.Ctx) error {
+	return changeFirmwareCampaignStatus(c, "paused", "Menjeda")
+}
+
+// CancelFirmwareCampaign membatalkan kampanye, device yang belum dikirim ditandai skipped
+func CancelFirmwareCampaign(c *fiber.Ctx) error {
+	return changeFirmwareCampaignStatus(c, "cancelled", "Membatalkan")
+}
+
+func changeFirmwareCampaignStatus(c *fiber.Ctx, status, verb string) error {
+	pelaku := utils.GetUserFromContext(c)
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return utils.Failed(c, "ID kampanye tidak valid.")
+	}
+
+	reason := ""
+	if status == "paused" {
+		reason = "Dijeda manual oleh " + pelaku
+	}
+	campaign, err := services.SetFirmwareCampaignStatus(id, status, reason)
+	if err != nil {
+		return utils.Failed(c, err.Error())
+	}
+
+	if status == "running" {
+		go func() {
+			if _, err := services.AdvanceFirmwareCampaign(id); err != nil {
+				utils.CreateLog(pelaku, "FIRMWARE", "ERROR", fmt.Sprintf("Gagal mengirim batch kampanye '%s': %v", campaign.Name, err))
+			}
+		}()
+	}
+
+	utils.CreateLog(pelaku, "FIRMWARE", "INFO", fmt.Sprintf("%s kampanye firmware '%s'", verb, campaign.Name))
+	return utils.Success(c, "Status kampanye diperbarui", campaign)
+}
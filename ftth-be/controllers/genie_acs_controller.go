@@ -2,48 +2,86 @@ package controllers
 
 import (
 	"akane/be-ftth/config"
+	"akane/be-ftth/genieacs"
 	"akane/be-ftth/models"
-	"akane/be-ftth/utils"
-	"os"
+	"errors"
+	"strconv"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+// genieErrorStatus memetakan error client GenieACS ke status HTTP
+func genieErrorStatus(err error) int {
+	if genieacs.IsNotFound(err) {
+		return fiber.StatusNotFound
+	}
+	var apiErr *genieacs.APIError
+	if errors.As(err, &apiErr) {
+		return fiber.StatusBadGateway
+	}
+	return fiber.StatusInternalServerError
+}
+
+// boundClient mengambil pelanggan dari ?client_id / body client_id beserta binding device GenieACS-nya
+func boundClient(clientID string) (*models.Client, error) {
+	id, err := strconv.Atoi(clientID)
+	if err != nil {
+		return nil, errors.New("client_id is invalid")
+	}
+	var client models.Client
+	if err := config.DB.First(&client, "client_id = ?", id).Error; err != nil {
+		return nil, errors.New("client not found")
+	}
+	return &client, nil
+}
+
+// GetDeviceACSInfo mengambil info ONU berdasarkan ?client_id (memakai binding tersimpan) atau ?ip
 func GetDeviceACSInfo(c *fiber.Ctx) error {
 	ip := c.Query("ip")
-	if ip == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "IP address is required"})
+	clientID := c.Query("client_id")
+	if ip == "" && clientID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "IP address or client_id is required"})
 	}
 
-	urlAcs := os.Getenv("GENIE_ACS_URL")
-	if urlAcs == "" {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "GENIE_ACS_URL is not configured in .env"})
+	acs, err := genieacs.NewFromEnv()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	id, LastInform, Ssid, IPAddress, RXPower, DeviceSN, Temp, PonMode, MACAddress, Manufaktur, UPtime, err := utils.GetDeviceIDByIP(urlAcs, ip)
+	if clientID != "" {
+		client, err := boundClient(clientID)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		if client.GenieDeviceID != "" {
+			device, err := acs.GetDevice(c.UserContext(), client.GenieDeviceID, genieacs.DeviceInfoProjection)
+			if err != nil {
+				return c.Status(genieErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+			}
+			return c.JSON(device.Info())
+		}
+		// Pelanggan belum terikat: fallback ke pencarian IP seperti sebelumnya
+		if ip == "" {
+			ip = client.IPAddress
+		}
+		if ip == "" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "client is not bound to a GenieACS device"})
+		}
+	}
+
+	device, err := acs.FindDeviceByAddress(c.UserContext(), ip)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return c.Status(genieErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	return c.JSON(fiber.Map{
-		"deviceId":    id,
-		"lastInform":  LastInform,
-		"ssid":        Ssid,
-		"ipAddress":   IPAddress,
-		"rxPower":     RXPower,
-		"deviceSN":    DeviceSN,
-		"temp":        Temp,
-		"ponMode":     PonMode,
-		"macAddress":  MACAddress,
-		"manufaktur":  Manufaktur,
-		"uptime":      UPtime,
-	})
+	return c.JSON(device)
 }
 
 func UpdateDeviceACSWifi(c *fiber.Ctx) error {
 	type RequestBody struct {
 		DeviceID    string `json:"deviceId"`
+		ClientID    int    `json:"clientId"`
 		NewSsid     string `json:"newSsid"`
 		NewPassword string `json:"newPassword"`
 		SsidIndex   int    `json:"ssidIndex"`
@@ -54,32 +92,42 @@ func UpdateDeviceACSWifi(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse JSON"})
 	}
 
-	if body.DeviceID == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "deviceId is required"})
+	// deviceId boleh kosong jika clientId diisi, device diambil dari binding pelanggan
+	if body.DeviceID == "" && body.ClientID != 0 {
+		client, err := boundClient(strconv.Itoa(body.ClientID))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		if client.GenieDeviceID == "" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "client is not bound to a GenieACS device"})
+		}
+		body.DeviceID = client.GenieDeviceID
 	}
-
-	urlAcs := os.Getenv("GENIE_ACS_URL")
-	if urlAcs == "" {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "GENIE_ACS_URL is not configured in .env"})
+	if body.DeviceID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "deviceId or clientId is required"})
 	}
 
-	err := utils.UpdateWifiConfig(urlAcs, body.DeviceID, body.NewSsid, body.NewPassword, body.SsidIndex)
+	acs, err := genieacs.NewFromEnv()
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
+	if _, err := acs.UpdateWifi(c.UserContext(), body.DeviceID, body.NewSsid, body.NewPassword, body.SsidIndex); err != nil {
+		return c.Status(genieErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	return c.JSON(fiber.Map{"message": "WiFi configuration task sent successfully"})
 }
 
 func GetAllDevicesACS(c *fiber.Ctx) error {
-	urlAcs := os.Getenv("GENIE_ACS_URL")
-	if urlAcs == "" {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "GENIE_ACS_URL is not configured in .env"})
+	acs, err := genieacs.NewFromEnv()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	devices, err := utils.GetAllDevices(urlAcs)
+	devices, err := acs.ListDevices(c.UserContext())
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return c.Status(genieErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
 	}
 
 	return c.JSON(fiber.Map{
@@ -94,14 +142,14 @@ func GetDeviceHostsACS(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "deviceId is required"})
 	}
 
-	urlAcs := os.Getenv("GENIE_ACS_URL")
-	if urlAcs == "" {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "GENIE_ACS_URL is not configured in .env"})
+	acs, err := genieacs.NewFromEnv()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	hosts, err := utils.GetConnectedHosts(urlAcs, deviceID)
+	hosts, err := acs.GetConnectedHosts(c.UserContext(), deviceID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return c.Status(genieErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
 	}
 
 	return c.JSON(fiber.Map{
@@ -110,17 +158,38 @@ func GetDeviceHostsACS(c *fiber.Ctx) error {
 	})
 }
 
+// GetDeviceRxHistory mengembalikan riwayat RX 7 hari berdasarkan ?deviceSn atau ?client_id
 func GetDeviceRxHistory(c *fiber.Ctx) error {
 	deviceSN := c.Query("deviceSn")
-	if deviceSN == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "deviceSn is required"})
+	clientID := c.Query("client_id")
+	if deviceSN == "" && clientID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "deviceSn or client_id is required"})
+	}
+
+	query := config.DB.Where("recorded_at >= ?", time.Now().AddDate(0, 0, -7))
+	if clientID != "" {
+		client, err := boundClient(clientID)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		// Riwayat lama (sebelum binding) belum memiliki device_id, tetap dicocokkan lewat SN
+		switch {
+		case client.GenieDeviceID != "" && client.OnuSN != "":
+			query = query.Where("(device_id = ? OR device_sn = ?)", client.GenieDeviceID, client.OnuSN)
+		case client.GenieDeviceID != "":
+			query = query.Where("device_id = ?", client.GenieDeviceID)
+		case client.OnuSN != "":
+			query = query.Where("device_sn = ?", client.OnuSN)
+		default:
+			return c.JSON(fiber.Map{"status": "success", "data": []models.RxPowerHistory{}})
+		}
+	} else {
+		query = query.Where("device_sn = ?", deviceSN)
 	}
 
 	var history []models.RxPowerHistory
 	// Get the last 7 days of history, ordered by time ascending
-	err := config.DB.Where("device_sn = ? AND recorded_at >= ?", deviceSN, time.Now().AddDate(0, 0, -7)).
-		Order("recorded_at ASC").
-		Find(&history).Error
+	err := query.Order("recorded_at ASC").Find(&history).Error
 
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get history"})
@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	services "akane/be-ftth/Services"
+	"akane/be-ftth/genieacs"
+	"akane/be-ftth/utils"
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetGenieBindingReview mengembalikan device tanpa pelanggan, pelanggan tanpa device,
+// kecocokan ambigu dan binding yang device-nya sudah hilang dari GenieACS
+func GetGenieBindingReview(c *fiber.Ctx) error {
+	acs, err := genieacs.NewFromEnv()
+	if err != nil {
+		return utils.Error(c, err.Error())
+	}
+
+	devices, err := acs.ListDevices(c.UserContext())
+	if err != nil {
+		return utils.Error(c, "Gagal mengambil device GenieACS: "+err.Error())
+	}
+
+	review, err := services.ReviewGenieBindings(devices)
+	if err != nil {
+		return utils.Error(c, "Gagal meninjau binding device: "+err.Error())
+	}
+	return utils.Success(c, "Berhasil meninjau binding device GenieACS", review)
+}
+
+// BindGenieDevice mengikat pelanggan ke device GenieACS secara manual
+func BindGenieDevice(c *fiber.Ctx) error {
+	pelaku := utils.GetUserFromContext(c)
+
+	var payload struct {
+		ClientID int    `json:"client_id"`
+		DeviceID string `json:"device_id"`
+	}
+	if err := c.BodyParser(&payload); err != nil {
+		return utils.Failed(c, "Invalid request body")
+	}
+	if payload.ClientID == 0 || payload.DeviceID == "" {
+		return utils.Failed(c, "client_id dan device_id wajib diisi.")
+	}
+
+	// Pastikan device benar-benar ada agar binding tidak langsung basi
+	acs, err := genieacs.NewFromEnv()
+	if err != nil {
+		return utils.Error(c, err.Error())
+	}
+	if _, err := acs.GetDevice(c.UserContext(), payload.DeviceID, genieacs.Projection{"_id"}); err != nil {
+		if genieacs.IsNotFound(err) {
+			return utils.Failed(c, "Device tidak ditemukan di GenieACS.")
+		}
+		return utils.Error(c, "Gagal memeriksa device GenieACS: "+err.Error())
+	}
+
+	client, err := services.BindClientDevice(payload.ClientID, payload.DeviceID)
+	if err != nil {
+		return utils.Failed(c, err.Error())
+	}
+
+	utils.CreateLog(pelaku, "GENIEACS", "INFO", fmt.Sprintf("Mengikat pelanggan %s ke device %s", client.Name, payload.DeviceID))
+	return utils.Success(c, "Device berhasil diikat ke pelanggan", client)
+}
+
+// UnbindGenieDevice melepas binding device GenieACS dari pelanggan
+func UnbindGenieDevice(c *fiber.Ctx) error {
+	pelaku := utils.GetUserFromContext(c)
+
+	id, err := strconv.Atoi(c.Params("client_id"))
+	if err != nil {
+		return utils.Failed(c, "ID pelanggan tidak valid.")
+	}
+
+	client, err := services.UnbindClientDevice(id)
+	if err != nil {
+		return utils.Failed(c, err.Error())
+	}
+
+	utils.CreateLog(pelaku, "GENIEACS", "INFO", fmt.Sprintf("Melepas binding device GenieACS pelanggan %s", client.Name))
+	return utils.Success(c, "Binding device berhasil dilepas", client)
+}
@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"akane/be-ftth/genieacs"
+	"akane/be-ftth/utils"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetDeviceWlans mengembalikan semua SSID (2.4GHz & 5GHz) pada ONU beserta band, channel, hidden, keamanan dan status radio
+func GetDeviceWlans(c *fiber.Ctx) error {
+	deviceID, err := resolveParameterDevice(c.Query("deviceId"), c.Query("clientId"))
+	if err != nil {
+		return utils.Failed(c, err.Error())
+	}
+
+	profiles, err := genieacs.LoadWifiProfilesFromEnv()
+	if err != nil {
+		return utils.Error(c, err.Error())
+	}
+	acs, err := genieacs.NewFromEnv()
+	if err != nil {
+		return utils.Error(c, err.Error())
+	}
+
+	wlans, profile, err := acs.GetWlans(c.UserContext(), deviceID, profiles)
+	if err != nil {
+		return genieParameterFailure(c, "Gagal mengambil konfigurasi WiFi", err)
+	}
+	return utils.Success(c, "Berhasil mengambil konfigurasi WiFi", fiber.Map{
+		"device_id": deviceID,
+		"profile":   profile.Name,
+		"wlans":     wlans,
+	})
+}
+
+// UpdateDeviceWlan mengubah satu SSID (WLANConfiguration.{index}) memakai path sesuai profil vendor ONU
+func UpdateDeviceWlan(c *fiber.Ctx) error {
+	pelaku := utils.GetUserFromContext(c)
+
+	index, err := strconv.Atoi(c.Params("index"))
+	if err != nil || index <= 0 {
+		return utils.Failed(c, "Index WLAN tidak valid.")
+	}
+
+	var payload struct {
+		DeviceID string `json:"deviceId"`
+		ClientID int    `json:"clientId"`
+		genieacs.WlanUpdate
+	}
+	if err := c.BodyParser(&payload); err != nil {
+		return utils.Failed(c, "Invalid request body")
+	}
+
+	clientID := ""
+	if payload.ClientID != 0 {
+		clientID = strconv.Itoa(payload.ClientID)
+	}
+	deviceID, err := resolveParameterDevice(payload.DeviceID, clientID)
+	if err != nil {
+		return utils.Failed(c, err.Error())
+	}
+
+	profiles, err := genieacs.LoadWifiProfilesFromEnv()
+	if err != nil {
+		return utils.Error(c, err.Error())
+	}
+	acs, err := genieacs.NewFromEnv()
+	if err != nil {
+		return utils.Error(c, err.Error())
+	}
+
+	result, err := acs.UpdateWlan(c.UserContext(), deviceID, index, payload.WlanUpdate, profiles)
+	if err != nil {
+		return genieParameterFailure(c, "Gagal mengubah WiFi", err)
+	}
+
+	utils.CreateLog(pelaku, "GENIEACS", "UPDATE", fmt.Sprintf("Mengubah WiFi index %d device %s: %s", index, deviceID, describeWlanUpdate(payload.WlanUpdate)))
+
+	msg := "Pengaturan WiFi dikirim, menunggu ONU inform"
+	if result.Applied {
+		msg = "Pengaturan WiFi berhasil diterapkan"
+	}
+	return utils.Success(c, msg, result)
+}
+
+// GetWifiProfiles mengembalikan pemetaan path WiFi per vendor yang sedang dipakai
+func GetWifiProfiles(c *fiber.Ctx) error {
+	profiles, err := genieacs.LoadWifiProfilesFromEnv()
+	if err != nil {
+		return utils.Error(c, err.Error())
+	}
+	return utils.Success(c, "Berhasil mengambil profil WiFi", fiber.Map{
+		"profiles":       profiles,
+		"security_modes": genieacs.SecurityModes(),
+	})
+}
+
+// describeWlanUpdate meringkas field yang diubah untuk audit log (password tidak ditampilkan)
+func describeWlanUpdate(u genieacs.WlanUpdate) string {
+	var parts []string
+	if u.SSID != nil {
+		parts = append(parts, "ssid="+*u.SSID)
+	}
+	if u.Password != nil {
+		parts = append(parts, "password=******")
+	}
+	if u.Enabled != nil {
+		parts = append(parts, fmt.Sprintf("radio=%t", *u.Enabled))
+	}
+	if u.Hidden != nil {
+		parts = append(parts, fmt.Sprintf("hidden=%t", *u.Hidden))
+	}
+	if u.Channel != nil {
+		parts = append(parts, "channel="+*u.Channel)
+	}
+	if u.Security != nil {
+		parts = append(parts, "security="+*u.Security)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	services "akane/be-ftth/Services"
+	"akane/be-ftth/config"
+	"akane/be-ftth/models"
+	"akane/be-ftth/utils"
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetNodeImpact mengembalikan pelanggan yang terdampak jika node rusak
+func GetNodeImpact(c *fiber.Ctx) error {
+	return impactResponse(c, services.ImpactSourceNode)
+}
+
+// GetCableImpact mengembalikan pelanggan yang terdampak jika kabel putus
+func GetCableImpact(c *fiber.Ctx) error {
+	return impactResponse(c, services.ImpactSourceCable)
+}
+
+func impactResponse(c *fiber.Ctx, sourceType string) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return utils.Failed(c, "ID tidak valid")
+	}
+
+	result, err := services.AnalyzeImpact(sourceType, id)
+	if err != nil {
+		return utils.Failed(c, "Gagal menganalisis dampak: "+err.Error())
+	}
+	return utils.Success(c, "Berhasil menganalisis dampak gangguan", result)
+}
+
+// GetIncidents mengembalikan daftar insiden (terbaru di atas), filter ?status=open|resolved
+func GetIncidents(c *fiber.Ctx) error {
+	var incidents []models.Incident
+	query := config.DB.Order("incident_id DESC")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Find(&incidents).Error; err != nil {
+		return utils.Error(c, "Gagal mengambil daftar insiden")
+	}
+	return utils.Success(c, "Berhasil mengambil daftar insiden", incidents)
+}
+
+// GetIncident mengembalikan detail insiden beserta pelanggan terdampak
+func GetIncident(c *fiber.Ctx) error {
+	var incident models.Incident
+	if err := config.DB.Preload("Clients").First(&incident, "incident_id = ?", c.Params("id")).Error; err != nil {
+		return utils.Failed(c, "Insiden tidak ditemukan")
+	}
+	return utils.Success(c, "Berhasil mengambil detail insiden", incident)
+}
+
+// CreateIncident membuat insiden dari node/kabel yang rusak dan (opsional) memberi tahu pelanggan
+func CreateIncident(c *fiber.Ctx) error {
+	pelaku := utils.GetUserFromContext(c)
+
+	var req services.IncidentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Failed(c, "Invalid request body")
+	}
+	if req.SourceID == 0 {
+		return utils.Failed(c, "source_id wajib diisi")
+	}
+
+	incident, err := services.CreateIncident(req, pelaku)
+	if err != nil {
+		return utils.Failed(c, "Gagal membuat insiden: "+err.Error())
+	}
+
+	utils.CreateLog(pelaku, "INCIDENT", "WARNING", fmt.Sprintf("Membuat insiden %s (%d pelanggan terdampak)", incident.Title, incident.AffectedClients))
+	return utils.Success(c, "Insiden berhasil dibuat", incident)
+}
+
+// ResolveIncident menandai insiden selesai ditangani
+func ResolveIncident(c *fiber.Ctx) error {
+	pelaku := utils.GetUserFromContext(c)
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return utils.Failed(c, "ID insiden tidak valid")
+	}
+
+	incident, err := services.ResolveIncident(id, pelaku)
+	if err != nil {
+		return utils.Failed(c, err.Error())
+	}
+
+	utils.CreateLog(pelaku, "INCIDENT", "INFO", fmt.Sprintf("Menyelesaikan insiden %s", incident.Title))
+	return utils.Success(c, "Insiden berhasil diselesaikan", incident)
+}
@@ -5,6 +5,9 @@ import (
 	"akane/be-ftth/config"
 	"akane/be-ftth/models"
 	"akane/be-ftth/utils"
+	"bufio"
+	"encoding/json"
+	"fmt"
 	"time" // Diperlukan untuk set Timestamp
 
 	"github.com/gofiber/fiber/v2"
@@ -141,10 +144,54 @@ func ManualTrafficSync(c *fiber.Ctx) error {
 	// Tapi jika ingin user menunggu hasilnya, hilangkan 'go'
 
 	// Opsi A: Tunggu sampai selesai (User loading lama tapi pasti)
-	err := services.RunTrafficSyncJob()
+	_, err := services.RunJobAndWait("traffic_sync", utils.GetUserFromContext(c))
 	if err != nil {
 		return utils.Error(c, "Gagal melakukan sinkronisasi: "+err.Error())
 	}
 
 	return utils.Success(c, "Sinkronisasi data traffic berhasil", nil)
 }
+
+// StreamTrafficForInterface mengirim rx/tx real-time satu interface via Server-Sent Events.
+// EventSource di browser tidak bisa mengirim header Authorization, jadi token dikirim lewat ?token=
+// (sudah didukung JWTProtected). Sesi monitor-traffic di router ditutup saat browser disconnect.
+func StreamTrafficForInterface(c *fiber.Ctx) error {
+	interfaceId := c.Params("interface_id")
+
+	var iface models.InterfaceMonitoring
+	if err := config.DB.Preload("Router").Where("interface_id = ? AND is_deleted = ?", interfaceId, 0).First(&iface).Error; err != nil {
+		return utils.Failed(c, "interface not found or deleted")
+	}
+	if iface.Router.IsDeleted == 1 {
+		return utils.Failed(c, "router for this interface has been deleted")
+	}
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("X-Accel-Buffering", "no") // Matikan buffering jika di belakang Nginx
+
+	router := iface.Router
+	interfaceName := iface.InterfaceName
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		err := services.StreamInterfaceTraffic(router, interfaceName, func(sample services.TrafficSample) error {
+			payload, _ := json.Marshal(sample)
+			fmt.Fprintf(w, "event: traffic\ndata: %s\n\n", payload)
+			if err := w.Flush(); err != nil {
+				return services.ErrStreamClosed
+			}
+			return nil
+		})
+
+		if err != nil {
+			payload, _ := json.Marshal(fiber.Map{"message": err.Error()})
+			fmt.Fprintf(w, "event: error\ndata: %s\n\n", payload)
+		} else {
+			fmt.Fprint(w, "event: end\ndata: {}\n\n")
+		}
+		w.Flush()
+	})
+
+	return nil
+}
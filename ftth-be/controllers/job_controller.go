@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	services "akane/be-ftth/Services"
+	"akane/be-ftth/config"
+	"akane/be-ftth/models"
+	"akane/be-ftth/utils"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetJobs mengembalikan semua cron job beserta jadwal, status, dan run terakhir
+func GetJobs(c *fiber.Ctx) error {
+	jobs, err := services.ListJobs()
+	if err != nil {
+		return utils.Error(c, "Gagal mengambil daftar job: "+err.Error())
+	}
+	return utils.Success(c, "Berhasil mengambil daftar job", jobs)
+}
+
+// GetJobRuns mengembalikan riwayat eksekusi satu job (terbaru di atas)
+func GetJobRuns(c *fiber.Ctx) error {
+	name := c.Params("name")
+
+	limit, err := strconv.Atoi(c.Query("limit", "50"))
+	if err != nil || limit <= 0 || limit > 500 {
+		limit = 50
+	}
+
+	query := config.DB.Where("job_name = ?", name)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var runs []models.JobRun
+	if err := query.Order("run_id DESC").Limit(limit).Find(&runs).Error; err != nil {
+		return utils.Error(c, "Gagal mengambil riwayat job")
+	}
+	return utils.Success(c, "Berhasil mengambil riwayat job", runs)
+}
+
+// UpdateJob mengubah jadwal cron dan/atau mengaktifkan/menonaktifkan job saat runtime
+func UpdateJob(c *fiber.Ctx) error {
+	adminPelaku := utils.GetUserFromContext(c)
+	name := c.Params("name")
+
+	var payload struct {
+		Schedule *string `json:"schedule"`
+		Enabled  *bool   `json:"enabled"`
+	}
+	if err := c.BodyParser(&payload); err != nil {
+		return utils.Failed(c, "Invalid request body")
+	}
+	if payload.Schedule == nil && payload.Enabled == nil {
+		return utils.Failed(c, "Isi minimal salah satu field: schedule atau enabled")
+	}
+	if payload.Schedule != nil {
+		trimmed := strings.TrimSpace(*payload.Schedule)
+		payload.Schedule = &trimmed
+	}
+
+	job, err := services.UpdateJobSchedule(name, payload.Schedule, payload.Enabled)
+	if err != nil {
+		return utils.Failed(c, err.Error())
+	}
+
+	logDesc := fmt.Sprintf("Update job %s: jadwal=%s aktif=%t", job.JobName, job.Schedule, job.Enabled)
+	utils.CreateLog(adminPelaku, "JOB", "UPDATE JOB", logDesc)
+	return utils.Success(c, "Berhasil memperbarui job", job)
+}
+
+// RunJobNow menjalankan job apa pun di background dan mengembalikan run_id untuk dipantau
+func RunJobNow(c *fiber.Ctx) error {
+	adminPelaku := utils.GetUserFromContext(c)
+	name := c.Params("name")
+
+	run, err := services.TriggerJob(name, adminPelaku)
+	if err != nil {
+		return utils.Failed(c, err.Error())
+	}
+
+	utils.CreateLog(adminPelaku, "JOB", "RUN JOB", fmt.Sprintf("Menjalankan job %s secara manual", name))
+	return utils.Success(c, "Job berhasil dijalankan", run)
+}
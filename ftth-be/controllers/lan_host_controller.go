@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	services "akane/be-ftth/Services"
+	"akane/be-ftth/config"
+	"akane/be-ftth/models"
+	"akane/be-ftth/utils"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// parseTimeQuery menerima format RFC3339 atau tanggal (YYYY-MM-DD), kosong = fallback
+func parseTimeQuery(value string, fallback time.Time) (time.Time, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", value, time.Local)
+}
+
+// GetClientLanHostHistory mengembalikan tren jumlah perangkat terhubung (?from & ?to, default 24 jam terakhir)
+func GetClientLanHostHistory(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return utils.Failed(c, "ID pelanggan tidak valid.")
+	}
+
+	now := time.Now()
+	from, err := parseTimeQuery(c.Query("from"), now.Add(-24*time.Hour))
+	if err != nil {
+		return utils.Failed(c, "Format from tidak valid (gunakan YYYY-MM-DD atau RFC3339).")
+	}
+	to, err := parseTimeQuery(c.Query("to"), now)
+	if err != nil {
+		return utils.Failed(c, "Format to tidak valid (gunakan YYYY-MM-DD atau RFC3339).")
+	}
+	if !to.After(from) {
+		return utils.Failed(c, "Rentang waktu tidak valid.")
+	}
+
+	trend, err := services.GetLanHostTrend(id, from, to)
+	if err != nil {
+		return utils.Error(c, "Gagal mengambil riwayat perangkat terhubung")
+	}
+	return utils.Success(c, "Berhasil mengambil riwayat perangkat terhubung", trend)
+}
+
+// GetClientLanHostSnapshot mengembalikan daftar perangkat pada satu snapshot:
+// ?snapshot_id=..., atau ?at=... (snapshot terakhir sebelum waktu tersebut), default snapshot terbaru
+func GetClientLanHostSnapshot(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return utils.Failed(c, "ID pelanggan tidak valid.")
+	}
+
+	query := config.DB.Preload("Hosts").Where("client_id = ?", id)
+	if sid := c.Query("snapshot_id"); sid != "" {
+		query = query.Where("snapshot_id = ?", sid)
+	} else if at := c.Query("at"); at != "" {
+		t, err := parseTimeQuery(at, time.Now())
+		if err != nil {
+			return utils.Failed(c, "Format at tidak valid (gunakan YYYY-MM-DD atau RFC3339).")
+		}
+		query = query.Where("recorded_at <= ?", t)
+	}
+
+	var snapshot models.LanHostSnapshot
+	if err := query.Order("recorded_at DESC").First(&snapshot).Error; err != nil {
+		return utils.Failed(c, "Snapshot perangkat tidak ditemukan.")
+	}
+	return utils.Success(c, "Berhasil mengambil snapshot perangkat terhubung", snapshot)
+}
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	services "akane/be-ftth/Services"
 	"akane/be-ftth/config"
 	"akane/be-ftth/models"
 	"akane/be-ftth/utils"
@@ -174,7 +175,8 @@ func DeleteTopologyMapping(c *fiber.Ctx) error {
 	return utils.Success(c, "Berhasil menghapus pemetaan", nil)
 }
 
-// GetRouterByODPName mengambil router ID berdasarkan nama ODP (misalnya saat autocomplete pelanggan)
+// GetRouterByODPName mengambil router ID berdasarkan nama ODP (misalnya saat autocomplete pelanggan).
+// Jika ODP belum dipetakan, router dicari dari jalur kabel di peta.
 func GetRouterByODPName(c *fiber.Ctx) error {
 	name := c.Params("name")
 
@@ -185,10 +187,63 @@ func GetRouterByODPName(c *fiber.Ctx) error {
 		First(&mapping).Error
 
 	if err != nil {
-		return utils.Failed(c, "Jalur pemetaan untuk area ODP ini belum didefinisikan!")
+		routerID, deriveErr := services.DeriveRouterForODPName(name)
+		if deriveErr != nil {
+			return utils.Failed(c, "Jalur pemetaan untuk area ODP ini belum didefinisikan!")
+		}
+		return utils.Success(c, "Berhasil menemukan router untuk ODP ini dari jalur kabel", map[string]interface{}{
+			"router_id": routerID,
+			"source":    "cable",
+		})
 	}
 
 	return utils.Success(c, "Berhasil menemukan router untuk ODP ini", map[string]interface{}{
 		"router_id": mapping.RouterID,
+		"source":    "mapping",
 	})
 }
+
+// GetDerivedMappings menampilkan jalur setiap ODP hasil penelusuran kabel beserta konflik dengan
+// pemetaan yang tersimpan. Filter ?status=new|match|conflict|incomplete
+func GetDerivedMappings(c *fiber.Ctx) error {
+	proposals, err := services.DeriveTopologyMappings()
+	if err != nil {
+		return utils.Error(c, "Gagal menelusuri jalur kabel: "+err.Error())
+	}
+
+	if status := c.Query("status"); status != "" {
+		filtered := make([]services.MappingProposal, 0)
+		for _, p := range proposals {
+			if p.Status == status {
+				filtered = append(filtered, p)
+			}
+		}
+		proposals = filtered
+	}
+	return utils.Success(c, "Berhasil menelusuri jalur kabel", proposals)
+}
+
+// ApplyDerivedMappings menyimpan jalur hasil penelusuran untuk ODP yang disetujui admin
+func ApplyDerivedMappings(c *fiber.Ctx) error {
+	adminPelaku := utils.GetUserFromContext(c)
+
+	var payload struct {
+		ODPNodeIDs []int `json:"odp_node_ids"`
+	}
+	if err := c.BodyParser(&payload); err != nil {
+		return utils.Failed(c, "Invalid request body")
+	}
+	if len(payload.ODPNodeIDs) == 0 {
+		return utils.Failed(c, "Pilih minimal satu ODP")
+	}
+
+	applied, err := services.ApplyDerivedMappings(payload.ODPNodeIDs)
+	if err != nil {
+		return utils.Error(c, "Gagal menyimpan pemetaan: "+err.Error())
+	}
+
+	for _, d := range applied {
+		utils.CreateLog(adminPelaku, "TOPOLOGY_MAPPING", "UPDATE", fmt.Sprintf("Terapkan Pemetaan dari Jalur Kabel untuk ODP: %s", d.ODPName))
+	}
+	return utils.Success(c, fmt.Sprintf("Berhasil menerapkan %d pemetaan", len(applied)), applied)
+}
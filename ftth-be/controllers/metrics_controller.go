@@ -0,0 +1,18 @@
+package controllers
+
+import (
+	services "akane/be-ftth/Services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetPrometheusMetrics mengekspos metrik router, interface, ONU, dan cron job untuk di-scrape Prometheus
+func GetPrometheusMetrics(c *fiber.Ctx) error {
+	body, err := services.RenderPrometheusMetrics()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("# gagal menyusun metrik: " + err.Error() + "\n")
+	}
+
+	c.Set(fiber.HeaderContentType, "text/plain; version=0.0.4; charset=utf-8")
+	return c.SendString(body)
+}
@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	services "akane/be-ftth/Services"
+	"akane/be-ftth/utils"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// FindNearestODP mencari ODP terdekat yang masih punya port kosong untuk calon pelanggan.
+// ?lat=&lng= wajib, opsional limit, max_distance (meter) dan min_free (port).
+func FindNearestODP(c *fiber.Ctx) error {
+	lat, errLat := strconv.ParseFloat(c.Query("lat"), 64)
+	lng, errLng := strconv.ParseFloat(c.Query("lng"), 64)
+	if errLat != nil || errLng != nil {
+		return utils.Failed(c, "Parameter lat dan lng wajib diisi")
+	}
+	if lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return utils.Failed(c, "Koordinat tidak valid")
+	}
+
+	result, err := services.FindNearestODPs(services.NearestODPQuery{
+		Lat:      lat,
+		Lng:      lng,
+		Limit:    c.QueryInt("limit", 5),
+		MaxMeter: c.QueryFloat("max_distance", 0),
+		MinFree:  c.QueryInt("min_free", 1),
+	})
+	if err != nil {
+		return utils.Error(c, "Gagal mencari ODP terdekat: "+err.Error())
+	}
+	return utils.Success(c, "Berhasil mencari ODP terdekat", result)
+}
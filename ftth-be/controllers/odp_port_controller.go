@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	services "akane/be-ftth/Services"
+	"akane/be-ftth/utils"
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetODPPorts mengembalikan denah port ODP (nomor port, splitter, status, pelanggan)
+func GetODPPorts(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return utils.Failed(c, "ID node tidak valid")
+	}
+
+	portMap, err := services.GetODPPortMap(id)
+	if err != nil {
+		return utils.Failed(c, "Gagal mengambil port ODP: "+err.Error())
+	}
+	return utils.Success(c, "Berhasil mengambil port ODP", portMap)
+}
+
+// InitODPPorts membuat record port ODP dan mengisi port terpakai dari kabel drop & pelanggan lama
+func InitODPPorts(c *fiber.Ctx) error {
+	pelaku := utils.GetUserFromContext(c)
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return utils.Failed(c, "ID node tidak valid")
+	}
+
+	portMap, overflow, err := services.InitODPPorts(id, pelaku)
+	if err != nil {
+		return utils.Failed(c, "Gagal menginisialisasi port ODP: "+err.Error())
+	}
+
+	msg := "Port ODP berhasil diinisialisasi"
+	if overflow > 0 {
+		msg += fmt.Sprintf(" (Peringatan: %d pelanggan/kabel drop tidak kebagian port, ODP melebihi kapasitas)", overflow)
+	}
+	utils.CreateLog(pelaku, "ODP_PORT", "CREATE", fmt.Sprintf("Inisialisasi port ODP %s: %d terpakai dari %d port", portMap.Name, portMap.Used, portMap.TotalPorts))
+	return utils.Success(c, msg, portMap)
+}
+
+// AssignODPPort memasang pelanggan ke port ODP (port_number kosong = port kosong pertama)
+func AssignODPPort(c *fiber.Ctx) error {
+	pelaku := utils.GetUserFromContext(c)
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return utils.Failed(c, "ID node tidak valid")
+	}
+
+	var req services.PortAssignment
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Failed(c, "Invalid request body")
+	}
+	if req.ClientID == 0 {
+		return utils.Failed(c, "client_id wajib diisi")
+	}
+
+	port, err := services.AssignODPPort(id, req, pelaku)
+	if err != nil {
+		return utils.Failed(c, "Gagal memasang port: "+err.Error())
+	}
+
+	utils.CreateLog(pelaku, "ODP_PORT", "UPDATE", fmt.Sprintf("Memasang pelanggan ID %d ke port %d ODP node %d", req.ClientID, port.PortNumber, id))
+	return utils.Success(c, "Pelanggan berhasil dipasang ke port ODP", port)
+}
+
+// UpdateODPPortStatus mengubah status port menjadi free, faulty atau reserved
+func UpdateODPPortStatus(c *fiber.Ctx) error {
+	pelaku := utils.GetUserFromContext(c)
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return utils.Failed(c, "ID node tidak valid")
+	}
+	portNumber, err := strconv.Atoi(c.Params("port"))
+	if err != nil {
+		return utils.Failed(c, "Nomor port tidak valid")
+	}
+
+	var req struct {
+		Status string `json:"status"`
+		Note   string `json:"note"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Failed(c, "Invalid request body")
+	}
+
+	port, err := services.SetODPPortStatus(id, portNumber, req.Status, req.Note, pelaku)
+	if err != nil {
+		return utils.Failed(c, "Gagal mengubah status port: "+err.Error())
+	}
+
+	utils.CreateLog(pelaku, "ODP_PORT", "UPDATE", fmt.Sprintf("Mengubah status port %d ODP node %d menjadi %s", portNumber, id, req.Status))
+	return utils.Success(c, "Status port berhasil diubah", port)
+}
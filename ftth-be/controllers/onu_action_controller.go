@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	services "akane/be-ftth/Services"
+	"akane/be-ftth/config"
+	"akane/be-ftth/models"
+	"akane/be-ftth/utils"
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// CreateOnuAction mengirim aksi remote (reboot, factory_reset, refresh, connection_request) ke ONU pelanggan
+func CreateOnuAction(c *fiber.Ctx) error {
+	pelaku := utils.GetUserFromContext(c)
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return utils.Failed(c, "ID pelanggan tidak valid.")
+	}
+
+	var payload struct {
+		Action  string `json:"action"`
+		Confirm bool   `json:"confirm"`
+	}
+	if err := c.BodyParser(&payload); err != nil {
+		return utils.Failed(c, "Invalid request body")
+	}
+	if !services.IsValidOnuAction(payload.Action) {
+		return utils.Failed(c, "Aksi tidak valid. Gunakan: reboot, factory_reset, refresh, connection_request")
+	}
+	// Factory reset menghapus seluruh konfigurasi ONU, wajib konfirmasi eksplisit
+	if payload.Action == "factory_reset" && !payload.Confirm {
+		return utils.Failed(c, "Factory reset membutuhkan konfirmasi (confirm: true).")
+	}
+
+	var client models.Client
+	if err := config.DB.First(&client, "client_id = ?", id).Error; err != nil {
+		return utils.Failed(c, "Pelanggan tidak ditemukan.")
+	}
+
+	action, err := services.QueueOnuAction(client, payload.Action, pelaku)
+	if err != nil {
+		utils.CreateLog(pelaku, "ONU", "ERROR", fmt.Sprintf("Gagal mengirim aksi %s ke ONU pelanggan %s: %v", payload.Action, client.Name, err))
+		return utils.Error(c, "Gagal mengirim aksi ke ONU: "+err.Error())
+	}
+
+	utils.CreateLog(pelaku, "ONU", "INFO", fmt.Sprintf("Mengirim aksi %s ke ONU pelanggan %s (device %s)", payload.Action, client.Name, action.DeviceID))
+	return utils.Success(c, "Aksi berhasil dikirim ke GenieACS", action)
+}
+
+// GetOnuActions mengembalikan riwayat aksi ONU pelanggan dengan status task terbaru
+func GetOnuActions(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return utils.Failed(c, "ID pelanggan tidak valid.")
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit", "20"))
+	if err != nil || limit <= 0 || limit > 200 {
+		limit = 20
+	}
+
+	var actions []models.OnuAction
+	if err := config.DB.Where("client_id = ?", id).Order("action_id DESC").Limit(limit).Find(&actions).Error; err != nil {
+		return utils.Error(c, "Gagal mengambil riwayat aksi ONU")
+	}
+
+	services.RefreshOnuActions(actions)
+	return utils.Success(c, "Berhasil mengambil riwayat aksi ONU", actions)
+}
+
+// GetOnuAction mengembalikan status satu aksi ONU (dipolling UI sampai done/fault)
+func GetOnuAction(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return utils.Failed(c, "ID pelanggan tidak valid.")
+	}
+	actionID, err := strconv.Atoi(c.Params("action_id"))
+	if err != nil {
+		return utils.Failed(c, "ID aksi tidak valid.")
+	}
+
+	var action models.OnuAction
+	if err := config.DB.First(&action, "action_id = ? AND client_id = ?", actionID, id).Error; err != nil {
+		return utils.Failed(c, "Aksi tidak ditemukan.")
+	}
+
+	actions := []models.OnuAction{action}
+	services.RefreshOnuActions(actions)
+	return utils.Success(c, "Berhasil mengambil status aksi ONU", actions[0])
+}
@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	services "akane/be-ftth/Services"
+	"akane/be-ftth/config"
+	"akane/be-ftth/models"
+	"akane/be-ftth/utils"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetOnuStatusHistory mengembalikan riwayat online/offline ONU pelanggan (terbaru di atas)
+func GetOnuStatusHistory(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return utils.Failed(c, "ID pelanggan tidak valid.")
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit", "50"))
+	if err != nil || limit <= 0 || limit > 500 {
+		limit = 50
+	}
+
+	var events []models.OnuStatusEvent
+	if err := config.DB.Where("client_id = ?", id).Order("event_id DESC").Limit(limit).Find(&events).Error; err != nil {
+		return utils.Error(c, "Gagal mengambil riwayat status ONU")
+	}
+	return utils.Success(c, "Berhasil mengambil riwayat status ONU", events)
+}
+
+// GetOnuOutages mengembalikan ONU offline yang dikelompokkan per ODP/ODC/OLT.
+// ?only_mass=true hanya mengembalikan node yang memenuhi ambang gangguan massal.
+func GetOnuOutages(c *fiber.Ctx) error {
+	summary, err := services.AnalyzeOnuOutages(services.GetOnuOfflineThresholds())
+	if err != nil {
+		return utils.Error(c, "Gagal menganalisis ONU offline: "+err.Error())
+	}
+
+	if c.Query("only_mass") == "true" {
+		mass := make([]services.OutageGroup, 0)
+		for _, g := range summary.Groups {
+			if g.MassOutage {
+				mass = append(mass, g)
+			}
+		}
+		summary.Groups = mass
+	}
+
+	return utils.Success(c, "Berhasil menganalisis ONU offline", summary)
+}
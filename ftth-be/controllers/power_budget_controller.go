@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	services "akane/be-ftth/Services"
+	"akane/be-ftth/utils"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetPowerBudget menghitung perkiraan RX semua node CLIENT dan membandingkannya dengan RX dari GenieACS.
+// ?status=mismatch|above_expected|ok|no_measurement|no_path untuk memfilter hasil.
+func GetPowerBudget(c *fiber.Ctx) error {
+	report, err := services.AnalyzePowerBudget()
+	if err != nil {
+		return utils.Error(c, "Gagal menghitung loss budget: "+err.Error())
+	}
+
+	if status := c.Query("status"); status != "" {
+		filtered := make([]services.ClientPowerBudget, 0)
+		for _, b := range report.Clients {
+			if b.Status == status {
+				filtered = append(filtered, b)
+			}
+		}
+		report.Clients = filtered
+	}
+	return utils.Success(c, "Berhasil menghitung loss budget", report)
+}
+
+// GetNodePowerBudget mengembalikan rincian redaman jalur satu node CLIENT
+func GetNodePowerBudget(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return utils.Failed(c, "ID node tidak valid")
+	}
+
+	budget, err := services.GetNodePowerBudget(id)
+	if err != nil {
+		return utils.Failed(c, "Gagal menghitung loss budget: "+err.Error())
+	}
+	return utils.Success(c, "Berhasil menghitung loss budget node", budget)
+}
+
+// GetPowerBudgetConfig mengembalikan parameter perhitungan yang sedang dipakai
+func GetPowerBudgetConfig(c *fiber.Ctx) error {
+	return utils.Success(c, "Berhasil mengambil konfigurasi loss budget", services.GetPowerBudgetConfig())
+}
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	services "akane/be-ftth/Services"
+	"akane/be-ftth/config"
+	"akane/be-ftth/models"
+	"akane/be-ftth/utils"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RouterHealthSummary adalah sampel terakhir satu router beserta daftar masalahnya
+type RouterHealthSummary struct {
+	RouterID   string                 `json:"router_id"`
+	RouterName string                 `json:"router_name"`
+	Latest     *models.RouterHealth   `json:"latest"`
+	Issues     []services.HealthIssue `json:"issues"`
+}
+
+// GetRouterHealthHistory mengembalikan time-series health satu router (default 24 jam terakhir)
+func GetRouterHealthHistory(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var router models.Router
+	if err := config.DB.Where("router_id = ? AND is_deleted = 0", id).First(&router).Error; err != nil {
+		return utils.Failed(c, "Router tidak ditemukan")
+	}
+
+	hours, err := strconv.Atoi(c.Query("hours", "24"))
+	if err != nil || hours <= 0 {
+		hours = 24
+	}
+	if hours > 24*30 {
+		hours = 24 * 30
+	}
+
+	var history []models.RouterHealth
+	if err := config.DB.Where("router_id = ? AND recorded_at >= ?", router.RouterID, time.Now().Add(-time.Duration(hours)*time.Hour)).
+		Order("recorded_at ASC").
+		Find(&history).Error; err != nil {
+		return utils.Error(c, "Gagal mengambil riwayat health router")
+	}
+
+	return utils.Success(c, "Berhasil mengambil riwayat health router", history)
+}
+
+// GetRoutersHealthLatest mengembalikan sampel health terakhir untuk semua router aktif
+func GetRoutersHealthLatest(c *fiber.Ctx) error {
+	var routers []models.Router
+	if err := config.DB.Where("is_deleted = 0").Find(&routers).Error; err != nil {
+		return utils.Error(c, "Gagal mengambil data router")
+	}
+
+	thresholds := services.GetHealthThresholds()
+	summaries := make([]RouterHealthSummary, 0, len(routers))
+
+	for _, r := range routers {
+		summary := RouterHealthSummary{
+			RouterID:   r.RouterID.String(),
+			RouterName: r.RouterName,
+			Issues:     []services.HealthIssue{},
+		}
+
+		var latest models.RouterHealth
+		if err := config.DB.Where("router_id = ?", r.RouterID).Order("recorded_at DESC").First(&latest).Error; err == nil {
+			summary.Latest = &latest
+			if issues := services.EvaluateRouterHealth(&latest, thresholds); issues != nil {
+				summary.Issues = issues
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+
+	return utils.Success(c, "Berhasil mengambil health terakhir router", fiber.Map{
+		"thresholds": thresholds,
+		"routers":    summaries,
+	})
+}
+
+// ManualRouterHealthSync menjalankan job health router secara langsung
+func ManualRouterHealthSync(c *fiber.Ctx) error {
+	if _, err := services.RunJobAndWait("router_health", utils.GetUserFromContext(c)); err != nil {
+		return utils.Error(c, "Gagal mengambil health router: "+err.Error())
+	}
+	return utils.Success(c, "Pengambilan health router berhasil", nil)
+}
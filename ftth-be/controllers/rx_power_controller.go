@@ -0,0 +1,29 @@
+package controllers
+
+import (
+	services "akane/be-ftth/Services"
+	"akane/be-ftth/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetRxPowerAnalysis mengembalikan ONU dengan RX lemah/menurun dan ringkasan per FAT.
+// ?only_issues=true hanya mengembalikan ONU yang bermasalah.
+func GetRxPowerAnalysis(c *fiber.Ctx) error {
+	analysis, err := services.AnalyzeRxPower(services.GetRxThresholds())
+	if err != nil {
+		return utils.Error(c, "Gagal menganalisis RX power: "+err.Error())
+	}
+
+	if c.Query("only_issues") == "true" {
+		issues := make([]services.OnuRxStatus, 0)
+		for _, o := range analysis.Onus {
+			if o.BelowFloor || o.Degraded {
+				issues = append(issues, o)
+			}
+		}
+		analysis.Onus = issues
+	}
+
+	return utils.Success(c, "Berhasil menganalisis RX power", analysis)
+}
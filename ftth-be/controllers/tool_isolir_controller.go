@@ -1,7 +1,7 @@
 package controllers
 
 import (
-	"crypto/tls"
+	"context"
 	"fmt"
 	"io"
 	"log"
@@ -11,6 +11,7 @@ import (
 	"sync"
 	"time"
 
+	services "akane/be-ftth/Services"
 	"akane/be-ftth/config"
 	"akane/be-ftth/models"
 	"akane/be-ftth/utils"
@@ -301,30 +302,27 @@ func runIsolirTask(taskID string, targets []string, params IsolirRouterParams) {
 		Client *routeros.Client
 	}
 	var activeConnections []RouterConn
-
-	// Melakukan dial ke router yang terpilih
-	for _, r := range routers {
-		addr := fmt.Sprintf("%s:%d", r.RouterAddress, r.RouterPort)
-		var client *routeros.Client
-		var err error
-
-		if r.RouterRemoteType == "API-SSL" {
-			tlsConfig := &tls.Config{InsecureSkipVerify: true}
-			client, err = routeros.DialTLS(addr, r.RouterUsername, r.RouterPassword, tlsConfig)
-		} else {
-			client, err = routeros.Dial(addr, r.RouterUsername, r.RouterPassword)
-		}
-
+	var connMu sync.Mutex
+
+	// Dial ke router terpilih secara paralel. Timeout hanya berlaku untuk dial (bukan
+	// deadline socket) karena koneksi dipakai sepanjang task berjalan.
+	poolOpts := services.RouterPoolOptions()
+	poolOpts.Timeout = 0
+	services.RunBounded(routers, poolOpts, func(ctx context.Context, r models.Router) error {
+		client, err := services.DialMikrotik(ctx, r.RouterAddress, r.RouterPort, r.RouterUsername, r.RouterPassword, r.RouterRemoteType)
 		if err != nil {
-			log.Printf("[ISOLIR TASK] Gagal terhubung ke router %s (%s): %v", r.RouterName, addr, err)
-			continue
+			log.Printf("[ISOLIR TASK] Gagal terhubung ke router %s (%s:%d): %v", r.RouterName, r.RouterAddress, r.RouterPort, err)
+			return err
 		}
 
+		connMu.Lock()
 		activeConnections = append(activeConnections, RouterConn{
 			Router: &r,
 			Client: client,
 		})
-	}
+		connMu.Unlock()
+		return nil
+	})
 
 	// Tutup seluruh koneksi setelah selesai
 	defer func() {
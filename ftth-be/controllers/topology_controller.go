@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	services "akane/be-ftth/Services"
 	"akane/be-ftth/config"
 	"akane/be-ftth/models"
 	"akane/be-ftth/utils"
@@ -107,10 +108,17 @@ func GetNetworkTopology(c *fiber.Ctx) error {
 		countMap[c.NodeID] = c.Count
 	}
 
+	// ODP yang sudah punya record port memakai jumlah port terpakai, sisanya tetap dari jumlah kabel
+	portCounts, _ := services.ODPUsedPortCounts()
+
 	// Apply ke nodes (memory only, tidak save ke DB)
 	for i := range nodes {
 		if nodes[i].Type == models.TypeODP && nodes[i].ODPDetail != nil {
-			nodes[i].ODPDetail.UsedPorts = countMap[nodes[i].NodeID]
+			if used, ok := portCounts[nodes[i].NodeID]; ok {
+				nodes[i].ODPDetail.UsedPorts = used
+			} else {
+				nodes[i].ODPDetail.UsedPorts = countMap[nodes[i].NodeID]
+			}
 		}
 	}
 
@@ -145,12 +153,15 @@ type NodeTableRow struct {
 }
 
 type CableTableRow struct {
-	CableID      int     `json:"cable_id"`
-	SourceName   string  `json:"source_name"`
-	TargetName   string  `json:"target_name"`
-	CableType    string  `json:"cable_type"`
-	Description  string  `json:"description"`
-	LengthMeter  float64 `json:"length_meter"`
+	CableID             int      `json:"cable_id"`
+	SourceName          string   `json:"source_name"`
+	TargetName          string   `json:"target_name"`
+	CableType           string   `json:"cable_type"`
+	Description         string   `json:"description"`
+	LengthMeter         float64  `json:"length_meter"`
+	ManualLengthMeter   *float64 `json:"manual_length_meter"`
+	ComputedLengthMeter float64  `json:"computed_length_meter"`
+	OltPonPort          string   `json:"olt_pon_port"`
 }
 
 func GetTopologyTable(c *fiber.Ctx) error {
@@ -182,6 +193,7 @@ func GetTopologyTable(c *fiber.Ctx) error {
 	for _, cnt := range counts {
 		countMap[cnt.NodeID] = cnt.Count
 	}
+	portCounts, _ := services.ODPUsedPortCounts()
 
 	nodeRows := make([]NodeTableRow, 0, len(nodes))
 	for _, n := range nodes {
@@ -197,7 +209,11 @@ func GetTopologyTable(c *fiber.Ctx) error {
 		}
 		if n.ODPDetail != nil {
 			row.TotalPorts = n.ODPDetail.TotalPorts
-			row.UsedPorts = countMap[n.NodeID]
+			if used, ok := portCounts[n.NodeID]; ok {
+				row.UsedPorts = used
+			} else {
+				row.UsedPorts = countMap[n.NodeID]
+			}
 		}
 		if n.OLTDetail != nil {
 			row.Brand = n.OLTDetail.Brand
@@ -226,12 +242,15 @@ func GetTopologyTable(c *fiber.Ctx) error {
 	cableRows := make([]CableTableRow, 0, len(cables))
 	for _, cab := range cables {
 		cableRows = append(cableRows, CableTableRow{
-			CableID:     cab.CableID,
-			SourceName:  cab.SourceNode.Name,
-			TargetName:  cab.TargetNode.Name,
-			CableType:   cab.CableType,
-			Description: cab.Description,
-			LengthMeter: cab.LengthMeter,
+			CableID:             cab.CableID,
+			SourceName:          cab.SourceNode.Name,
+			TargetName:          cab.TargetNode.Name,
+			CableType:           cab.CableType,
+			Description:         cab.Description,
+			LengthMeter:         cab.LengthMeter,
+			ManualLengthMeter:   cab.ManualLengthMeter,
+			ComputedLengthMeter: cab.ComputedLengthMeter,
+			OltPonPort:          cab.OltPonPort,
 		})
 	}
 
@@ -252,14 +271,16 @@ func GetTopologyTable(c *fiber.Ctx) error {
 func AddNetworkNode(c *fiber.Ctx) error {
 	type AddNodeRequest struct {
 		models.NetworkNode
-		TotalPorts     int     `json:"total_ports"`
-		Brand          string  `json:"brand"`
-		UplinkType     string  `json:"uplink_type"`
-		IPAddress      string  `json:"ip_address"`
-		Capacity       int     `json:"capacity"`
-		SubscriberID   string  `json:"subscriber_id"`
-		PacketName     string  `json:"packet_name"`
-		LinkedRouterID *string `json:"linked_router_id"`
+		TotalPorts     int      `json:"total_ports"`
+		Brand          string   `json:"brand"`
+		UplinkType     string   `json:"uplink_type"`
+		IPAddress      string   `json:"ip_address"`
+		Capacity       int      `json:"capacity"`
+		SubscriberID   string   `json:"subscriber_id"`
+		PacketName     string   `json:"packet_name"`
+		LinkedRouterID *string  `json:"linked_router_id"`
+		SplitterRatio  int      `json:"splitter_ratio"`
+		TxPowerDbm     *float64 `json:"tx_power_dbm"`
 	}
 
 	var req AddNodeRequest
@@ -304,7 +325,7 @@ func AddNetworkNode(c *fiber.Ctx) error {
 			if req.TotalPorts > 0 {
 				ports = req.TotalPorts
 			}
-			odp := models.ODP{NodeID: node.NodeID, TotalPorts: ports, UsedPorts: 0}
+			odp := models.ODP{NodeID: node.NodeID, TotalPorts: ports, UsedPorts: 0, SplitterRatio: req.SplitterRatio}
 			if err := tx.Create(&odp).Error; err != nil {
 				return err
 			}
@@ -315,6 +336,7 @@ func AddNetworkNode(c *fiber.Ctx) error {
 				Brand:      req.Brand,
 				UplinkType: req.UplinkType,
 				IPAddress:  req.IPAddress,
+				TxPowerDbm: req.TxPowerDbm,
 			}
 			if err := tx.Create(&olt).Error; err != nil {
 				return err
@@ -325,7 +347,7 @@ func AddNetworkNode(c *fiber.Ctx) error {
 			if req.Capacity > 0 {
 				capacity = req.Capacity
 			}
-			odc := models.ODC{NodeID: node.NodeID, Capacity: capacity}
+			odc := models.ODC{NodeID: node.NodeID, Capacity: capacity, SplitterRatio: req.SplitterRatio}
 			if err := tx.Create(&odc).Error; err != nil {
 				return err
 			}
@@ -433,7 +455,14 @@ func UpdateNetworkNode(c *fiber.Ctx) error {
 	node.Longitude = input.Longitude
 	node.Status = input.Status
 
-	if err := config.DB.Save(&node).Error; err != nil {
+	// Kabel tanpa jalur digambar lurus antar node, jadi panjangnya ikut berubah saat node dipindah
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&node).Error; err != nil {
+			return err
+		}
+		return services.RefreshNodeCableLengths(tx, node.NodeID)
+	})
+	if err != nil {
 		return utils.Failed(c, "Gagal update node")
 	}
 	return utils.Success(c, "Node updated", node)
@@ -499,6 +528,19 @@ func AddNetworkCable(c *fiber.Ctx) error {
 	if cable.SourceNodeID == 0 || cable.TargetNodeID == 0 {
 		return utils.Failed(c, "Source dan Target node wajib diisi")
 	}
+	if _, err := services.ParseCableCoordinates(cable.Coordinates); err != nil {
+		return utils.Failed(c, err.Error())
+	}
+
+	// Panjang yang diketik dianggap hasil ukur manual, panjang efektif dihitung ulang dari jalur
+	cable.ManualLengthMeter = nil
+	if cable.LengthMeter > 0 {
+		manual := cable.LengthMeter
+		cable.ManualLengthMeter = &manual
+	}
+	if err := services.RefreshCableLength(&cable); err != nil {
+		return utils.Failed(c, err.Error())
+	}
 
 	if err := config.DB.Create(&cable).Error; err != nil {
 		return utils.Failed(c, err.Error())
@@ -531,9 +573,10 @@ func DeleteNetworkCable(c *fiber.Ctx) error {
 func UpdateCablePath(c *fiber.Ctx) error {
 	id := c.Params("id")
 
+	// length_meter dari client lama diabaikan, panjang manual hanya dari manual_length_meter
 	type UpdatePathRequest struct {
-		Coordinates string  `json:"coordinates"`
-		Length      float64 `json:"length_meter"`
+		Coordinates       string   `json:"coordinates"`
+		ManualLengthMeter *float64 `json:"manual_length_meter"` // nil = tidak diubah, 0 = hapus panjang manual
 	}
 
 	var req UpdatePathRequest
@@ -546,9 +589,24 @@ func UpdateCablePath(c *fiber.Ctx) error {
 		return utils.Failed(c, "Kabel tidak ditemukan")
 	}
 
+	if _, err := services.ParseCableCoordinates(req.Coordinates); err != nil {
+		return utils.Failed(c, err.Error())
+	}
+
 	cable.Coordinates = req.Coordinates
-	cable.LengthMeter = req.Length
-	config.DB.Save(&cable)
+	services.PreserveLegacyCableLength(&cable)
+	if req.ManualLengthMeter != nil {
+		cable.ManualLengthMeter = nil
+		if *req.ManualLengthMeter > 0 {
+			cable.ManualLengthMeter = req.ManualLengthMeter
+		}
+	}
+	if err := services.RefreshCableLength(&cable); err != nil {
+		return utils.Failed(c, err.Error())
+	}
+	if err := config.DB.Save(&cable).Error; err != nil {
+		return utils.Failed(c, "Gagal menyimpan jalur kabel")
+	}
 
 	return utils.Success(c, "Jalur kabel berhasil disimpan", cable)
 }
@@ -561,8 +619,10 @@ func UpdateCableDetails(c *fiber.Ctx) error {
 	id := c.Params("id")
 
 	type UpdateDetailsReq struct {
-		CableType   string `json:"cable_type"`
-		Description string `json:"description"`
+		CableType         string   `json:"cable_type"`
+		Description       string   `json:"description"`
+		ManualLengthMeter *float64 `json:"manual_length_meter"` // nil = tidak diubah, 0 = hapus panjang manual
+		OltPonPort        *string  `json:"olt_pon_port"`        // nil = tidak diubah
 	}
 
 	var req UpdateDetailsReq
@@ -577,6 +637,21 @@ func UpdateCableDetails(c *fiber.Ctx) error {
 
 	cable.CableType = req.CableType
 	cable.Description = req.Description
+	if req.OltPonPort != nil {
+		cable.OltPonPort = strings.TrimSpace(*req.OltPonPort)
+	}
+	backfill := services.PreserveLegacyCableLength(&cable)
+	if req.ManualLengthMeter != nil {
+		cable.ManualLengthMeter = nil
+		if *req.ManualLengthMeter > 0 {
+			cable.ManualLengthMeter = req.ManualLengthMeter
+		}
+	}
+	if backfill || req.ManualLengthMeter != nil {
+		if err := services.RefreshCableLength(&cable); err != nil {
+			return utils.Failed(c, err.Error())
+		}
+	}
 	
 	if err := config.DB.Save(&cable).Error; err != nil {
 		return utils.Failed(c, "Gagal mengupdate kabel")
@@ -593,18 +668,20 @@ func UpdateNodeDetails(c *fiber.Ctx) error {
 	id := c.Params("id")
 
 	type UpdateDetailReq struct {
-		Type           string  `json:"type"`
-		TotalPorts     int     `json:"total_ports"`
-		UsedPorts      int     `json:"used_ports"`
-		Brand          string  `json:"brand"`
-		UplinkType     string  `json:"uplink_type"`
-		IPAddress      string  `json:"ip_address"`
-		Capacity       int     `json:"capacity"`
-		SubscriberID   string  `json:"subscriber_id"`
-		PacketName     string  `json:"packet_name"`
-		OnuSN          string  `json:"onu_sn"`
-		PppoeUsername  string  `json:"pppoe_username"`
-		LinkedRouterID *string `json:"linked_router_id"`
+		Type           string   `json:"type"`
+		TotalPorts     int      `json:"total_ports"`
+		UsedPorts      int      `json:"used_ports"`
+		Brand          string   `json:"brand"`
+		UplinkType     string   `json:"uplink_type"`
+		IPAddress      string   `json:"ip_address"`
+		Capacity       int      `json:"capacity"`
+		SubscriberID   string   `json:"subscriber_id"`
+		PacketName     string   `json:"packet_name"`
+		OnuSN          string   `json:"onu_sn"`
+		PppoeUsername  string   `json:"pppoe_username"`
+		LinkedRouterID *string  `json:"linked_router_id"`
+		SplitterRatio  *int     `json:"splitter_ratio"` // nil = tidak diubah
+		TxPowerDbm     *float64 `json:"tx_power_dbm"`   // nil = tidak diubah
 	}
 
 	var req UpdateDetailReq
@@ -625,9 +702,15 @@ func UpdateNodeDetails(c *fiber.Ctx) error {
 			odp.NodeID = node.NodeID
 			odp.TotalPorts = req.TotalPorts
 			odp.UsedPorts = req.UsedPorts
+			if req.SplitterRatio != nil {
+				odp.SplitterRatio = *req.SplitterRatio
+			}
 			if err := tx.Save(&odp).Error; err != nil {
 				return err
 			}
+			if err := services.SyncODPPortRows(tx, odp); err != nil {
+				return err
+			}
 		case "OLT":
 			var olt models.OLT
 			tx.Where("node_id = ?", node.NodeID).First(&olt)
@@ -635,6 +718,9 @@ func UpdateNodeDetails(c *fiber.Ctx) error {
 			olt.Brand = req.Brand
 			olt.UplinkType = req.UplinkType
 			olt.IPAddress = req.IPAddress
+			if req.TxPowerDbm != nil {
+				olt.TxPowerDbm = req.TxPowerDbm
+			}
 			if err := tx.Save(&olt).Error; err != nil {
 				return err
 			}
@@ -643,6 +729,9 @@ func UpdateNodeDetails(c *fiber.Ctx) error {
 			tx.Where("node_id = ?", node.NodeID).First(&odc)
 			odc.NodeID = node.NodeID
 			odc.Capacity = req.Capacity
+			if req.SplitterRatio != nil {
+				odc.SplitterRatio = *req.SplitterRatio
+			}
 			if err := tx.Save(&odc).Error; err != nil {
 				return err
 			}
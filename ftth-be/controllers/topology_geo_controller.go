@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	services "akane/be-ftth/Services"
+	"akane/be-ftth/utils"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ImportTopologyFile mengimpor node & kabel dari file KML/KMZ/GeoJSON (multipart field "file").
+// Field "options" berisi JSON GeoImportOptions. ?preview=true hanya menampilkan rencana tanpa menyimpan.
+func ImportTopologyFile(c *fiber.Ctx) error {
+	file, err := c.FormFile("file")
+	if err != nil {
+		return utils.Failed(c, "Tidak ada file yang dipilih.")
+	}
+
+	var opts services.GeoImportOptions
+	if raw := c.FormValue("options"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &opts); err != nil {
+			return utils.Failed(c, "Format options tidak valid: "+err.Error())
+		}
+	}
+	preview := c.QueryBool("preview") || c.FormValue("preview") == "true"
+
+	src, err := file.Open()
+	if err != nil {
+		return utils.Failed(c, "Gagal membaca file unggahan.")
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return utils.Failed(c, "Gagal mengolah file unggahan.")
+	}
+
+	result, err := services.ImportTopologyFile(file.Filename, data, opts, preview)
+	if err != nil {
+		return utils.Failed(c, "Gagal mengimpor peta: "+err.Error())
+	}
+	if preview {
+		return utils.Success(c, "Preview impor peta", result)
+	}
+
+	pelaku := utils.GetUserFromContext(c)
+	utils.CreateLog(pelaku, "TOPOLOGY", "CREATE", fmt.Sprintf("Impor peta %s: %d node dan %d kabel dibuat",
+		file.Filename, result.Summary["node_"+services.GeoActionCreate], result.Summary["cable_"+services.GeoActionCreate]))
+	return utils.Success(c, "Impor peta berhasil", result)
+}
+
+// ExportTopologyGeoJSON mengunduh seluruh topologi sebagai file GeoJSON
+func ExportTopologyGeoJSON(c *fiber.Ctx) error {
+	data, err := services.ExportTopologyGeoJSON()
+	if err != nil {
+		return utils.Error(c, "Gagal mengekspor topologi: "+err.Error())
+	}
+	c.Set("Content-Type", "application/geo+json")
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=topologi_ftth_%s.geojson", time.Now().Format("20060102")))
+	return c.Send(data)
+}
+
+// ExportTopologyKML mengunduh seluruh topologi sebagai file KML (bisa dibuka di Google Earth)
+func ExportTopologyKML(c *fiber.Ctx) error {
+	data, err := services.ExportTopologyKML()
+	if err != nil {
+		return utils.Error(c, "Gagal mengekspor topologi: "+err.Error())
+	}
+	c.Set("Content-Type", "application/vnd.google-earth.kml+xml")
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=topologi_ftth_%s.kml", time.Now().Format("20060102")))
+	return c.Send(data)
+}
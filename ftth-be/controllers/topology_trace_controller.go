@@ -0,0 +1,27 @@
+package controllers
+
+import (
+	services "akane/be-ftth/Services"
+	"akane/be-ftth/utils"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ==========================================
+// TRACE JALUR KE HULU
+// ==========================================
+
+// TraceNetworkNode menelusuri kabel dari node (biasanya CLIENT/ODP) sampai ke OLT & router
+func TraceNetworkNode(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return utils.Failed(c, "ID node tidak valid")
+	}
+
+	result, err := services.TraceNodeUpstream(id)
+	if err != nil {
+		return utils.Failed(c, "Gagal trace jalur: "+err.Error())
+	}
+	return utils.Success(c, "Berhasil trace jalur node", result)
+}
@@ -0,0 +1,29 @@
+package controllers
+
+import (
+	services "akane/be-ftth/Services"
+	"akane/be-ftth/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetTopologyValidation menjalankan validasi topologi (tipe kabel, siklus, kapasitas ODP, pelanggan terhapus, node yatim).
+// ?severity=error|warning dan ?rule= untuk memfilter daftar pelanggaran; ringkasan tetap dihitung dari semua pelanggaran.
+func GetTopologyValidation(c *fiber.Ctx) error {
+	report, err := services.ValidateTopology()
+	if err != nil {
+		return utils.Error(c, "Gagal memvalidasi topologi: "+err.Error())
+	}
+
+	severity, rule := c.Query("severity"), c.Query("rule")
+	if severity != "" || rule != "" {
+		filtered := make([]services.TopologyViolation, 0)
+		for _, v := range report.Violations {
+			if (severity == "" || v.Severity == severity) && (rule == "" || v.Rule == rule) {
+				filtered = append(filtered, v)
+			}
+		}
+		report.Violations = filtered
+	}
+	return utils.Success(c, "Berhasil memvalidasi topologi", report)
+}
@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	services "akane/be-ftth/Services"
+	"akane/be-ftth/genieacs"
+	"akane/be-ftth/utils"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// resolveParameterDevice mengambil device dari deviceId atau dari binding pelanggan clientId
+func resolveParameterDevice(deviceID, clientID string) (string, error) {
+	if deviceID != "" {
+		return deviceID, nil
+	}
+	if clientID == "" {
+		return "", fmt.Errorf("deviceId atau clientId wajib diisi")
+	}
+	client, err := boundClient(clientID)
+	if err != nil {
+		return "", err
+	}
+	if client.GenieDeviceID == "" {
+		return "", fmt.Errorf("pelanggan belum terikat ke device GenieACS")
+	}
+	return client.GenieDeviceID, nil
+}
+
+// genieParameterFailure: device/path yang tidak ada adalah kesalahan input, selain itu error GenieACS
+func genieParameterFailure(c *fiber.Ctx, remark string, err error) error {
+	if genieacs.IsNotFound(err) {
+		return utils.Failed(c, remark+": "+err.Error())
+	}
+	return utils.Error(c, remark+": "+err.Error())
+}
+
+// GetDeviceParameters menampilkan pohon parameter TR-069 device di bawah ?path (satu level).
+// ?refresh=true meminta ONU mengirim ulang nilai path tersebut sebelum dibaca.
+func GetDeviceParameters(c *fiber.Ctx) error {
+	deviceID, err := resolveParameterDevice(c.Query("deviceId"), c.Query("clientId"))
+	if err != nil {
+		return utils.Failed(c, err.Error())
+	}
+
+	acs, err := genieacs.NewFromEnv()
+	if err != nil {
+		return utils.Error(c, err.Error())
+	}
+
+	path := c.Query("path")
+	if c.Query("refresh") == "true" {
+		if _, err := acs.RefreshParameters(c.UserContext(), deviceID, path); err != nil {
+			return genieParameterFailure(c, "Gagal refresh parameter", err)
+		}
+	}
+
+	node, err := acs.GetParameterTree(c.UserContext(), deviceID, path)
+	if err != nil {
+		return genieParameterFailure(c, "Gagal mengambil parameter", err)
+	}
+	return utils.Success(c, "Berhasil mengambil parameter device", node)
+}
+
+// SetDeviceParameters mengirim setParameterValues untuk parameter writable yang ada di allowlist
+func SetDeviceParameters(c *fiber.Ctx) error {
+	pelaku := utils.GetUserFromContext(c)
+
+	var payload struct {
+		DeviceID string                    `json:"deviceId"`
+		ClientID int                       `json:"clientId"`
+		Values   []services.ParameterValue `json:"values"`
+	}
+	if err := c.BodyParser(&payload); err != nil {
+		return utils.Failed(c, "Invalid request body")
+	}
+
+	clientID := ""
+	if payload.ClientID != 0 {
+		clientID = strconv.Itoa(payload.ClientID)
+	}
+	deviceID, err := resolveParameterDevice(payload.DeviceID, clientID)
+	if err != nil {
+		return utils.Failed(c, err.Error())
+	}
+
+	result, err := services.SetOnuParameters(deviceID, payload.Values, pelaku)
+	if err != nil {
+		utils.CreateLog(pelaku, "TR069", "ERROR", fmt.Sprintf("Gagal set parameter device %s: %v", deviceID, err))
+		return utils.Failed(c, "Gagal set parameter: "+err.Error())
+	}
+
+	changes := make([]string, 0, len(payload.Values))
+	for _, v := range payload.Values {
+		changes = append(changes, fmt.Sprintf("%s=%s", v.Path, services.MaskParameterValue(v.Path, v.Value)))
+	}
+	utils.CreateLog(pelaku, "TR069", "UPDATE", fmt.Sprintf("Set parameter device %s: %s", deviceID, strings.Join(changes, ", ")))
+
+	msg := "Parameter dikirim, menunggu ONU inform"
+	if result.Applied {
+		msg = "Parameter berhasil diterapkan"
+	}
+	return utils.Success(c, msg, result)
+}
+
+// GetParameterAllowlist mengembalikan pola parameter yang boleh diubah
+func GetParameterAllowlist(c *fiber.Ctx) error {
+	return utils.Success(c, "Berhasil mengambil allowlist parameter", services.Tr069WriteAllowlist())
+}
@@ -0,0 +1,129 @@
+// Package genieacs adalah client untuk Northbound Interface (NBI) GenieACS.
+package genieacs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultTimeout = 15 * time.Second
+
+// Config berisi alamat NBI dan kredensial opsional (jika NBI dipasang di belakang basic auth)
+type Config struct {
+	BaseURL  string
+	Username string
+	Password string
+	Timeout  time.Duration // Timeout per request HTTP (0 = default 15 detik)
+}
+
+// ConfigFromEnv membaca GENIE_ACS_URL, GENIE_ACS_USERNAME, GENIE_ACS_PASSWORD dan GENIE_ACS_TIMEOUT_SECONDS
+func ConfigFromEnv() Config {
+	cfg := Config{
+		BaseURL:  os.Getenv("GENIE_ACS_URL"),
+		Username: os.Getenv("GENIE_ACS_USERNAME"),
+		Password: os.Getenv("GENIE_ACS_PASSWORD"),
+	}
+	if v, err := strconv.Atoi(os.Getenv("GENIE_ACS_TIMEOUT_SECONDS")); err == nil && v > 0 {
+		cfg.Timeout = time.Duration(v) * time.Second
+	}
+	return cfg
+}
+
+// Client memanggil NBI GenieACS. Aman dipakai bersamaan dari banyak goroutine.
+type Client struct {
+	cfg        Config
+	httpClient *http.Client
+	// uploadClient tanpa timeout global; durasi upload file besar dibatasi lewat ctx
+	uploadClient *http.Client
+}
+
+// New membuat client baru. Mengembalikan ErrNotConfigured jika BaseURL kosong.
+func New(cfg Config) (*Client, error) {
+	cfg.BaseURL = strings.TrimRight(strings.TrimSpace(cfg.BaseURL), "/")
+	if cfg.BaseURL == "" {
+		return nil, ErrNotConfigured
+	}
+	if _, err := url.Parse(cfg.BaseURL); err != nil {
+		return nil, fmt.Errorf("GENIE_ACS_URL tidak valid: %v", err)
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	return &Client{cfg: cfg, httpClient: &http.Client{Timeout: cfg.Timeout}, uploadClient: &http.Client{}}, nil
+}
+
+// NewFromEnv membuat client dari konfigurasi .env
+func NewFromEnv() (*Client, error) {
+	return New(ConfigFromEnv())
+}
+
+// do mengirim request ke NBI. body (jika ada) dikirim sebagai JSON dan respon
+// didecode ke out (jika tidak nil). Status selain 2xx dikembalikan sebagai *APIError.
+func (c *Client) do(ctx context.Context, method, path string, params url.Values, body interface{}, out interface{}) (int, error) {
+	var reader io.Reader
+	headers := map[string]string{}
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return 0, fmt.Errorf("gagal encode request: %v", err)
+		}
+		reader = bytes.NewReader(payload)
+		headers["Content-Type"] = "application/json"
+	}
+	return c.send(ctx, c.httpClient, method, path, params, reader, headers, out)
+}
+
+func (c *Client) send(ctx context.Context, hc *http.Client, method, path string, params url.Values, body io.Reader, headers map[string]string, out interface{}) (int, error) {
+	endpoint := c.cfg.BaseURL + path
+	if len(params) > 0 {
+		endpoint += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return 0, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.cfg.Username != "" {
+		req.SetBasicAuth(c.cfg.Username, c.cfg.Password)
+	}
+
+	resp, err := hc.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("gagal menghubungi GenieACS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, fmt.Errorf("gagal membaca respon GenieACS: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, &APIError{StatusCode: resp.StatusCode, Method: method, Path: path, Body: strings.TrimSpace(string(respBody))}
+	}
+
+	if out != nil && len(respBody) > 0 {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return resp.StatusCode, fmt.Errorf("respon GenieACS tidak valid: %v", err)
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+// devicePath membentuk path /devices/<id> dengan ID yang sudah di-escape
+func devicePath(deviceID string, suffix string) string {
+	return "/devices/" + url.PathEscape(deviceID) + suffix
+}
@@ -0,0 +1,569 @@
+package genieacs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeNBI adalah server NBI palsu yang mencatat request terakhir
+type fakeNBI struct {
+	devices  []map[string]interface{}
+	tasks    []Task
+	faults   []Fault
+	status   int
+	delay    time.Duration
+	lastReq  *http.Request
+	lastBody []byte
+}
+
+func (f *fakeNBI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lastReq = r
+	f.lastBody, _ = io.ReadAll(r.Body)
+	if f.delay > 0 {
+		select {
+		case <-time.After(f.delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if f.status != 0 {
+		w.WriteHeader(f.status)
+		w.Write([]byte("boom"))
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/devices/":
+		json.NewEncoder(w).Encode(f.devices)
+	case r.Method == http.MethodGet && r.URL.Path == "/tasks/":
+		json.NewEncoder(w).Encode(f.tasks)
+	case r.Method == http.MethodGet && r.URL.Path == "/faults/":
+		json.NewEncoder(w).Encode(f.faults)
+	case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/files/"):
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/tasks"):
+		var task map[string]interface{}
+		json.Unmarshal(f.lastBody, &task)
+		task["_id"] = "task-1"
+		if _, ok := r.URL.Query()["connection_request"]; ok {
+			w.WriteHeader(http.StatusOK)
+		} else {
+			w.WriteHeader(http.StatusAccepted)
+		}
+		json.NewEncoder(w).Encode(task)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTestClient(t *testing.T, f *fakeNBI, timeout time.Duration) *Client {
+	t.Helper()
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	c, err := New(Config{BaseURL: srv.URL + "/", Timeout: timeout})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return c
+}
+
+func TestNewRequiresBaseURL(t *testing.T) {
+	if _, err := New(Config{}); !errors.Is(err, ErrNotConfigured) {
+		t.Fatalf("expected ErrNotConfigured, got %v", err)
+	}
+}
+
+func TestQueryEncodeEscapesValues(t *testing.T) {
+	malicious := `x"},{"$where":"1`
+	encoded, err := Or(Eq("_id", malicious), Eq("VirtualParameters.pppoeIP._value", "10.0.0.1")).Encode()
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	var decoded map[string][]map[string]string
+	if err := json.Unmarshal([]byte(encoded), &decoded); err != nil {
+		t.Fatalf("query bukan JSON valid: %v (%s)", err, encoded)
+	}
+	if got := decoded["$or"][0]["_id"]; got != malicious {
+		t.Fatalf("nilai berubah: %q", got)
+	}
+	if len(decoded) != 1 || len(decoded["$or"]) != 2 {
+		t.Fatalf("struktur query berubah: %s", encoded)
+	}
+}
+
+func TestQueryRejectsOperatorFields(t *testing.T) {
+	if _, err := Eq("$where", "1").Encode(); err == nil {
+		t.Fatal("expected error for operator field")
+	}
+	if _, err := And(Eq("a", 1), Query{"$expr": true}).Encode(); err == nil {
+		t.Fatal("expected error for nested operator field")
+	}
+}
+
+func TestFindDeviceByAddress(t *testing.T) {
+	f := &fakeNBI{devices: []map[string]interface{}{{
+		"_id":         "00259E-HG8245-4857544300000001",
+		"_lastInform": "2026-01-01T00:00:00.000Z",
+		"_deviceId":   map[string]interface{}{"_SerialNumber": "", "_Manufacturer": "Huawei", "_ProductClass": "HG8245"},
+		"VirtualParameters": map[string]interface{}{
+			"IPTR069":         map[string]interface{}{"_value": "0.0.0.0"},
+			"pppoeIP":         map[string]interface{}{"_value": "10.10.0.5"},
+			"RXPower":         map[string]interface{}{"_value": -21.5},
+			"getSerialNumber": map[string]interface{}{"_value": "HWTC00000001"},
+			"PonMac":          map[string]interface{}{"_value": ""},
+			"pppoeMac":        map[string]interface{}{"_value": "AA:BB:CC:DD:EE:FF"},
+		},
+	}}}
+	c := newTestClient(t, f, time.Second)
+
+	info, err := c.FindDeviceByAddress(context.Background(), `10.10.0.5"}`)
+	if err != nil {
+		t.Fatalf("FindDeviceByAddress: %v", err)
+	}
+
+	q := f.lastReq.URL.Query()
+	var query map[string][]map[string]string
+	if err := json.Unmarshal([]byte(q.Get("query")), &query); err != nil {
+		t.Fatalf("query tidak bisa didecode server: %v", err)
+	}
+	if len(query["$or"]) != 5 || query["$or"][0]["_deviceId._SerialNumber"] != `10.10.0.5"}` {
+		t.Fatalf("query tidak sesuai: %v", query)
+	}
+	if !strings.Contains(q.Get("projection"), "VirtualParameters.RXPower._value") {
+		t.Fatalf("projection tidak dikirim: %s", q.Get("projection"))
+	}
+
+	if info.IPAddress != "10.10.0.5" || info.DeviceSN != "HWTC00000001" || info.MACAddress != "AA:BB:CC:DD:EE:FF" {
+		t.Fatalf("fallback field salah: %+v", info)
+	}
+	if info.RXPower != "-21.5" || info.SSID != "Unknown/Hidden" || info.Manufaktur != "Huawei-HG8245" {
+		t.Fatalf("field salah: %+v", info)
+	}
+}
+
+func TestFindDeviceByAddressNotFound(t *testing.T) {
+	c := newTestClient(t, &fakeNBI{}, time.Second)
+	_, err := c.FindDeviceByAddress(context.Background(), "10.0.0.1")
+	if !errors.Is(err, ErrDeviceNotFound) || !IsNotFound(err) {
+		t.Fatalf("expected ErrDeviceNotFound, got %v", err)
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	c := newTestClient(t, &fakeNBI{status: http.StatusInternalServerError}, time.Second)
+	_, err := c.ListDevices(context.Background())
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *APIError, got %T %v", err, err)
+	}
+	if apiErr.StatusCode != http.StatusInternalServerError || apiErr.Body != "boom" {
+		t.Fatalf("APIError salah: %+v", apiErr)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	c := newTestClient(t, &fakeNBI{delay: time.Second}, 50*time.Millisecond)
+	start := time.Now()
+	if _, err := c.ListDevices(context.Background()); err == nil {
+		t.Fatal("expected timeout error")
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Fatal("timeout client tidak dihormati")
+	}
+}
+
+func TestContextCancel(t *testing.T) {
+	c := newTestClient(t, &fakeNBI{delay: time.Second}, 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := c.ListDevices(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context deadline, got %v", err)
+	}
+}
+
+func TestGetConnectedHosts(t *testing.T) {
+	f := &fakeNBI{devices: []map[string]interface{}{{
+		"_id": "dev/1",
+		"InternetGatewayDevice": map[string]interface{}{"LANDevice": map[string]interface{}{"1": map[string]interface{}{
+			"Hosts": map[string]interface{}{"Host": map[string]interface{}{
+				"_object":   true,
+				"_writable": false,
+				"2":         map[string]interface{}{"MACAddress": map[string]interface{}{"_value": "22:22"}, "Active": map[string]interface{}{"_value": true}},
+				"1":         map[string]interface{}{"MACAddress": map[string]interface{}{"_value": "11:11"}, "HostName": map[string]interface{}{"_value": "hp"}},
+				"3":         map[string]interface{}{"MACAddress": map[string]interface{}{"_value": ""}},
+			}},
+		}}},
+	}}}
+	c := newTestClient(t, f, time.Second)
+
+	hosts, err := c.GetConnectedHosts(context.Background(), "dev/1")
+	if err != nil {
+		t.Fatalf("GetConnectedHosts: %v", err)
+	}
+	if len(hosts) != 2 || hosts[0].MACAddress != "11:11" || hosts[0].HostName != "hp" || hosts[1].Active != "true" {
+		t.Fatalf("hosts salah: %+v", hosts)
+	}
+}
+
+func TestListConnectedHostsClassifiesInterfaces(t *testing.T) {
+	host := func(mac, l1 string, active bool) map[string]interface{} {
+		return map[string]interface{}{
+			"MACAddress":      map[string]interface{}{"_value": mac},
+			"Layer1Interface": map[string]interface{}{"_value": l1},
+			"Active":          map[string]interface{}{"_value": active},
+		}
+	}
+	f := &fakeNBI{devices: []map[string]interface{}{{
+		"_id": "dev-1",
+		"InternetGatewayDevice": map[string]interface{}{"LANDevice": map[string]interface{}{"1": map[string]interface{}{
+			"Hosts": map[string]interface{}{"Host": map[string]interface{}{
+				"1": host("aa", "InternetGatewayDevice.LANDevice.1.WLANConfiguration.1", true),
+				"2": host("bb", "InternetGatewayDevice.LANDevice.1.LANEthernetInterfaceConfig.2", true),
+				"3": host("cc", "", false),
+			}},
+		}}},
+	}, {"_id": "dev-2"}}}
+	c := newTestClient(t, f, time.Second)
+
+	all, err := c.ListConnectedHosts(context.Background())
+	if err != nil {
+		t.Fatalf("ListConnectedHosts: %v", err)
+	}
+	if len(all) != 2 || len(all["dev-2"]) != 0 {
+		t.Fatalf("unexpected result %+v", all)
+	}
+	hosts := all["dev-1"]
+	if len(hosts) != 3 || hosts[0].InterfaceType() != HostInterfaceWifi || hosts[1].InterfaceType() != HostInterfaceLAN ||
+		hosts[2].InterfaceType() != HostInterfaceUnknown || hosts[2].IsActive() {
+		t.Fatalf("unexpected hosts %+v", hosts)
+	}
+}
+
+func TestUpdateWifiPushesTask(t *testing.T) {
+	f := &fakeNBI{}
+	c := newTestClient(t, f, time.Second)
+
+	res, err := c.UpdateWifi(context.Background(), "00259E-HG8245-48575443%2F1", "Rumah", "rahasia123", 2)
+	if err != nil {
+		t.Fatalf("UpdateWifi: %v", err)
+	}
+	if !res.Applied || res.Task.ID != "task-1" {
+		t.Fatalf("hasil task salah: %+v", res)
+	}
+	if got := f.lastReq.URL.EscapedPath(); got != "/devices/00259E-HG8245-48575443%252F1/tasks" {
+		t.Fatalf("device id tidak di-escape: %s", got)
+	}
+
+	var task Task
+	if err := json.Unmarshal(f.lastBody, &task); err != nil {
+		t.Fatalf("body task tidak valid: %v", err)
+	}
+	if task.Name != "setParameterValues" || len(task.ParameterValues) != 2 ||
+		task.ParameterValues[0][0] != "InternetGatewayDevice.LANDevice.1.WLANConfiguration.2.SSID" {
+		t.Fatalf("task salah: %+v", task)
+	}
+
+	if _, err := c.UpdateWifi(context.Background(), "dev", "", "", 1); err == nil {
+		t.Fatal("expected error when SSID and password empty")
+	}
+}
+
+func TestTaskStatus(t *testing.T) {
+	f := &fakeNBI{}
+	c := newTestClient(t, f, time.Second)
+	ctx := context.Background()
+
+	if status, _, err := c.TaskStatus(ctx, "dev", "t1"); err != nil || status != TaskDone {
+		t.Fatalf("expected done, got %s %v", status, err)
+	}
+
+	f.tasks = []Task{{ID: "t1", Name: "reboot"}}
+	if status, _, err := c.TaskStatus(ctx, "dev", "t1"); err != nil || status != TaskPending {
+		t.Fatalf("expected pending, got %s %v", status, err)
+	}
+
+	f.faults = []Fault{{ID: "dev:task_t1", Channel: "task_t1", Code: "cwmp.9002", Message: "Internal error"}}
+	status, fault, err := c.TaskStatus(ctx, "dev", "t1")
+	if err != nil || status != TaskFault || fault == nil || fault.Code != "cwmp.9002" {
+		t.Fatalf("expected fault, got %s %+v %v", status, fault, err)
+	}
+
+	var q map[string][]map[string]string
+	json.Unmarshal([]byte(f.lastReq.URL.Query().Get("query")), &q)
+	if q["$and"][1]["channel"] != "task_t1" {
+		t.Fatalf("query fault salah: %v", q)
+	}
+}
+
+func TestUploadFileSendsMetadata(t *testing.T) {
+	f := &fakeNBI{}
+	c := newTestClient(t, f, time.Second)
+
+	err := c.UploadFile(context.Background(), "HG8245 V5.bin", strings.NewReader("firmware"), FileMeta{OUI: "00259E", ProductClass: "HG8245", Version: "V5"})
+	if err != nil {
+		t.Fatalf("UploadFile: %v", err)
+	}
+	if got := f.lastReq.URL.EscapedPath(); got != "/files/HG8245%20V5.bin" {
+		t.Fatalf("nama file tidak di-escape: %s", got)
+	}
+	h := f.lastReq.Header
+	if h.Get("fileType") != FileTypeFirmware || h.Get("oui") != "00259E" || h.Get("productClass") != "HG8245" || h.Get("version") != "V5" {
+		t.Fatalf("header metadata salah: %v", h)
+	}
+	if string(f.lastBody) != "firmware" {
+		t.Fatalf("isi file salah: %q", f.lastBody)
+	}
+}
+
+func parameterDevice() map[string]interface{} {
+	return map[string]interface{}{
+		"_id": "dev-1",
+		"InternetGatewayDevice": map[string]interface{}{
+			"_object": true,
+			"LANDevice": map[string]interface{}{
+				"_object": true,
+				"1": map[string]interface{}{
+					"_object": true,
+					"WLANConfiguration": map[string]interface{}{
+						"_object": true,
+						"10":      map[string]interface{}{"_object": true, "_writable": true},
+						"2":       map[string]interface{}{"_object": true, "_writable": true},
+						"1": map[string]interface{}{
+							"_object":   true,
+							"_writable": true,
+							"SSID": map[string]interface{}{
+								"_value": "RUMAH", "_type": "xsd:string", "_writable": true, "_timestamp": "2024-01-01T00:00:00.000Z",
+							},
+							"TotalAssociations": map[string]interface{}{
+								"_value": []interface{}{3, "xsd:unsignedInt"}, "_writable": false,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestGetParameterTreeListsChildren(t *testing.T) {
+	f := &fakeNBI{devices: []map[string]interface{}{parameterDevice()}}
+	c := newTestClient(t, f, time.Second)
+
+	node, err := c.GetParameterTree(context.Background(), "dev-1", "InternetGatewayDevice.LANDevice.1.WLANConfiguration.")
+	if err != nil {
+		t.Fatalf("GetParameterTree: %v", err)
+	}
+	if got := f.lastReq.URL.Query().Get("projection"); got != "InternetGatewayDevice.LANDevice.1.WLANConfiguration" {
+		t.Fatalf("unexpected projection %q", got)
+	}
+	if !node.Object || node.ChildCount != 3 {
+		t.Fatalf("expected object with 3 children, got %+v", node)
+	}
+	var names []string
+	for _, ch := range node.Children {
+		names = append(names, ch.Name)
+	}
+	if strings.Join(names, ",") != "1,2,10" {
+		t.Fatalf("children not sorted numerically: %v", names)
+	}
+	if node.Children[0].Path != "InternetGatewayDevice.LANDevice.1.WLANConfiguration.1" || node.Children[0].ChildCount != 2 {
+		t.Fatalf("unexpected first child %+v", node.Children[0])
+	}
+}
+
+func TestGetParameterTreeLeaf(t *testing.T) {
+	f := &fakeNBI{devices: []map[string]interface{}{parameterDevice()}}
+	c := newTestClient(t, f, time.Second)
+
+	node, err := c.GetParameterTree(context.Background(), "dev-1", "InternetGatewayDevice.LANDevice.1.WLANConfiguration.1.TotalAssociations")
+	if err != nil {
+		t.Fatalf("GetParameterTree: %v", err)
+	}
+	if node.Object || node.Writable || node.Type != "xsd:unsignedInt" || node.Value != float64(3) {
+		t.Fatalf("unexpected leaf %+v", node)
+	}
+
+	_, err = c.GetParameterTree(context.Background(), "dev-1", "InternetGatewayDevice.LANDevice.9")
+	if !errors.Is(err, ErrParameterNotFound) || !IsNotFound(err) {
+		t.Fatalf("expected ErrParameterNotFound, got %v", err)
+	}
+}
+
+func TestGetParameterNodes(t *testing.T) {
+	f := &fakeNBI{devices: []map[string]interface{}{parameterDevice()}}
+	c := newTestClient(t, f, time.Second)
+
+	ssid := "InternetGatewayDevice.LANDevice.1.WLANConfiguration.1.SSID"
+	missing := "InternetGatewayDevice.LANDevice.1.WLANConfiguration.1.KeyPassphrase"
+	nodes, err := c.GetParameterNodes(context.Background(), "dev-1", []string{ssid, missing})
+	if err != nil {
+		t.Fatalf("GetParameterNodes: %v", err)
+	}
+	if n, ok := nodes[ssid]; !ok || !n.Writable || n.Value != "RUMAH" || n.Type != "xsd:string" {
+		t.Fatalf("unexpected SSID node %+v", nodes[ssid])
+	}
+	if _, ok := nodes[missing]; ok {
+		t.Fatalf("missing parameter should not be returned")
+	}
+}
+
+func wlanDevice(manufacturer string) map[string]interface{} {
+	inst := func(ssid, standard string, advertise bool) map[string]interface{} {
+		return map[string]interface{}{
+			"_object":                  true,
+			"Enable":                   map[string]interface{}{"_value": true, "_type": "xsd:boolean", "_writable": true},
+			"SSID":                     map[string]interface{}{"_value": ssid, "_type": "xsd:string", "_writable": true},
+			"SSIDAdvertisementEnabled": map[string]interface{}{"_value": advertise, "_type": "xsd:boolean", "_writable": true},
+			"Channel":                  map[string]interface{}{"_value": 6, "_type": "xsd:unsignedInt", "_writable": true},
+			"AutoChannelEnable":        map[string]interface{}{"_value": false, "_type": "xsd:boolean", "_writable": true},
+			"Standard":                 map[string]interface{}{"_value": standard, "_type": "xsd:string"},
+			"BeaconType":               map[string]interface{}{"_value": "11i", "_type": "xsd:string", "_writable": true},
+			"TotalAssociations":        map[string]interface{}{"_value": 2, "_type": "xsd:unsignedInt"},
+		}
+	}
+	return map[string]interface{}{
+		"_id":       "dev-1",
+		"_deviceId": map[string]interface{}{"_Manufacturer": manufacturer, "_ProductClass": "HG8145V5"},
+		"InternetGatewayDevice": map[string]interface{}{
+			"LANDevice": map[string]interface{}{
+				"1": map[string]interface{}{
+					"WLANConfiguration": map[string]interface{}{
+						"1": inst("RUMAH", "b,g,n", true),
+						"5": inst("RUMAH-5G", "n", false),
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestGetWlansDetectsBandAndSettings(t *testing.T) {
+	f := &fakeNBI{devices: []map[string]interface{}{wlanDevice("Huawei Technologies Co., Ltd")}}
+	c := newTestClient(t, f, time.Second)
+
+	wlans, profile, err := c.GetWlans(context.Background(), "dev-1", DefaultWifiProfiles)
+	if err != nil {
+		t.Fatalf("GetWlans: %v", err)
+	}
+	if profile.Name != "Huawei" {
+		t.Fatalf("expected Huawei profile, got %q", profile.Name)
+	}
+	if len(wlans) != 2 {
+		t.Fatalf("expected 2 WLANs, got %d", len(wlans))
+	}
+	w1, w5 := wlans[0], wlans[1]
+	if w1.Band != Band24GHz || !w1.Enabled || w1.Hidden || w1.Channel != "6" || w1.Security != "wpa2" || w1.Associations != 2 {
+		t.Fatalf("unexpected WLAN 1 %+v", w1)
+	}
+	// Index 5 ditandai 5GHz oleh profil Huawei walaupun Standard hanya "n"
+	if w5.Index != 5 || w5.Band != Band5GHz || !w5.Hidden || w5.SSID != "RUMAH-5G" {
+		t.Fatalf("unexpected WLAN 5 %+v", w5)
+	}
+}
+
+func TestUpdateWlanUsesVendorPaths(t *testing.T) {
+	f := &fakeNBI{devices: []map[string]interface{}{wlanDevice("Huawei")}}
+	c := newTestClient(t, f, time.Second)
+
+	pass, hidden, channel := "rahasia123", true, "auto"
+	if _, err := c.UpdateWlan(context.Background(), "dev-1", 5, WlanUpdate{Password: &pass, Hidden: &hidden, Channel: &channel}, DefaultWifiProfiles); err != nil {
+		t.Fatalf("UpdateWlan: %v", err)
+	}
+
+	var task Task
+	if err := json.Unmarshal(f.lastBody, &task); err != nil {
+		t.Fatalf("decode task: %v", err)
+	}
+	base := "InternetGatewayDevice.LANDevice.1.WLANConfiguration.5."
+	want := map[string]interface{}{
+		base + "PreSharedKey.1.PreSharedKey": "rahasia123",
+		base + "SSIDAdvertisementEnabled":    false,
+		base + "AutoChannelEnable":           true,
+	}
+	if len(task.ParameterValues) != len(want) {
+		t.Fatalf("unexpected parameter values %v", task.ParameterValues)
+	}
+	for _, pv := range task.ParameterValues {
+		if v, ok := want[pv[0].(string)]; !ok || v != pv[1] {
+			t.Fatalf("unexpected parameter %v", pv)
+		}
+	}
+
+	short := "123"
+	if _, err := c.UpdateWlan(context.Background(), "dev-1", 1, WlanUpdate{Password: &short}, DefaultWifiProfiles); err == nil {
+		t.Fatalf("expected error for short password")
+	}
+}
+
+func TestUpdateWlanRadioSecurityAndChannel(t *testing.T) {
+	f := &fakeNBI{devices: []map[string]interface{}{wlanDevice("Huawei")}}
+	c := newTestClient(t, f, time.Second)
+
+	// Channel 6 sudah aktif dan AutoChannelEnable false: tidak ada yang perlu dikirim ulang
+	radio, channel, security := false, "6", "wpa-wpa2"
+	if _, err := c.UpdateWlan(context.Background(), "dev-1", 1, WlanUpdate{Enabled: &radio, Channel: &channel, Security: &security}, DefaultWifiProfiles); err != nil {
+		t.Fatalf("UpdateWlan: %v", err)
+	}
+
+	var task Task
+	if err := json.Unmarshal(f.lastBody, &task); err != nil {
+		t.Fatalf("decode task: %v", err)
+	}
+	base := "InternetGatewayDevice.LANDevice.1.WLANConfiguration.1."
+	want := map[string]interface{}{
+		base + "RadioEnabled":              false,
+		base + "BeaconType":                "WPAand11i",
+		base + "WPAEncryptionModes":        "TKIPandAESEncryption",
+		base + "WPAAuthenticationMode":     "PSKAuthentication",
+		base + "IEEE11iEncryptionModes":    "TKIPandAESEncryption",
+		base + "IEEE11iAuthenticationMode": "PSKAuthentication",
+	}
+	if len(task.ParameterValues) != len(want) {
+		t.Fatalf("unexpected parameter values %v", task.ParameterValues)
+	}
+	for _, pv := range task.ParameterValues {
+		if v, ok := want[pv[0].(string)]; !ok || v != pv[1] {
+			t.Fatalf("unexpected parameter %v", pv)
+		}
+	}
+
+	if _, err := c.UpdateWlan(context.Background(), "dev-1", 3, WlanUpdate{Enabled: &radio}, DefaultWifiProfiles); err == nil {
+		t.Fatalf("expected error for missing WLAN index")
+	}
+}
+
+func TestDetectBand(t *testing.T) {
+	huawei := MatchWifiProfile(DefaultWifiProfiles, DeviceID{Manufacturer: "Huawei"})
+	generic := MatchWifiProfile(nil, DeviceID{})
+	cases := []struct {
+		name     string
+		standard string
+		index    int
+		profile  WifiProfile
+		want     string
+	}{
+		{"ax di 2.4GHz", "b,g,n,ax", 1, generic, Band24GHz},
+		{"a/n/ac", "a,n,ac", 1, generic, Band5GHz},
+		{"ac saja", "ac", 1, generic, Band5GHz},
+		{"prefix 802.11", "802.11b, 802.11g", 5, huawei, Band24GHz},
+		{"n saja pakai index profil", "n", 5, huawei, Band5GHz},
+		{"ax saja tanpa index", "ax", 1, huawei, Band24GHz},
+		{"kosong", "", 1, generic, Band24GHz},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := detectBand(map[string]interface{}{}, tc.index, tc.standard, tc.profile); got != tc.want {
+				t.Fatalf("detectBand(%q, %d) = %s, want %s", tc.standard, tc.index, got, tc.want)
+			}
+		})
+	}
+}
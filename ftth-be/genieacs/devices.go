@@ -0,0 +1,410 @@
+package genieacs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Value adalah _value parameter TR-069. GenieACS bisa mengirim string, angka atau boolean,
+// semuanya disimpan sebagai string.
+type Value string
+
+func (v *Value) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		*v = ""
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*v = Value(s)
+		return nil
+	}
+	var any interface{}
+	if err := json.Unmarshal(b, &any); err != nil {
+		return err
+	}
+	*v = Value(fmt.Sprintf("%v", any))
+	return nil
+}
+
+func (v Value) String() string {
+	return string(v)
+}
+
+type Parameter struct {
+	Value Value `json:"_value"`
+}
+
+type Device struct {
+	ID                string                `json:"_id"`
+	LastInform        string                `json:"_lastInform"`
+	VirtualParameters VirtualParameters     `json:"VirtualParameters"`
+	InternetGateway   InternetGatewayDevice `json:"InternetGatewayDevice"`
+	DeviceID          DeviceID              `json:"_deviceId"`
+}
+
+type DeviceID struct {
+	ProductClass string `json:"_ProductClass"`
+	Manufacturer string `json:"_Manufacturer"`
+	SerialNumber string `json:"_SerialNumber"`
+	OUI          string `json:"_OUI"`
+}
+
+type InternetGatewayDevice struct {
+	DeviceInfo IGDDeviceInfo `json:"DeviceInfo"`
+	LANDevice  LANDevice     `json:"LANDevice"`
+	WANDevice  WANDevice     `json:"WANDevice"`
+}
+
+type IGDDeviceInfo struct {
+	SoftwareVersion Parameter `json:"SoftwareVersion"`
+	HardwareVersion Parameter `json:"HardwareVersion"`
+	UpTime          Parameter `json:"UpTime"`
+}
+
+type LANDevice struct {
+	Device1 LANDevice1 `json:"1"`
+}
+
+type LANDevice1 struct {
+	WLANConfiguration WLANConfiguration `json:"WLANConfiguration"`
+	Hosts             Hosts             `json:"Hosts"`
+}
+
+type WLANConfiguration struct {
+	Config1 WLANConfig1 `json:"1"`
+}
+
+type WLANConfig1 struct {
+	SSID Parameter `json:"SSID"`
+}
+
+type WANDevice struct {
+	Device1 WANDevice1 `json:"1"`
+}
+
+type WANDevice1 struct {
+	WANConnectionDevice WANConnectionDevice `json:"WANConnectionDevice"`
+}
+
+type WANConnectionDevice struct {
+	Device1 WANConnection1 `json:"1"`
+}
+
+type WANConnection1 struct {
+	WANIPConnection  WANIPConnection `json:"WANIPConnection"`
+	WANPPPConnection WANIPConnection `json:"WANPPPConnection"`
+}
+
+type WANIPConnection struct {
+	Device1 WANIPConnection1 `json:"1"`
+}
+
+type WANIPConnection1 struct {
+	ExternalIPAddress Parameter `json:"ExternalIPAddress"`
+	MACAddress        Parameter `json:"MACAddress"`
+	DNSServers        Parameter `json:"DNSServers"`
+}
+
+type VirtualParameters struct {
+	IPTR069      Parameter `json:"IPTR069"`
+	RxPower      Parameter `json:"RXPower"`
+	DeviceSN     Parameter `json:"getSerialNumber"`
+	Temp         Parameter `json:"gettemp"`
+	Pon          Parameter `json:"getponmode"`
+	DeviceUptime Parameter `json:"getdeviceuptime"`
+	PonMac       Parameter `json:"PonMac"`
+	PppoeIP      Parameter `json:"pppoeIP"`
+	PppoeMac     Parameter `json:"pppoeMac"`
+	PppoeUser    Parameter `json:"pppoeUsername"`
+}
+
+// Hosts menampung InternetGatewayDevice.LANDevice.1.Hosts.Host.{i}. Key metadata
+// GenieACS (_object, _timestamp, _writable) diabaikan.
+type Hosts struct {
+	Host map[string]HostEntry `json:"-"`
+}
+
+type HostEntry struct {
+	MACAddress      Parameter `json:"MACAddress"`
+	IPAddress       Parameter `json:"IPAddress"`
+	HostName        Parameter `json:"HostName"`
+	Active          Parameter `json:"Active"`
+	Layer1Interface Parameter `json:"Layer1Interface"`
+}
+
+func (h *Hosts) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		Host map[string]json.RawMessage `json:"Host"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	h.Host = make(map[string]HostEntry)
+	for key, val := range raw.Host {
+		if _, err := strconv.Atoi(key); err != nil {
+			continue
+		}
+		var entry HostEntry
+		if err := json.Unmarshal(val, &entry); err != nil {
+			continue
+		}
+		h.Host[key] = entry
+	}
+	return nil
+}
+
+// DeviceInfo adalah ringkasan device yang dipakai frontend dan job sinkronisasi
+type DeviceInfo struct {
+	DeviceID   string `json:"deviceId"`
+	LastInform string `json:"lastInform"`
+	SSID       string `json:"ssid"`
+	IPAddress  string `json:"ipAddress"`
+	RXPower    string `json:"rxPower"`
+	DeviceSN   string `json:"deviceSN"`
+	Temp       string `json:"temp"`
+	PonMode    string `json:"ponMode"`
+	MACAddress string `json:"macAddress"`
+	Manufaktur string `json:"manufaktur"`
+	Uptime     string `json:"uptime"`
+	PppoeUser  string `json:"pppoeUsername"`
+}
+
+// HostDevice adalah satu perangkat LAN/WiFi yang terhubung ke ONU
+type HostDevice struct {
+	MACAddress      string `json:"macAddress"`
+	IPAddress       string `json:"ipAddress"`
+	HostName        string `json:"hostName"`
+	Active          string `json:"active"`
+	Layer1Interface string `json:"layer1Interface"`
+}
+
+// DeviceInfoProjection adalah parameter minimal untuk membentuk DeviceInfo
+var DeviceInfoProjection = Projection{
+	"_id",
+	"_lastInform",
+	"InternetGatewayDevice.LANDevice.1.WLANConfiguration.1.SSID._value",
+	"VirtualParameters.IPTR069._value",
+	"VirtualParameters.pppoeIP._value",
+	"VirtualParameters.RXPower._value",
+	"_deviceId._SerialNumber",
+	"VirtualParameters.gettemp._value",
+	"VirtualParameters.getponmode._value",
+	"VirtualParameters.PonMac._value",
+	"VirtualParameters.pppoeMac._value",
+	"_deviceId._Manufacturer",
+	"_deviceId._ProductClass",
+	"VirtualParameters.getdeviceuptime._value",
+	"VirtualParameters.pppoeUsername._value",
+}
+
+// Info membentuk DeviceInfo dengan fallback virtual parameter seperti helper lama
+func (d Device) Info() DeviceInfo {
+	ssid := d.InternetGateway.LANDevice.Device1.WLANConfiguration.Config1.SSID.Value.String()
+	if ssid == "" {
+		ssid = "Unknown/Hidden"
+	}
+
+	// Pick IPAddress from Virtual Parameters (Stabilized)
+	ip := d.VirtualParameters.IPTR069.Value.String()
+	if ip == "" || ip == "0.0.0.0" {
+		ip = d.VirtualParameters.PppoeIP.Value.String()
+	}
+
+	sn := d.DeviceID.SerialNumber
+	if sn == "" {
+		sn = d.VirtualParameters.DeviceSN.Value.String()
+	}
+
+	// Pick MACAddress from Virtual Parameters (Stabilized)
+	mac := d.VirtualParameters.PonMac.Value.String()
+	if mac == "" {
+		mac = d.VirtualParameters.PppoeMac.Value.String()
+	}
+
+	return DeviceInfo{
+		DeviceID:   d.ID,
+		LastInform: d.LastInform,
+		SSID:       ssid,
+		IPAddress:  ip,
+		RXPower:    d.VirtualParameters.RxPower.Value.String(),
+		DeviceSN:   sn,
+		Temp:       d.VirtualParameters.Temp.Value.String(),
+		PonMode:    d.VirtualParameters.Pon.Value.String(),
+		MACAddress: mac,
+		Manufaktur: d.DeviceID.Manufacturer + "-" + d.DeviceID.ProductClass,
+		Uptime:     d.VirtualParameters.DeviceUptime.Value.String(),
+		PppoeUser:  d.VirtualParameters.PppoeUser.Value.String(),
+	}
+}
+
+// FindDevices menjalankan GET /devices dengan query dan projection opsional (nil = semua)
+func (c *Client) FindDevices(ctx context.Context, q Query, projection Projection) ([]Device, error) {
+	var devices []Device
+	if err := c.findInto(ctx, q, projection, &devices); err != nil {
+		return nil, err
+	}
+	return devices, nil
+}
+
+// FindRawDevices sama dengan FindDevices tetapi mengembalikan dokumen mentah
+// (dipakai saat path parameter bervariasi antar model ONU)
+func (c *Client) FindRawDevices(ctx context.Context, q Query, projection Projection) ([]map[string]interface{}, error) {
+	var devices []map[string]interface{}
+	if err := c.findInto(ctx, q, projection, &devices); err != nil {
+		return nil, err
+	}
+	return devices, nil
+}
+
+func (c *Client) findInto(ctx context.Context, q Query, projection Projection, out interface{}) error {
+	params := url.Values{}
+	if len(q) > 0 {
+		encoded, err := q.Encode()
+		if err != nil {
+			return err
+		}
+		params.Set("query", encoded)
+	}
+	if len(projection) > 0 {
+		params.Set("projection", projection.String())
+	}
+	_, err := c.do(ctx, http.MethodGet, "/devices/", params, nil, out)
+	return err
+}
+
+// ListDevices mengambil ringkasan semua device
+func (c *Client) ListDevices(ctx context.Context) ([]DeviceInfo, error) {
+	devices, err := c.FindDevices(ctx, nil, DeviceInfoProjection)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]DeviceInfo, 0, len(devices))
+	for _, d := range devices {
+		results = append(results, d.Info())
+	}
+	return results, nil
+}
+
+// GetDevice mengambil satu device berdasarkan _id
+func (c *Client) GetDevice(ctx context.Context, deviceID string, projection Projection) (*Device, error) {
+	devices, err := c.FindDevices(ctx, Eq("_id", deviceID), projection)
+	if err != nil {
+		return nil, err
+	}
+	if len(devices) == 0 {
+		return nil, ErrDeviceNotFound
+	}
+	return &devices[0], nil
+}
+
+// FindDeviceByAddress mencari device dari serial number, IP TR-069, IP PPPoE atau IP WAN
+func (c *Client) FindDeviceByAddress(ctx context.Context, address string) (*DeviceInfo, error) {
+	q := Or(
+		Eq("_deviceId._SerialNumber", address),
+		Eq("VirtualParameters.IPTR069._value", address),
+		Eq("VirtualParameters.pppoeIP._value", address),
+		Eq("InternetGatewayDevice.WANDevice.1.WANConnectionDevice.1.WANIPConnection.1.ExternalIPAddress._value", address),
+		Eq("InternetGatewayDevice.WANDevice.1.WANConnectionDevice.1.WANPPPConnection.1.ExternalIPAddress._value", address),
+	)
+	devices, err := c.FindDevices(ctx, q, DeviceInfoProjection)
+	if err != nil {
+		return nil, err
+	}
+	if len(devices) == 0 {
+		return nil, ErrDeviceNotFound
+	}
+	info := devices[0].Info()
+	return &info, nil
+}
+
+// GetConnectedHosts mengambil daftar host LAN dari tabel Hosts.Host ONU
+func (c *Client) GetConnectedHosts(ctx context.Context, deviceID string) ([]HostDevice, error) {
+	device, err := c.GetDevice(ctx, deviceID, Projection{hostsPath})
+	if err != nil {
+		return nil, err
+	}
+	return device.Hosts(), nil
+}
+
+// ListConnectedHosts mengambil tabel host semua device dalam satu request (dipakai job snapshot).
+// Key map adalah _id device.
+func (c *Client) ListConnectedHosts(ctx context.Context) (map[string][]HostDevice, error) {
+	devices, err := c.FindDevices(ctx, nil, Projection{"_id", hostsPath})
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string][]HostDevice, len(devices))
+	for _, d := range devices {
+		result[d.ID] = d.Hosts()
+	}
+	return result, nil
+}
+
+const hostsPath = "InternetGatewayDevice.LANDevice.1.Hosts.Host"
+
+// Hosts mengubah tabel Hosts.Host menjadi daftar HostDevice urut berdasarkan instance
+func (d Device) Hosts() []HostDevice {
+	hostMap := d.InternetGateway.LANDevice.Device1.Hosts.Host
+	keys := make([]string, 0, len(hostMap))
+	for k := range hostMap {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, _ := strconv.Atoi(keys[i])
+		b, _ := strconv.Atoi(keys[j])
+		return a < b
+	})
+
+	hosts := []HostDevice{}
+	for _, k := range keys {
+		h := hostMap[k]
+		if h.MACAddress.Value == "" {
+			continue
+		}
+		hosts = append(hosts, HostDevice{
+			MACAddress:      h.MACAddress.Value.String(),
+			IPAddress:       h.IPAddress.Value.String(),
+			HostName:        h.HostName.Value.String(),
+			Active:          h.Active.Value.String(),
+			Layer1Interface: h.Layer1Interface.Value.String(),
+		})
+	}
+	return hosts
+}
+
+// Jenis koneksi host
+const (
+	HostInterfaceWifi    = "wifi"
+	HostInterfaceLAN     = "lan"
+	HostInterfaceUnknown = "unknown"
+)
+
+// InterfaceType mengklasifikasikan Layer1Interface menjadi wifi atau lan
+func (h HostDevice) InterfaceType() string {
+	l1 := strings.ToLower(h.Layer1Interface)
+	switch {
+	case strings.Contains(l1, "wlan") || strings.Contains(l1, "wifi") || strings.Contains(l1, "ssid"):
+		return HostInterfaceWifi
+	case strings.Contains(l1, "ethernet") || strings.Contains(l1, "lan"):
+		return HostInterfaceLAN
+	default:
+		return HostInterfaceUnknown
+	}
+}
+
+// IsActive bernilai true jika host sedang terhubung (ONU yang tidak mengirim Active dianggap aktif)
+func (h HostDevice) IsActive() bool {
+	switch strings.ToLower(h.Active) {
+	case "false", "0":
+		return false
+	default:
+		return true
+	}
+}
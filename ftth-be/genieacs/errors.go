@@ -0,0 +1,40 @@
+package genieacs
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	// ErrNotConfigured dikembalikan saat GENIE_ACS_URL belum diisi
+	ErrNotConfigured = errors.New("GENIE_ACS_URL is not configured in .env")
+	// ErrDeviceNotFound dikembalikan saat query tidak menemukan device
+	ErrDeviceNotFound = errors.New("device not found")
+	// ErrParameterNotFound dikembalikan saat path parameter tidak ada di pohon device
+	ErrParameterNotFound = errors.New("parameter not found")
+)
+
+// APIError adalah respon non-2xx dari NBI GenieACS
+type APIError struct {
+	StatusCode int
+	Method     string
+	Path       string
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("GenieACS %s %s: status %d", e.Method, e.Path, e.StatusCode)
+	}
+	return fmt.Sprintf("GenieACS %s %s: status %d: %s", e.Method, e.Path, e.StatusCode, e.Body)
+}
+
+// IsNotFound bernilai true untuk ErrDeviceNotFound, ErrParameterNotFound maupun APIError 404
+func IsNotFound(err error) bool {
+	if errors.Is(err, ErrDeviceNotFound) || errors.Is(err, ErrParameterNotFound) {
+		return true
+	}
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,59 @@
+package genieacs
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// FileTypeFirmware adalah fileType TR-069 untuk image firmware
+const FileTypeFirmware = "1 Firmware Upgrade Image"
+
+// FileMeta adalah metadata file di GenieACS (dipakai untuk filter di UI GenieACS)
+type FileMeta struct {
+	FileType     string `json:"fileType"`
+	OUI          string `json:"oui"`
+	ProductClass string `json:"productClass"`
+	Version      string `json:"version"`
+}
+
+// File adalah dokumen di /files
+type File struct {
+	ID       string   `json:"_id"`
+	Length   int64    `json:"length"`
+	Metadata FileMeta `json:"metadata"`
+}
+
+// UploadFile mengunggah file ke GenieACS (PUT /files/<name>). File dengan nama sama akan ditimpa.
+// Timeout client tidak berlaku di sini, batasi durasi upload lewat ctx.
+func (c *Client) UploadFile(ctx context.Context, name string, content io.Reader, meta FileMeta) error {
+	if meta.FileType == "" {
+		meta.FileType = FileTypeFirmware
+	}
+	headers := map[string]string{
+		"Content-Type": "application/octet-stream",
+		"fileType":     meta.FileType,
+		"oui":          meta.OUI,
+		"productClass": meta.ProductClass,
+		"version":      meta.Version,
+	}
+	_, err := c.send(ctx, c.uploadClient, http.MethodPut, "/files/"+url.PathEscape(name), nil, content, headers, nil)
+	return err
+}
+
+// GetFile mengambil metadata file berdasarkan nama
+func (c *Client) GetFile(ctx context.Context, name string) (*File, error) {
+	encoded, err := Eq("_id", name).Encode()
+	if err != nil {
+		return nil, err
+	}
+	var files []File
+	if _, err := c.do(ctx, http.MethodGet, "/files/", url.Values{"query": {encoded}}, nil, &files); err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, &APIError{StatusCode: http.StatusNotFound, Method: http.MethodGet, Path: "/files/" + name, Body: "file not found"}
+	}
+	return &files[0], nil
+}
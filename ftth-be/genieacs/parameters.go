@@ -0,0 +1,167 @@
+package genieacs
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParameterNode adalah satu node pada pohon parameter TR-069 device (object atau parameter)
+type ParameterNode struct {
+	Path       string          `json:"path"`
+	Name       string          `json:"name"`
+	Object     bool            `json:"object"`
+	Writable   bool            `json:"writable"`
+	Value      interface{}     `json:"value,omitempty"`
+	Type       string          `json:"type,omitempty"`
+	Timestamp  string          `json:"timestamp,omitempty"`
+	ChildCount int             `json:"child_count,omitempty"`
+	Children   []ParameterNode `json:"children,omitempty"` // Hanya satu level di bawah path yang diminta
+}
+
+// GetParameterTree mengambil node pada path beserta anak langsungnya.
+// Path kosong berarti root device (InternetGatewayDevice, Device, VirtualParameters, ...).
+func (c *Client) GetParameterTree(ctx context.Context, deviceID, path string) (*ParameterNode, error) {
+	path = strings.Trim(path, ".")
+	var projection Projection
+	if path != "" {
+		projection = Projection{path}
+	}
+
+	devices, err := c.FindRawDevices(ctx, Eq("_id", deviceID), projection)
+	if err != nil {
+		return nil, err
+	}
+	if len(devices) == 0 {
+		return nil, ErrDeviceNotFound
+	}
+
+	var raw interface{} = devices[0]
+	if path != "" {
+		for _, seg := range strings.Split(path, ".") {
+			obj, ok := raw.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%w: %s", ErrParameterNotFound, path)
+			}
+			if raw, ok = obj[seg]; !ok {
+				return nil, fmt.Errorf("%w: %s", ErrParameterNotFound, path)
+			}
+		}
+	}
+
+	obj, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrParameterNotFound, path)
+	}
+	node := parseParameterNode(path, obj)
+	if !node.Object {
+		return &node, nil
+	}
+	node.Children = childNodes(path, obj)
+	node.ChildCount = len(node.Children)
+	return &node, nil
+}
+
+// GetParameterNodes mengambil beberapa parameter (leaf) sekaligus, dipakai untuk validasi sebelum set.
+// Path yang tidak ada di device tidak masuk ke map hasil.
+func (c *Client) GetParameterNodes(ctx context.Context, deviceID string, paths []string) (map[string]ParameterNode, error) {
+	devices, err := c.FindRawDevices(ctx, Eq("_id", deviceID), Projection(paths))
+	if err != nil {
+		return nil, err
+	}
+	if len(devices) == 0 {
+		return nil, ErrDeviceNotFound
+	}
+
+	result := make(map[string]ParameterNode, len(paths))
+	for _, p := range paths {
+		if obj, ok := rawLookup(devices[0], p).(map[string]interface{}); ok {
+			result[p] = parseParameterNode(p, obj)
+		}
+	}
+	return result, nil
+}
+
+// RefreshParameters meminta ONU mengirim ulang nilai parameter di bawah path (refreshObject)
+func (c *Client) RefreshParameters(ctx context.Context, deviceID, path string) (*TaskResult, error) {
+	path = strings.Trim(path, ".")
+	if path == "" {
+		return nil, fmt.Errorf("path wajib diisi untuk refresh")
+	}
+	return c.PushTask(ctx, deviceID, Task{Name: "refreshObject", ObjectName: path}, true)
+}
+
+// parseParameterNode membaca atribut _object, _writable, _value, _type dan _timestamp dari dokumen GenieACS
+func parseParameterNode(path string, obj map[string]interface{}) ParameterNode {
+	node := ParameterNode{Path: path, Name: path}
+	if i := strings.LastIndex(path, "."); i >= 0 {
+		node.Name = path[i+1:]
+	}
+	if v, ok := obj["_writable"].(bool); ok {
+		node.Writable = v
+	}
+	if v, ok := obj["_timestamp"].(string); ok {
+		node.Timestamp = v
+	}
+
+	value, hasValue := obj["_value"]
+	if !hasValue {
+		// Tanpa _value berarti object (termasuk root device yang tidak memiliki atribut _object),
+		// kecuali parameter yang nilainya belum pernah diambil dari ONU
+		node.ChildCount = len(childNames(obj))
+		isObject, _ := obj["_object"].(bool)
+		node.Object = isObject || node.ChildCount > 0
+		return node
+	}
+
+	// GenieACS bisa mengirim _value sebagai [nilai, tipe]
+	if pair, ok := value.([]interface{}); ok && len(pair) == 2 {
+		value = pair[0]
+		if t, ok := pair[1].(string); ok {
+			node.Type = t
+		}
+	}
+	node.Value = value
+	if t, ok := obj["_type"].(string); ok {
+		node.Type = t
+	}
+	return node
+}
+
+func childNodes(path string, obj map[string]interface{}) []ParameterNode {
+	names := childNames(obj)
+	children := make([]ParameterNode, 0, len(names))
+	for _, name := range names {
+		child, ok := obj[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		childPath := name
+		if path != "" {
+			childPath = path + "." + name
+		}
+		children = append(children, parseParameterNode(childPath, child))
+	}
+	return children
+}
+
+// childNames mengembalikan nama anak (tanpa atribut berawalan "_"), instance angka diurutkan numerik
+func childNames(obj map[string]interface{}) []string {
+	names := make([]string, 0, len(obj))
+	for k := range obj {
+		if !strings.HasPrefix(k, "_") {
+			names = append(names, k)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, errA := strconv.Atoi(names[i])
+		b, errB := strconv.Atoi(names[j])
+		if errA == nil && errB == nil {
+			return a < b
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
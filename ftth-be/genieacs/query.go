@@ -0,0 +1,82 @@
+package genieacs
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Query adalah filter MongoDB untuk parameter ?query= di NBI.
+// Nilai selalu di-encode lewat encoding/json sehingga input user tidak bisa
+// keluar dari string atau menyisipkan operator ($ne, $where, ...).
+type Query map[string]interface{}
+
+// Eq mencocokkan field dengan nilai persis
+func Eq(field string, value interface{}) Query {
+	return Query{field: value}
+}
+
+// Ne mencocokkan field yang nilainya tidak sama dengan value
+func Ne(field string, value interface{}) Query {
+	return Query{field: map[string]interface{}{"$ne": value}}
+}
+
+// Lt / Gt membandingkan field (mis. _lastInform) dengan value
+func Lt(field string, value interface{}) Query {
+	return Query{field: map[string]interface{}{"$lt": value}}
+}
+
+func Gt(field string, value interface{}) Query {
+	return Query{field: map[string]interface{}{"$gt": value}}
+}
+
+// Or menggabungkan beberapa query dengan $or
+func Or(queries ...Query) Query {
+	return Query{"$or": queries}
+}
+
+// And menggabungkan beberapa query dengan $and
+func And(queries ...Query) Query {
+	return Query{"$and": queries}
+}
+
+// Encode menghasilkan JSON query. Nama field yang diawali "$" di luar $or/$and ditolak.
+func (q Query) Encode() (string, error) {
+	if err := q.validate(); err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(q)
+	if err != nil {
+		return "", fmt.Errorf("gagal encode query: %v", err)
+	}
+	return string(b), nil
+}
+
+func (q Query) validate() error {
+	for field, value := range q {
+		switch field {
+		case "$or", "$and":
+			subs, ok := value.([]Query)
+			if !ok {
+				return fmt.Errorf("operator %s harus berisi daftar query", field)
+			}
+			for _, sub := range subs {
+				if err := sub.validate(); err != nil {
+					return err
+				}
+			}
+		default:
+			if field == "" || strings.HasPrefix(field, "$") {
+				return fmt.Errorf("nama field query tidak valid: %q", field)
+			}
+		}
+	}
+	return nil
+}
+
+// Projection adalah daftar path parameter untuk ?projection=
+type Projection []string
+
+func (p Projection) String() string {
+	return strings.Join(p, ",")
+}
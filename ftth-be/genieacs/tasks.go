@@ -0,0 +1,166 @@
+package genieacs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Task adalah task NBI (setParameterValues, reboot, refreshObject, ...).
+// Field yang kosong tidak ikut dikirim.
+type Task struct {
+	ID              string          `json:"_id,omitempty"`
+	Name            string          `json:"name"`
+	Device          string          `json:"device,omitempty"`
+	ParameterValues [][]interface{} `json:"parameterValues,omitempty"`
+	ParameterNames  []string        `json:"parameterNames,omitempty"`
+	ObjectName      string          `json:"objectName,omitempty"`
+	FileName        string          `json:"fileName,omitempty"`
+	FileType        string          `json:"fileType,omitempty"`
+	Timestamp       string          `json:"timestamp,omitempty"`
+}
+
+// TaskResult adalah hasil POST task. Applied bernilai true jika GenieACS berhasil
+// melakukan connection request dan task sudah dieksekusi (HTTP 200); false berarti
+// task masih antre sampai ONU inform berikutnya (HTTP 202).
+type TaskResult struct {
+	Task    Task `json:"task"`
+	Applied bool `json:"applied"`
+}
+
+// PushTask mengirim task ke device. connectionRequest = true meminta GenieACS
+// langsung menghubungi ONU agar task dieksekusi saat itu juga.
+func (c *Client) PushTask(ctx context.Context, deviceID string, task Task, connectionRequest bool) (*TaskResult, error) {
+	if deviceID == "" {
+		return nil, fmt.Errorf("deviceId wajib diisi")
+	}
+	params := url.Values{}
+	if connectionRequest {
+		// GenieACS hanya mengecek keberadaan parameter connection_request
+		params.Set("connection_request", "")
+	}
+
+	var created Task
+	status, err := c.do(ctx, http.MethodPost, devicePath(deviceID, "/tasks"), params, task, &created)
+	if err != nil {
+		return nil, err
+	}
+	if created.Name == "" {
+		created = task
+	}
+	return &TaskResult{Task: created, Applied: status == http.StatusOK}, nil
+}
+
+// SetParameterValues mengirim task setParameterValues. values berisi path => nilai string.
+func (c *Client) SetParameterValues(ctx context.Context, deviceID string, values [][]interface{}) (*TaskResult, error) {
+	if len(values) == 0 {
+		return nil, fmt.Errorf("tidak ada parameter yang diubah")
+	}
+	return c.PushTask(ctx, deviceID, Task{Name: "setParameterValues", ParameterValues: values}, true)
+}
+
+// UpdateWifi mengubah SSID dan/atau password WLANConfiguration.{ssidIndex}
+func (c *Client) UpdateWifi(ctx context.Context, deviceID, ssid, password string, ssidIndex int) (*TaskResult, error) {
+	if ssidIndex == 0 {
+		ssidIndex = 1
+	}
+
+	var values [][]interface{}
+	// Hanya tambahkan jika parameter tidak kosong
+	if ssid != "" {
+		values = append(values, []interface{}{
+			fmt.Sprintf("InternetGatewayDevice.LANDevice.1.WLANConfiguration.%d.SSID", ssidIndex), ssid, "xsd:string",
+		})
+	}
+	if password != "" {
+		values = append(values, []interface{}{
+			"VirtualParameters.WlanPassword", password, "xsd:string",
+		})
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("SSID or Password must be provided")
+	}
+	return c.SetParameterValues(ctx, deviceID, values)
+}
+
+// Status task di GenieACS. Task yang sudah selesai dihapus dari koleksi tasks,
+// sedangkan task yang gagal meninggalkan dokumen di koleksi faults.
+const (
+	TaskPending = "pending"
+	TaskDone    = "done"
+	TaskFault   = "fault"
+)
+
+// Fault adalah dokumen di /faults (channel "task_<id>" untuk fault dari task NBI)
+type Fault struct {
+	ID        string      `json:"_id"`
+	Device    string      `json:"device"`
+	Channel   string      `json:"channel"`
+	Code      string      `json:"code"`
+	Message   string      `json:"message"`
+	Detail    interface{} `json:"detail,omitempty"`
+	Retries   int         `json:"retries"`
+	Timestamp string      `json:"timestamp"`
+}
+
+// FindTasks menjalankan GET /tasks dengan query
+func (c *Client) FindTasks(ctx context.Context, q Query) ([]Task, error) {
+	params := url.Values{}
+	if len(q) > 0 {
+		encoded, err := q.Encode()
+		if err != nil {
+			return nil, err
+		}
+		params.Set("query", encoded)
+	}
+	var tasks []Task
+	if _, err := c.do(ctx, http.MethodGet, "/tasks/", params, nil, &tasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// FindFaults menjalankan GET /faults dengan query
+func (c *Client) FindFaults(ctx context.Context, q Query) ([]Fault, error) {
+	params := url.Values{}
+	if len(q) > 0 {
+		encoded, err := q.Encode()
+		if err != nil {
+			return nil, err
+		}
+		params.Set("query", encoded)
+	}
+	var faults []Fault
+	if _, err := c.do(ctx, http.MethodGet, "/faults/", params, nil, &faults); err != nil {
+		return nil, err
+	}
+	return faults, nil
+}
+
+// TaskStatus menentukan status task: fault jika ada fault untuk task tersebut,
+// pending jika task masih antre, selain itu done.
+func (c *Client) TaskStatus(ctx context.Context, deviceID, taskID string) (string, *Fault, error) {
+	faults, err := c.FindFaults(ctx, And(Eq("device", deviceID), Eq("channel", "task_"+taskID)))
+	if err != nil {
+		return "", nil, err
+	}
+	if len(faults) > 0 {
+		return TaskFault, &faults[0], nil
+	}
+
+	tasks, err := c.FindTasks(ctx, Eq("_id", taskID))
+	if err != nil {
+		return "", nil, err
+	}
+	if len(tasks) > 0 {
+		return TaskPending, nil, nil
+	}
+	return TaskDone, nil, nil
+}
+
+// DeleteTask menghapus task yang masih antre (mis. ONU offline terlalu lama)
+func (c *Client) DeleteTask(ctx context.Context, taskID string) error {
+	_, err := c.do(ctx, http.MethodDelete, "/tasks/"+url.PathEscape(taskID), nil, nil, nil)
+	return err
+}
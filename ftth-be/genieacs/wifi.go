@@ -0,0 +1,423 @@
+package genieacs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Band WiFi
+const (
+	Band24GHz = "2.4GHz"
+	Band5GHz  = "5GHz"
+)
+
+// WifiProfile memetakan nama parameter WLANConfiguration per vendor/model ONU.
+// Path selain BasePath relatif terhadap WLANConfiguration.{i}; field kosong memakai nama TR-098 standar.
+type WifiProfile struct {
+	Name           string `json:"name"`
+	Manufacturer   string `json:"manufacturer"`  // Dicocokkan case-insensitive (contains) dengan _deviceId._Manufacturer, kosong = semua
+	ProductClass   string `json:"product_class"` // Dicocokkan case-insensitive (prefix) dengan _deviceId._ProductClass, kosong = semua
+	BasePath       string `json:"base_path"`
+	EnablePath     string `json:"enable_path"`        // Enable SSID, dipakai jika RadioEnabled tidak ada
+	RadioPath      string `json:"radio_enabled_path"` // RadioEnabled (radio on/off)
+	SSIDPath       string `json:"ssid_path"`
+	AdvertisePath  string `json:"advertise_path"` // SSIDAdvertisementEnabled (false = hidden)
+	ChannelPath    string `json:"channel_path"`
+	AutoChanPath   string `json:"auto_channel_path"`
+	StandardPath   string `json:"standard_path"`
+	SecurityPath   string `json:"security_path"` // BeaconType
+	BasicEncPath   string `json:"basic_encryption_path"`
+	BasicAuthPath  string `json:"basic_auth_path"`
+	WPAEncPath     string `json:"wpa_encryption_path"`
+	WPAAuthPath    string `json:"wpa_auth_path"`
+	WPA2EncPath    string `json:"ieee11i_encryption_path"`
+	WPA2AuthPath   string `json:"ieee11i_auth_path"`
+	PasswordPath   string `json:"password_path"`
+	AssocPath      string `json:"associations_path"`
+	BandPath       string `json:"band_path"`        // Parameter band vendor (jika ada), nilai mengandung "5" = 5GHz
+	FiveGHzIndexes []int  `json:"five_ghz_indexes"` // Instance WLANConfiguration yang merupakan radio 5GHz
+}
+
+// DefaultWifiProfiles adalah pemetaan awal untuk vendor yang umum dipakai.
+// Bisa ditimpa/ditambah lewat file JSON (lihat LoadWifiProfiles).
+var DefaultWifiProfiles = []WifiProfile{
+	{Name: "Huawei", Manufacturer: "Huawei", PasswordPath: "PreSharedKey.1.PreSharedKey", FiveGHzIndexes: []int{5, 6, 7, 8}},
+	{Name: "ZTE", Manufacturer: "ZTE", PasswordPath: "KeyPassphrase", FiveGHzIndexes: []int{5, 6, 7, 8}},
+	{Name: "FiberHome", Manufacturer: "FiberHome", PasswordPath: "PreSharedKey.1.KeyPassphrase", FiveGHzIndexes: []int{5, 6, 7, 8}},
+	{Name: "Generic TR-098"},
+}
+
+// withDefaults mengisi path yang kosong dengan nama parameter TR-098 standar
+func (p WifiProfile) withDefaults() WifiProfile {
+	def := func(v *string, d string) {
+		if *v == "" {
+			*v = d
+		}
+	}
+	def(&p.BasePath, "InternetGatewayDevice.LANDevice.1.WLANConfiguration")
+	def(&p.EnablePath, "Enable")
+	def(&p.RadioPath, "RadioEnabled")
+	def(&p.SSIDPath, "SSID")
+	def(&p.AdvertisePath, "SSIDAdvertisementEnabled")
+	def(&p.ChannelPath, "Channel")
+	def(&p.AutoChanPath, "AutoChannelEnable")
+	def(&p.StandardPath, "Standard")
+	def(&p.SecurityPath, "BeaconType")
+	def(&p.BasicEncPath, "BasicEncryptionModes")
+	def(&p.BasicAuthPath, "BasicAuthenticationMode")
+	def(&p.WPAEncPath, "WPAEncryptionModes")
+	def(&p.WPAAuthPath, "WPAAuthenticationMode")
+	def(&p.WPA2EncPath, "IEEE11iEncryptionModes")
+	def(&p.WPA2AuthPath, "IEEE11iAuthenticationMode")
+	def(&p.PasswordPath, "KeyPassphrase")
+	def(&p.AssocPath, "TotalAssociations")
+	return p
+}
+
+func (p WifiProfile) matches(id DeviceID) bool {
+	if p.Manufacturer != "" && !strings.Contains(strings.ToLower(id.Manufacturer), strings.ToLower(p.Manufacturer)) {
+		return false
+	}
+	if p.ProductClass != "" && !strings.HasPrefix(strings.ToLower(id.ProductClass), strings.ToLower(p.ProductClass)) {
+		return false
+	}
+	return true
+}
+
+// MatchWifiProfile memilih profil pertama yang cocok dengan vendor/model device
+func MatchWifiProfile(profiles []WifiProfile, id DeviceID) WifiProfile {
+	for _, p := range profiles {
+		if p.matches(id) {
+			return p.withDefaults()
+		}
+	}
+	return WifiProfile{Name: "Generic TR-098"}.withDefaults()
+}
+
+// LoadWifiProfiles membaca profil dari file JSON (array WifiProfile). Profil dari file
+// dicek lebih dulu, lalu DefaultWifiProfiles sebagai fallback.
+func LoadWifiProfiles(path string) ([]WifiProfile, error) {
+	if path == "" {
+		return DefaultWifiProfiles, nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca profil WiFi: %v", err)
+	}
+	var custom []WifiProfile
+	if err := json.Unmarshal(b, &custom); err != nil {
+		return nil, fmt.Errorf("format profil WiFi tidak valid: %v", err)
+	}
+	return append(custom, DefaultWifiProfiles...), nil
+}
+
+// Pemetaan BeaconType TR-098 <-> mode keamanan yang dipakai UI
+var beaconTypeToSecurity = map[string]string{
+	"None":      "open",
+	"Basic":     "wep",
+	"WPA":       "wpa",
+	"11i":       "wpa2",
+	"WPAand11i": "wpa-wpa2",
+}
+
+// SecurityModes adalah nilai mode keamanan yang diterima UpdateWlan
+func SecurityModes() []string {
+	modes := make([]string, 0, len(beaconTypeToSecurity))
+	for _, m := range beaconTypeToSecurity {
+		modes = append(modes, m)
+	}
+	sort.Strings(modes)
+	return modes
+}
+
+func securityToBeaconType(mode string) (string, bool) {
+	for beacon, m := range beaconTypeToSecurity {
+		if m == mode {
+			return beacon, true
+		}
+	}
+	return "", false
+}
+
+// securityValues mengembalikan BeaconType beserta mode enkripsi & autentikasi TR-098
+// untuk mode keamanan UI. ONU umumnya tetap memakai enkripsi lama jika hanya BeaconType yang diubah.
+func securityValues(p WifiProfile, mode string) ([][2]string, bool) {
+	beacon, ok := securityToBeaconType(mode)
+	if !ok {
+		return nil, false
+	}
+	values := [][2]string{{p.SecurityPath, beacon}}
+	switch mode {
+	case "open":
+		values = append(values, [2]string{p.BasicEncPath, "None"}, [2]string{p.BasicAuthPath, "None"})
+	case "wep":
+		values = append(values, [2]string{p.BasicEncPath, "WEPEncryption"}, [2]string{p.BasicAuthPath, "None"})
+	case "wpa":
+		values = append(values, [2]string{p.WPAEncPath, "TKIPEncryption"}, [2]string{p.WPAAuthPath, "PSKAuthentication"})
+	case "wpa2":
+		values = append(values, [2]string{p.WPA2EncPath, "AESEncryption"}, [2]string{p.WPA2AuthPath, "PSKAuthentication"})
+	case "wpa-wpa2":
+		values = append(values,
+			[2]string{p.WPAEncPath, "TKIPandAESEncryption"}, [2]string{p.WPAAuthPath, "PSKAuthentication"},
+			[2]string{p.WPA2EncPath, "TKIPandAESEncryption"}, [2]string{p.WPA2AuthPath, "PSKAuthentication"},
+		)
+	}
+	return values, true
+}
+
+// WlanConfig adalah pengaturan satu SSID (WLANConfiguration.{i}) pada ONU
+type WlanConfig struct {
+	Index        int    `json:"index"`
+	Band         string `json:"band"`
+	Enabled      bool   `json:"enabled"` // Radio on/off (RadioEnabled, fallback Enable SSID)
+	SSID         string `json:"ssid"`
+	Hidden       bool   `json:"hidden"`
+	Channel      string `json:"channel"`
+	AutoChannel  bool   `json:"auto_channel"`
+	Standard     string `json:"standard"`
+	Security     string `json:"security"` // open, wep, wpa, wpa2, wpa-wpa2 (atau BeaconType mentah jika tidak dikenal)
+	Associations int    `json:"associations"`
+}
+
+// WlanUpdate berisi field yang akan diubah, nil = tidak diubah
+type WlanUpdate struct {
+	SSID     *string `json:"ssid"`
+	Password *string `json:"password"`
+	Enabled  *bool   `json:"enabled"` // Radio on/off, dikirim ke RadioEnabled
+	Hidden   *bool   `json:"hidden"`
+	Channel  *string `json:"channel"` // "auto" atau nomor channel
+	Security *string `json:"security"`
+}
+
+// GetWlans mengambil semua WLANConfiguration device beserta profil vendor yang dipakai
+func (c *Client) GetWlans(ctx context.Context, deviceID string, profiles []WifiProfile) ([]WlanConfig, WifiProfile, error) {
+	profile, err := c.wifiProfileFor(ctx, deviceID, profiles)
+	if err != nil {
+		return nil, profile, err
+	}
+	wlans, err := c.wlansWithProfile(ctx, deviceID, profile)
+	return wlans, profile, err
+}
+
+func (c *Client) wlansWithProfile(ctx context.Context, deviceID string, profile WifiProfile) ([]WlanConfig, error) {
+	devices, err := c.FindRawDevices(ctx, Eq("_id", deviceID), Projection{profile.BasePath})
+	if err != nil {
+		return nil, err
+	}
+	if len(devices) == 0 {
+		return nil, ErrDeviceNotFound
+	}
+	base, _ := rawLookup(devices[0], profile.BasePath).(map[string]interface{})
+	return parseWlans(base, profile), nil
+}
+
+// UpdateWlan mengubah pengaturan WLANConfiguration.{index} memakai path sesuai profil vendor.
+// Pengaturan channel dibandingkan dengan nilai saat ini agar AutoChannelEnable tidak ikut
+// dikirim jika tidak berubah.
+func (c *Client) UpdateWlan(ctx context.Context, deviceID string, index int, update WlanUpdate, profiles []WifiProfile) (*TaskResult, error) {
+	if index <= 0 {
+		return nil, fmt.Errorf("index WLAN tidak valid")
+	}
+	profile, err := c.wifiProfileFor(ctx, deviceID, profiles)
+	if err != nil {
+		return nil, err
+	}
+	wlans, err := c.wlansWithProfile(ctx, deviceID, profile)
+	if err != nil {
+		return nil, err
+	}
+	var current *WlanConfig
+	for i := range wlans {
+		if wlans[i].Index == index {
+			current = &wlans[i]
+			break
+		}
+	}
+	if current == nil {
+		return nil, fmt.Errorf("WLAN index %d tidak ada di device", index)
+	}
+	values, err := wlanUpdateValues(profile, *current, update)
+	if err != nil {
+		return nil, err
+	}
+	return c.SetParameterValues(ctx, deviceID, values)
+}
+
+func (c *Client) wifiProfileFor(ctx context.Context, deviceID string, profiles []WifiProfile) (WifiProfile, error) {
+	device, err := c.GetDevice(ctx, deviceID, Projection{"_deviceId"})
+	if err != nil {
+		return WifiProfile{}, err
+	}
+	return MatchWifiProfile(profiles, device.DeviceID), nil
+}
+
+func wlanUpdateValues(p WifiProfile, current WlanConfig, u WlanUpdate) ([][]interface{}, error) {
+	prefix := fmt.Sprintf("%s.%d.", p.BasePath, current.Index)
+	var values [][]interface{}
+	add := func(rel string, v interface{}, t string) {
+		values = append(values, []interface{}{prefix + rel, v, t})
+	}
+
+	if u.SSID != nil {
+		if *u.SSID == "" || len(*u.SSID) > 32 {
+			return nil, fmt.Errorf("SSID harus 1-32 karakter")
+		}
+		add(p.SSIDPath, *u.SSID, "xsd:string")
+	}
+	if u.Password != nil {
+		if len(*u.Password) < 8 || len(*u.Password) > 63 {
+			return nil, fmt.Errorf("password WiFi harus 8-63 karakter")
+		}
+		add(p.PasswordPath, *u.Password, "xsd:string")
+	}
+	if u.Enabled != nil {
+		add(p.RadioPath, *u.Enabled, "xsd:boolean")
+	}
+	if u.Hidden != nil {
+		add(p.AdvertisePath, !*u.Hidden, "xsd:boolean")
+	}
+	if u.Channel != nil {
+		if strings.EqualFold(*u.Channel, "auto") {
+			if !current.AutoChannel {
+				add(p.AutoChanPath, true, "xsd:boolean")
+			}
+		} else {
+			ch, err := strconv.Atoi(*u.Channel)
+			if err != nil || ch <= 0 {
+				return nil, fmt.Errorf("channel harus 'auto' atau nomor channel")
+			}
+			if current.AutoChannel {
+				add(p.AutoChanPath, false, "xsd:boolean")
+			}
+			if current.AutoChannel || current.Channel != strconv.Itoa(ch) {
+				add(p.ChannelPath, ch, "xsd:unsignedInt")
+			}
+		}
+	}
+	if u.Security != nil {
+		security, ok := securityValues(p, *u.Security)
+		if !ok {
+			return nil, fmt.Errorf("mode keamanan tidak dikenal, gunakan: %s", strings.Join(SecurityModes(), ", "))
+		}
+		for _, v := range security {
+			add(v[0], v[1], "xsd:string")
+		}
+	}
+
+	if len(values) == 0 {
+		return nil, fmt.Errorf("tidak ada pengaturan WiFi yang diubah")
+	}
+	return values, nil
+}
+
+func parseWlans(base map[string]interface{}, p WifiProfile) []WlanConfig {
+	wlans := []WlanConfig{}
+	for _, key := range childNames(base) {
+		index, err := strconv.Atoi(key)
+		if err != nil {
+			continue
+		}
+		inst, ok := base[key].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		w := WlanConfig{
+			Index:       index,
+			Enabled:     rawBool(inst, p.RadioPath),
+			SSID:        rawString(inst, p.SSIDPath),
+			Hidden:      !rawBool(inst, p.AdvertisePath),
+			Channel:     rawString(inst, p.ChannelPath),
+			AutoChannel: rawBool(inst, p.AutoChanPath),
+			Standard:    rawString(inst, p.StandardPath),
+		}
+		if rawLookup(inst, p.RadioPath) == nil {
+			w.Enabled = rawBool(inst, p.EnablePath)
+		}
+		if rawLookup(inst, p.AdvertisePath) == nil {
+			// ONU tanpa parameter SSIDAdvertisementEnabled dianggap tidak hidden
+			w.Hidden = false
+		}
+		w.Associations, _ = strconv.Atoi(rawString(inst, p.AssocPath))
+
+		beacon := rawString(inst, p.SecurityPath)
+		if mode, ok := beaconTypeToSecurity[beacon]; ok {
+			w.Security = mode
+		} else {
+			w.Security = beacon
+		}
+		w.Band = detectBand(inst, index, w.Standard, p)
+		wlans = append(wlans, w)
+	}
+	return wlans
+}
+
+// detectBand: parameter band vendor > token Standard (a/ac = 5GHz, b/g = 2.4GHz) >
+// daftar index 5GHz di profil. Standard "n" atau "ax" saja bisa di kedua band.
+func detectBand(inst map[string]interface{}, index int, standard string, p WifiProfile) string {
+	if p.BandPath != "" {
+		if band := rawString(inst, p.BandPath); band != "" {
+			if strings.HasPrefix(band, "5") {
+				return Band5GHz
+			}
+			return Band24GHz
+		}
+	}
+	for _, token := range strings.Split(strings.ToLower(standard), ",") {
+		switch strings.TrimPrefix(strings.TrimSpace(token), "802.11") {
+		case "a", "ac":
+			return Band5GHz
+		case "b", "g":
+			return Band24GHz
+		}
+	}
+	for _, i := range p.FiveGHzIndexes {
+		if i == index {
+			return Band5GHz
+		}
+	}
+	return Band24GHz
+}
+
+// rawLookup menelusuri dokumen mentah GenieACS berdasarkan path bertitik
+func rawLookup(doc map[string]interface{}, path string) interface{} {
+	var cur interface{} = doc
+	for _, seg := range strings.Split(path, ".") {
+		obj, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		cur = obj[seg]
+	}
+	return cur
+}
+
+func rawString(doc map[string]interface{}, path string) string {
+	obj, ok := rawLookup(doc, path).(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	node := parseParameterNode(path, obj)
+	if node.Value == nil {
+		return ""
+	}
+	if f, ok := node.Value.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%v", node.Value)
+}
+
+func rawBool(doc map[string]interface{}, path string) bool {
+	v := strings.ToLower(rawString(doc, path))
+	return v == "true" || v == "1"
+}
+
+// LoadWifiProfilesFromEnv membaca file profil dari GENIEACS_WIFI_PROFILES_FILE (opsional)
+func LoadWifiProfilesFromEnv() ([]WifiProfile, error) {
+	return LoadWifiProfiles(os.Getenv("GENIEACS_WIFI_PROFILES_FILE"))
+}
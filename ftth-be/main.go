@@ -3,6 +3,7 @@ package main
 import (
 	services "akane/be-ftth/Services" // Pastikan import path sesuai
 	"akane/be-ftth/config"
+	"akane/be-ftth/middleware"
 	"akane/be-ftth/migrations"
 	"akane/be-ftth/routes"
 	"log"
@@ -10,7 +11,6 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/joho/godotenv"
-	"github.com/robfig/cron/v3"
 )
 
 func main() {
@@ -23,42 +23,21 @@ func main() {
 	migrations.RunMigrations()
 
 	// --- SETUP CRON JOBS ---
-	c := cron.New()
-
-	// JOB 1: Traffic Sync (Setiap Jam atau bisa diganti @every 5m)
-	_, errT := c.AddFunc("@hourly", func() {
-		log.Println("[CRON] Running Traffic Sync...")
-		services.RunTrafficSyncJob()
-	})
-	if errT != nil {
-		log.Fatal(errT)
-	}
-
-	// JOB 2: Ping Check (Setiap 30 Menit)
-	// Format: "@every 30m"
-	_, errP := c.AddFunc("@every 30m", func() {
-		log.Println("[CRON] Running Ping Check...")
-		services.RunPingCheckJob()
-	})
-	if errP != nil {
-		log.Fatal(errP)
+	// Jadwal setiap job disimpan di tabel scheduled_jobs dan bisa diubah lewat /api/jobs
+	if err := services.StartScheduler(); err != nil {
+		log.Fatal(err)
 	}
-
-	// JOB 3: GenieACS Sync (Setiap 1 Jam)
-	// Format: "@every 1h"
-	_, errA := c.AddFunc("@every 1h", func() {
-		log.Println("[CRON] Running GenieACS Sync...")
-		services.RunGenieACSSyncJob()
-	})
-	if errA != nil {
-		log.Fatal(errA)
-	}
-
-	c.Start()
-	defer c.Stop()
+	defer services.StopScheduler()
 	// -----------------------
 
-	app := fiber.New()
+	app := fiber.New(fiber.Config{
+		// Body dibaca sebagai stream agar batas ukuran bisa berbeda per route
+		// (middleware.BodyLimit); hanya upload firmware yang boleh melebihi 4MB.
+		BodyLimit:                    fiber.DefaultBodyLimit,
+		StreamRequestBody:            true,
+		DisablePreParseMultipartForm: true,
+	})
+	app.Use(middleware.BodyLimit(fiber.DefaultBodyLimit, routes.FirmwareUploadPath))
 	app.Use(cors.New(cors.Config{
 		AllowOriginsFunc: func(origin string) bool {
 			return true
@@ -81,6 +60,10 @@ func main() {
 	routes.MappingRoutes(app)
 	routes.GenieACSRoutes(app)
 	routes.IPPoolRoutes(app)
+	routes.MetricsRoutes(app)
+	routes.JobRoutes(app)
+	routes.FirmwareRoutes(app)
+	routes.IncidentRoutes(app)
 
 	log.Fatal(app.Listen(":8080"))
 }
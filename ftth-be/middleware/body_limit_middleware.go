@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"io"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// BodyLimit membatasi ukuran body request dalam byte. Server membaca body sebagai stream
+// (StreamRequestBody), jadi pengecekan Content-Length di sini terjadi sebelum body dibaca
+// ke memori. Path pada skip tidak dicek karena route tersebut memasang BodyLimit sendiri.
+func BodyLimit(limit int, skip ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Routing Fiber tidak membedakan huruf besar/kecil maupun slash di akhir path
+		current := strings.TrimRight(c.Path(), "/")
+		for _, path := range skip {
+			if strings.EqualFold(current, strings.TrimRight(path, "/")) {
+				return c.Next()
+			}
+		}
+
+		req := c.Request()
+		length := req.Header.ContentLength()
+		if length > limit {
+			// Body yang ditolak tidak dibaca, jadi koneksi tidak bisa dipakai ulang
+			c.Context().SetConnectionClose()
+			return fiber.ErrRequestEntityTooLarge
+		}
+		if length < 0 && req.IsBodyStream() {
+			// Transfer-Encoding: chunked, ukuran baru diketahui saat dibaca; baca maksimal limit+1 byte
+			body, err := io.ReadAll(io.LimitReader(req.BodyStream(), int64(limit)+1))
+			if err != nil {
+				c.Context().SetConnectionClose()
+				return fiber.ErrBadRequest
+			}
+			if len(body) > limit {
+				c.Context().SetConnectionClose()
+				return fiber.ErrRequestEntityTooLarge
+			}
+			req.SetBody(body)
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"os"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// MetricsToken melindungi /metrics dengan bearer token statis dari METRICS_TOKEN.
+// Jika METRICS_TOKEN kosong, endpoint dibiarkan terbuka (biasanya dibatasi lewat firewall).
+func MetricsToken() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token := os.Getenv("METRICS_TOKEN")
+		if token == "" {
+			return c.Next()
+		}
+		given, ok := strings.CutPrefix(c.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			return c.Status(fiber.StatusUnauthorized).SendString("unauthorized\n")
+		}
+		return c.Next()
+	}
+}
@@ -29,6 +29,22 @@ func RunMigrations() {
 		&models.IPPool{},
 		&models.TopologyMapping{},
 		&models.RxPowerHistory{},
+		&models.RouterHealth{},
+		&models.ScheduledJob{},
+		&models.JobRun{},
+		&models.OnuAction{},
+		&models.FirmwareFile{},
+		&models.FirmwareCampaign{},
+		&models.FirmwareCampaignDevice{},
+		&models.OnuStatusEvent{},
+		&models.OnuOutageAlert{},
+		&models.LanHostSnapshot{},
+		&models.LanHostEntry{},
+		&models.Incident{},
+		&models.IncidentClient{},
+		&models.ODPPort{},
+		&models.CableCore{},
+		&models.SpliceRecord{},
 	)
 	if err != nil {
 		fmt.Println("Migration failed:", err)
@@ -112,6 +128,76 @@ func applyForeignKeys(db *gorm.DB) error {
 		 FOREIGN KEY (odp_node_id) REFERENCES network_nodes(node_id)
 		 ON DELETE CASCADE ON UPDATE CASCADE;`,
 
+		`ALTER TABLE router_healths
+		 ADD CONSTRAINT fk_router_healths_router
+		 FOREIGN KEY (router_id) REFERENCES routers(router_id)
+		 ON DELETE CASCADE ON UPDATE CASCADE;`,
+
+		`ALTER TABLE onu_actions
+		 ADD CONSTRAINT fk_onu_actions_client
+		 FOREIGN KEY (client_id) REFERENCES clients(client_id)
+		 ON DELETE CASCADE ON UPDATE CASCADE;`,
+
+		`ALTER TABLE onu_status_events
+		 ADD CONSTRAINT fk_onu_status_events_client
+		 FOREIGN KEY (client_id) REFERENCES clients(client_id)
+		 ON DELETE CASCADE ON UPDATE CASCADE;`,
+
+		`ALTER TABLE lan_host_snapshots
+		 ADD CONSTRAINT fk_lan_host_snapshots_client
+		 FOREIGN KEY (client_id) REFERENCES clients(client_id)
+		 ON DELETE CASCADE ON UPDATE CASCADE;`,
+
+		`ALTER TABLE lan_host_entries
+		 ADD CONSTRAINT fk_lan_host_entries_snapshot
+		 FOREIGN KEY (snapshot_id) REFERENCES lan_host_snapshots(snapshot_id)
+		 ON DELETE CASCADE ON UPDATE CASCADE;`,
+
+		`ALTER TABLE incident_clients
+		 ADD CONSTRAINT fk_incident_clients_incident
+		 FOREIGN KEY (incident_id) REFERENCES incidents(incident_id)
+		 ON DELETE CASCADE ON UPDATE CASCADE;`,
+
+		`ALTER TABLE odp_ports
+		 ADD CONSTRAINT fk_odp_ports_node
+		 FOREIGN KEY (odp_node_id) REFERENCES network_nodes(node_id)
+		 ON DELETE CASCADE ON UPDATE CASCADE;`,
+
+		`ALTER TABLE odp_ports
+		 ADD CONSTRAINT fk_odp_ports_client
+		 FOREIGN KEY (client_id) REFERENCES clients(client_id)
+		 ON DELETE SET NULL ON UPDATE CASCADE;`,
+
+		`ALTER TABLE cable_cores
+		 ADD CONSTRAINT fk_cable_cores_cable
+		 FOREIGN KEY (cable_id) REFERENCES network_cables(cable_id)
+		 ON DELETE CASCADE ON UPDATE CASCADE;`,
+
+		`ALTER TABLE splice_records
+		 ADD CONSTRAINT fk_splice_records_node
+		 FOREIGN KEY (node_id) REFERENCES network_nodes(node_id)
+		 ON DELETE CASCADE ON UPDATE CASCADE;`,
+
+		`ALTER TABLE splice_records
+		 ADD CONSTRAINT fk_splice_records_core_a
+		 FOREIGN KEY (core_a_id) REFERENCES cable_cores(core_id)
+		 ON DELETE CASCADE ON UPDATE CASCADE;`,
+
+		`ALTER TABLE splice_records
+		 ADD CONSTRAINT fk_splice_records_core_b
+		 FOREIGN KEY (core_b_id) REFERENCES cable_cores(core_id)
+		 ON DELETE CASCADE ON UPDATE CASCADE;`,
+
+		`ALTER TABLE firmware_campaigns
+		 ADD CONSTRAINT fk_firmware_campaigns_file
+		 FOREIGN KEY (file_id) REFERENCES firmware_files(file_id)
+		 ON DELETE RESTRICT ON UPDATE CASCADE;`,
+
+		`ALTER TABLE firmware_campaign_devices
+		 ADD CONSTRAINT fk_firmware_campaign_devices_campaign
+		 FOREIGN KEY (campaign_id) REFERENCES firmware_campaigns(campaign_id)
+		 ON DELETE CASCADE ON UPDATE CASCADE;`,
+
 	}
 
 	for _, query := range fkQueries {
@@ -1,11 +1,18 @@
 package models
 
+import "time"
+
 // 1. Tabel Detail ODP (Capacity Planning)
 type ODP struct {
 	ODPID      int `gorm:"primaryKey;autoIncrement" json:"odp_id"`
 	NodeID     int `gorm:"unique;not null" json:"node_id"` // Link ke NetworkNode
 	TotalPorts int `gorm:"default:8" json:"total_ports"`   // Kapasitas (8, 16, 24)
 	UsedPorts  int `gorm:"default:0" json:"used_ports"`    // Terisi berapa (Dihitung dari kabel)
+	// Rasio splitter (8 = 1:8). 0 = dianggap sama dengan TotalPorts
+	SplitterRatio int `gorm:"default:0" json:"splitter_ratio"`
+	// Diisi saat record port ODP dibuat & port terpakai diisi dari kabel drop / pelanggan lama.
+	// Selama masih nil, jumlah port terpakai dihitung dari kabel ke node CLIENT.
+	PortsBackfilledAt *time.Time `gorm:"column:ports_backfilled_at" json:"ports_backfilled_at"`
 
 	// Relasi
 	Node NetworkNode `gorm:"foreignKey:NodeID;references:NodeID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
@@ -18,6 +25,8 @@ type OLT struct {
 	Brand      string `gorm:"type:varchar(50)" json:"brand"`       // ZTE, Huawei, Hioso
 	UplinkType string `gorm:"type:varchar(20)" json:"uplink_type"` // 1G, 10G
 	IPAddress  string `gorm:"type:varchar(20)" json:"ip_address"`
+	// Daya pancar port PON (dBm), nil = pakai POWER_BUDGET_OLT_TX_DBM
+	TxPowerDbm *float64 `gorm:"type:double" json:"tx_power_dbm"`
 
 	Node NetworkNode `gorm:"foreignKey:NodeID;references:NodeID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
 }
@@ -27,6 +36,8 @@ type ODC struct {
 	ODCID    int `gorm:"primaryKey;autoIncrement" json:"odc_id"`
 	NodeID   int `gorm:"unique;not null" json:"node_id"`
 	Capacity int `gorm:"default:144" json:"capacity"` // 48, 96, 144, 288 core
+	// Rasio splitter di ODC (4 = 1:4). 0 = tanpa splitter (hanya sambungan)
+	SplitterRatio int `gorm:"default:0" json:"splitter_ratio"`
 
 	Node NetworkNode `gorm:"foreignKey:NodeID;references:NodeID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
 }
@@ -24,6 +24,14 @@ type Client struct {
 	PppoePassword string         `gorm:"type:varchar(100);column:pppoe_password" json:"pppoe_password"`
 	PppoeProfile  string         `gorm:"type:varchar(100);column:pppoe_profile" json:"pppoe_profile"`
 	RxPower       string         `gorm:"type:varchar(20);column:rx_power" json:"rx_power"`
+	GenieDeviceID string         `gorm:"type:varchar(255);index;column:genie_device_id" json:"genie_device_id"` // _id device GenieACS yang terikat ke pelanggan
+	GenieBindVia  string         `gorm:"type:varchar(20);column:genie_bind_via" json:"genie_bind_via"`          // sn, pppoe, manual
+	GenieBoundAt  *time.Time     `gorm:"column:genie_bound_at" json:"genie_bound_at,omitempty"`
+	OnuStatus     string         `gorm:"type:varchar(20);default:'unknown';column:onu_status" json:"onu_status"` // online, offline, unknown
+	OnuLastInform *time.Time     `gorm:"column:onu_last_inform" json:"onu_last_inform,omitempty"`
+	WifiSSID        string     `gorm:"type:varchar(64);column:wifi_ssid" json:"wifi_ssid"`        // SSID terakhir dari provisioning ONU
+	WifiPasswordEnc string     `gorm:"type:text;column:wifi_password_enc" json:"-"`               // Password WiFi provisioning (AES), lihat lewat endpoint admin
+	WifiProvisionAt *time.Time `gorm:"column:wifi_provision_at" json:"wifi_provision_at,omitempty"`
 	CreatedAt     time.Time      `json:"created_at"`
 	UpdatedAt     time.Time      `json:"updated_at"`
 	DeletedAt     gorm.DeletedAt `gorm:"index;column:deleted_at" json:"deleted_at,omitempty"` // Soft delete
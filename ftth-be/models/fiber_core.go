@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+)
+
+// CableCore adalah satu core fiber di dalam kabel, dengan kode warna tube & core (TIA-598)
+type CableCore struct {
+	CoreID     int    `gorm:"primaryKey;autoIncrement;column:core_id" json:"core_id"`
+	CableID    int    `gorm:"not null;uniqueIndex:idx_cable_cores_number;column:cable_id" json:"cable_id"`
+	CoreNumber int    `gorm:"not null;uniqueIndex:idx_cable_cores_number;column:core_number" json:"core_number"`
+	TubeNumber int    `gorm:"column:tube_number" json:"tube_number"`
+	TubeColor  string `gorm:"type:varchar(20);column:tube_color" json:"tube_color"`
+	CoreColor  string `gorm:"type:varchar(20);column:core_color" json:"core_color"`
+	Status     string `gorm:"type:varchar(20);default:'spare';column:status" json:"status"` // spare, active, broken
+	Note       string `gorm:"type:varchar(255);column:note" json:"note"`
+}
+
+// SpliceRecord adalah sambungan dua core dari kabel berbeda di ODC / joint closure (TB) / ODP
+type SpliceRecord struct {
+	SpliceID  int       `gorm:"primaryKey;autoIncrement;column:splice_id" json:"splice_id"`
+	NodeID    int       `gorm:"not null;index;column:node_id" json:"node_id"` // Closure tempat sambungan
+	CoreAID   int       `gorm:"not null;index;column:core_a_id" json:"core_a_id"`
+	CoreBID   int       `gorm:"not null;index;column:core_b_id" json:"core_b_id"`
+	LossDB    *float64  `gorm:"type:double;column:loss_db" json:"loss_db"` // Hasil ukur OTDR / splicer
+	Tray      string    `gorm:"type:varchar(20);column:tray" json:"tray"`  // Nomor tray di closure
+	Note      string    `gorm:"type:varchar(255);column:note" json:"note"`
+	CreatedBy string    `gorm:"type:varchar(120);column:created_by" json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+
+	CoreA *CableCore `gorm:"foreignKey:CoreAID;references:CoreID" json:"core_a,omitempty"`
+	CoreB *CableCore `gorm:"foreignKey:CoreBID;references:CoreID" json:"core_b,omitempty"`
+}
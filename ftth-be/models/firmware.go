@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+)
+
+// FirmwareFile adalah image firmware yang sudah terdaftar di GenieACS (/files)
+type FirmwareFile struct {
+	FileID       int       `gorm:"primaryKey;autoIncrement;column:file_id" json:"file_id"`
+	FileName     string    `gorm:"type:varchar(255);uniqueIndex;not null;column:file_name" json:"file_name"` // _id file di GenieACS
+	Manufacturer string    `gorm:"type:varchar(100);column:manufacturer" json:"manufacturer"`
+	OUI          string    `gorm:"type:varchar(20);column:oui" json:"oui"`
+	ProductClass string    `gorm:"type:varchar(100);column:product_class" json:"product_class"`
+	Version      string    `gorm:"type:varchar(100);column:version" json:"version"`
+	SizeBytes    int64     `gorm:"column:size_bytes" json:"size_bytes"`
+	UploadedBy   string    `gorm:"type:varchar(120);column:uploaded_by" json:"uploaded_by"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// FirmwareCampaign adalah upgrade firmware massal yang dikirim bertahap per batch
+type FirmwareCampaign struct {
+	CampaignID            int        `gorm:"primaryKey;autoIncrement;column:campaign_id" json:"campaign_id"`
+	Name                  string     `gorm:"type:varchar(150);not null;column:name" json:"name"`
+	FileID                int        `gorm:"not null;index;column:file_id" json:"file_id"`
+	TargetManufacturer    string     `gorm:"type:varchar(100);column:target_manufacturer" json:"target_manufacturer"`
+	TargetProductClass    string     `gorm:"type:varchar(100);column:target_product_class" json:"target_product_class"`
+	TargetFat             string     `gorm:"type:varchar(55);column:target_fat" json:"target_fat"`
+	TargetRouterID        string     `gorm:"type:char(36);column:target_router_id" json:"target_router_id"`
+	BatchSize             int        `gorm:"default:20;column:batch_size" json:"batch_size"`
+	BatchTimeoutMinutes   int        `gorm:"default:60;column:batch_timeout_minutes" json:"batch_timeout_minutes"`                 // Device pending lebih lama dari ini ditandai timeout
+	FaultThresholdPercent float64    `gorm:"type:double;default:10;column:fault_threshold_percent" json:"fault_threshold_percent"` // Kampanye di-pause jika persentase fault melewati batas
+	Status                string     `gorm:"type:varchar(20);index;default:'draft';column:status" json:"status"`                   // draft, running, paused, completed, cancelled
+	PauseReason           string     `gorm:"type:varchar(255);column:pause_reason" json:"pause_reason"`
+	CreatedBy             string     `gorm:"type:varchar(120);column:created_by" json:"created_by"`
+	StartedAt             *time.Time `gorm:"column:started_at" json:"started_at"`
+	FinishedAt            *time.Time `gorm:"column:finished_at" json:"finished_at"`
+	CreatedAt             time.Time  `json:"created_at"`
+	UpdatedAt             time.Time  `json:"updated_at"`
+
+	File *FirmwareFile `gorm:"foreignKey:FileID;references:FileID" json:"file,omitempty"`
+}
+
+// FirmwareCampaignDevice adalah status upgrade satu ONU dalam kampanye
+type FirmwareCampaignDevice struct {
+	ID              int        `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
+	CampaignID      int        `gorm:"not null;index;column:campaign_id" json:"campaign_id"`
+	DeviceID        string     `gorm:"type:varchar(255);index;column:device_id" json:"device_id"`
+	ClientID        *int       `gorm:"column:client_id" json:"client_id,omitempty"`
+	SerialNumber    string     `gorm:"type:varchar(100);column:serial_number" json:"serial_number"`
+	ProductClass    string     `gorm:"type:varchar(100);column:product_class" json:"product_class"`
+	SoftwareVersion string     `gorm:"type:varchar(100);column:software_version" json:"software_version"` // Versi sebelum upgrade
+	TaskID          string     `gorm:"type:varchar(64);column:task_id" json:"task_id"`
+	Status          string     `gorm:"type:varchar(20);index;column:status" json:"status"` // queued, pending, done, fault, timeout, skipped
+	FaultCode       string     `gorm:"type:varchar(100);column:fault_code" json:"fault_code"`
+	FaultMessage    string     `gorm:"type:text;column:fault_message" json:"fault_message"`
+	SentAt          *time.Time `gorm:"column:sent_at" json:"sent_at"`
+	FinishedAt      *time.Time `gorm:"column:finished_at" json:"finished_at"`
+}
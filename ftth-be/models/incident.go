@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+)
+
+// Incident mencatat gangguan jaringan (node rusak / kabel putus) beserta pelanggan yang terdampak
+type Incident struct {
+	IncidentID      int        `gorm:"primaryKey;autoIncrement;column:incident_id" json:"incident_id"`
+	Title           string     `gorm:"type:varchar(150);not null;column:title" json:"title"`
+	Description     string     `gorm:"type:text;column:description" json:"description"`
+	SourceType      string     `gorm:"type:varchar(10);column:source_type" json:"source_type"` // node, cable
+	SourceID        int        `gorm:"column:source_id" json:"source_id"`
+	SourceName      string     `gorm:"type:varchar(150);column:source_name" json:"source_name"`
+	Status          string     `gorm:"type:varchar(20);index;default:'open';column:status" json:"status"` // open, resolved
+	AffectedClients int        `gorm:"column:affected_clients" json:"affected_clients"`
+	AffectedODPs    int        `gorm:"column:affected_odps" json:"affected_odps"`
+	NotifyCustomers bool       `gorm:"column:notify_customers" json:"notify_customers"`
+	NotifiedCount   int        `gorm:"column:notified_count" json:"notified_count"`
+	CreatedBy       string     `gorm:"type:varchar(120);column:created_by" json:"created_by"`
+	ResolvedBy      string     `gorm:"type:varchar(120);column:resolved_by" json:"resolved_by"`
+	ResolvedAt      *time.Time `gorm:"column:resolved_at" json:"resolved_at"`
+	CreatedAt       time.Time  `gorm:"index" json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+
+	Clients []IncidentClient `gorm:"foreignKey:IncidentID;references:IncidentID" json:"clients,omitempty"`
+}
+
+// IncidentClient adalah satu node CLIENT yang terdampak gangguan
+type IncidentClient struct {
+	ID          int    `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
+	IncidentID  int    `gorm:"not null;index;column:incident_id" json:"incident_id"`
+	NodeID      int    `gorm:"column:node_id" json:"node_id"`              // Node CLIENT di peta
+	ClientID    *int   `gorm:"index;column:client_id" json:"client_id"`    // Pelanggan CRM (nil jika node belum terhubung)
+	Name        string `gorm:"type:varchar(100);column:name" json:"name"`  // Disalin saat insiden dibuat
+	Phone       string `gorm:"type:varchar(20);column:phone" json:"phone"` // Disalin saat insiden dibuat
+	ODPNodeID   int    `gorm:"column:odp_node_id" json:"odp_node_id"`      // 0 jika ODP tidak ditemukan di graf
+	ODPName     string `gorm:"type:varchar(100);column:odp_name" json:"odp_name"`
+	Notified    bool   `gorm:"column:notified" json:"notified"`
+	NotifyError string `gorm:"type:varchar(255);column:notify_error" json:"notify_error"`
+}
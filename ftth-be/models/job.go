@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+)
+
+// ScheduledJob menyimpan jadwal cron setiap job agar bisa diubah tanpa deploy ulang
+type ScheduledJob struct {
+	JobName     string    `gorm:"type:varchar(50);primaryKey;column:job_name" json:"job_name"`
+	Description string    `gorm:"type:varchar(255);column:description" json:"description"`
+	Schedule    string    `gorm:"type:varchar(100);not null;column:schedule" json:"schedule"` // Format cron / descriptor (@hourly, @every 30m)
+	Enabled     bool      `gorm:"default:true;column:enabled" json:"enabled"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// JobRun adalah riwayat satu kali eksekusi job
+type JobRun struct {
+	RunID          int        `gorm:"primaryKey;autoIncrement;column:run_id" json:"run_id"`
+	JobName        string     `gorm:"type:varchar(50);index;not null;column:job_name" json:"job_name"`
+	TriggeredBy    string     `gorm:"type:varchar(20);column:triggered_by" json:"triggered_by"` // cron, manual
+	Executor       string     `gorm:"type:varchar(120);column:executor" json:"executor"`
+	Status         string     `gorm:"type:varchar(20);index;column:status" json:"status"` // running, success, failed, interrupted
+	ErrorMessage   string     `gorm:"type:text;column:error_message" json:"error_message"`
+	ItemsProcessed int        `gorm:"default:0;column:items_processed" json:"items_processed"`
+	StartedAt      time.Time  `gorm:"index;column:started_at" json:"started_at"`
+	FinishedAt     *time.Time `gorm:"column:finished_at" json:"finished_at"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+)
+
+// LanHostSnapshot adalah ringkasan jumlah perangkat yang terhubung ke ONU pelanggan pada satu waktu
+type LanHostSnapshot struct {
+	SnapshotID int       `gorm:"primaryKey;autoIncrement;column:snapshot_id" json:"snapshot_id"`
+	ClientID   int       `gorm:"not null;index:idx_lan_host_snapshots_client_time;column:client_id" json:"client_id"`
+	DeviceID   string    `gorm:"type:varchar(255);column:device_id" json:"device_id"` // _id device di GenieACS
+	TotalHosts int       `gorm:"column:total_hosts" json:"total_hosts"`
+	WifiHosts  int       `gorm:"column:wifi_hosts" json:"wifi_hosts"`
+	LanHosts   int       `gorm:"column:lan_hosts" json:"lan_hosts"`
+	RecordedAt time.Time `gorm:"index:idx_lan_host_snapshots_client_time;index;column:recorded_at;autoCreateTime" json:"recorded_at"`
+
+	Hosts []LanHostEntry `gorm:"foreignKey:SnapshotID;references:SnapshotID" json:"hosts,omitempty"`
+}
+
+// LanHostEntry adalah satu perangkat (HP, laptop, TV, ...) dalam snapshot
+type LanHostEntry struct {
+	EntryID         int    `gorm:"primaryKey;autoIncrement;column:entry_id" json:"entry_id"`
+	SnapshotID      int    `gorm:"not null;index;column:snapshot_id" json:"snapshot_id"`
+	ClientID        int    `gorm:"not null;index;column:client_id" json:"client_id"`
+	MACAddress      string `gorm:"type:varchar(20);index;column:mac_address" json:"mac_address"`
+	IPAddress       string `gorm:"type:varchar(50);column:ip_address" json:"ip_address"`
+	HostName        string `gorm:"type:varchar(255);column:host_name" json:"host_name"`
+	Interface       string `gorm:"type:varchar(10);column:interface" json:"interface"` // wifi, lan, unknown
+	Layer1Interface string `gorm:"type:varchar(255);column:layer1_interface" json:"layer1_interface"`
+}
@@ -49,8 +49,13 @@ type NetworkCable struct {
 	TargetNodeID int     `json:"target_node_id"`
 	CableType    string  `json:"cable_type"`
 	Description  string  `json:"description"`
-	LengthMeter  float64 `json:"length_meter"`
+	LengthMeter  float64 `json:"length_meter"` // Panjang efektif: manual jika diisi, selain itu hasil hitung dari jalur
 	Coordinates  string  `gorm:"type:text" json:"coordinates"`
+	CoreCount    int     `gorm:"default:0" json:"core_count"`          // Jumlah core fiber (12, 24, 48, ...), 0 = belum didata
+	OltPonPort   string  `gorm:"type:varchar(30)" json:"olt_pon_port"` // Port PON di sisi OLT (mis. 1/1/3), hanya untuk kabel yang menempel ke OLT
+
+	ManualLengthMeter   *float64 `gorm:"type:double" json:"manual_length_meter"` // Hasil ukur lapangan (meteran kabel / OTDR)
+	ComputedLengthMeter float64  `gorm:"default:0" json:"computed_length_meter"` // Haversine jalur + CABLE_SLACK_PERCENT
 
 	SourceNode NetworkNode `gorm:"foreignKey:SourceNodeID;references:NodeID" json:"source_node"`
 	TargetNode NetworkNode `gorm:"foreignKey:TargetNodeID;references:NodeID" json:"target_node"`
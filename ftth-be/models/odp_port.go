@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// ODPPort adalah satu port output splitter di ODP beserta pelanggan / kabel drop yang memakainya
+type ODPPort struct {
+	PortID       int       `gorm:"primaryKey;autoIncrement;column:port_id" json:"port_id"`
+	ODPNodeID    int       `gorm:"not null;uniqueIndex:idx_odp_ports_number;column:odp_node_id" json:"odp_node_id"`
+	PortNumber   int       `gorm:"not null;uniqueIndex:idx_odp_ports_number;column:port_number" json:"port_number"`
+	SplitterType string    `gorm:"type:varchar(10);column:splitter_type" json:"splitter_type"`        // 1:8, 1:16
+	Status       string    `gorm:"type:varchar(20);default:'free';index;column:status" json:"status"` // free, used, faulty, reserved
+	ClientID     *int      `gorm:"index;column:client_id" json:"client_id"`
+	ClientNodeID *int      `gorm:"column:client_node_id" json:"client_node_id"` // Node CLIENT di peta
+	CableID      *int      `gorm:"column:cable_id" json:"cable_id"`             // Kabel drop dari ODP ke rumah pelanggan
+	Note         string    `gorm:"type:varchar(255);column:note" json:"note"`
+	UpdatedBy    string    `gorm:"type:varchar(120);column:updated_by" json:"updated_by"`
+	UpdatedAt    time.Time `json:"updated_at"`
+
+	Client *Client `gorm:"foreignKey:ClientID;references:ClientID" json:"client,omitempty"`
+}
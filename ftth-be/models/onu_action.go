@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+)
+
+// OnuAction mencatat aksi remote ke ONU (reboot, factory reset, refresh, connection request)
+// beserta task GenieACS yang dibuat untuk aksi tersebut
+type OnuAction struct {
+	ActionID     int       `gorm:"primaryKey;autoIncrement;column:action_id" json:"action_id"`
+	ClientID     int       `gorm:"index;not null;column:client_id" json:"client_id"`
+	DeviceID     string    `gorm:"type:varchar(255);index;column:device_id" json:"device_id"` // _id device di GenieACS
+	Action       string    `gorm:"type:varchar(30);column:action" json:"action"`              // reboot, factory_reset, refresh, connection_request, provision, set_parameter
+	TaskID       string    `gorm:"type:varchar(64);column:task_id" json:"task_id"`
+	Status       string    `gorm:"type:varchar(20);index;column:status" json:"status"` // pending, done, fault
+	FaultCode    string    `gorm:"type:varchar(100);column:fault_code" json:"fault_code"`
+	FaultMessage string    `gorm:"type:text;column:fault_message" json:"fault_message"`
+	Executor     string    `gorm:"type:varchar(120);column:executor" json:"executor"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+
+	Client *Client `gorm:"foreignKey:ClientID;references:ClientID" json:"client,omitempty"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+)
+
+// OnuStatusEvent mencatat perubahan status online/offline ONU pelanggan berdasarkan _lastInform GenieACS
+type OnuStatusEvent struct {
+	EventID    int        `gorm:"primaryKey;autoIncrement;column:event_id" json:"event_id"`
+	ClientID   int        `gorm:"index;not null;column:client_id" json:"client_id"`
+	DeviceID   string     `gorm:"type:varchar(255);index;column:device_id" json:"device_id"` // _id device di GenieACS
+	Status     string     `gorm:"type:varchar(20);column:status" json:"status"`              // online, offline
+	LastInform *time.Time `gorm:"column:last_inform" json:"last_inform"`
+	CreatedAt  time.Time  `gorm:"index" json:"created_at"`
+
+	Client *Client `gorm:"foreignKey:ClientID;references:ClientID" json:"client,omitempty"`
+}
+
+// OnuOutageAlert adalah gangguan massal (OLT/ODC/ODP) yang alert-nya sudah dikirim.
+// Disimpan di DB agar alert tidak terkirim ulang setiap kali aplikasi restart.
+type OnuOutageAlert struct {
+	AlertKey  string    `gorm:"primaryKey;type:varchar(50);column:alert_key" json:"alert_key"` // LEVEL:node_id
+	Label     string    `gorm:"type:varchar(255);column:label" json:"label"`                   // Dipakai untuk notifikasi pulih
+	CreatedAt time.Time `json:"created_at"`
+}
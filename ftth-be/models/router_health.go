@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RouterHealth menyimpan satu sampel kesehatan router (dari /system/resource & /system/health)
+type RouterHealth struct {
+	HealthID    int       `gorm:"primaryKey;autoIncrement;column:health_id" json:"health_id"`
+	RouterID    uuid.UUID `gorm:"type:char(36);not null;index;column:router_id" json:"router_id"`
+	CPULoad     int       `gorm:"column:cpu_load" json:"cpu_load"`                     // Persen (0-100)
+	FreeMemory  int64     `gorm:"column:free_memory" json:"free_memory"`               // Byte
+	TotalMemory int64     `gorm:"column:total_memory" json:"total_memory"`             // Byte
+	FreeHDD     int64     `gorm:"column:free_hdd" json:"free_hdd"`                     // Byte
+	TotalHDD    int64     `gorm:"column:total_hdd" json:"total_hdd"`                   // Byte
+	Temperature float64   `gorm:"type:double;column:temperature" json:"temperature"`   // Celcius (0 = tidak didukung board)
+	Voltage     float64   `gorm:"type:double;column:voltage" json:"voltage"`           // Volt (0 = tidak didukung board)
+	PSUState    string    `gorm:"type:varchar(100);column:psu_state" json:"psu_state"` // contoh: "psu1=ok,psu2=fail"
+	Uptime      string    `gorm:"type:varchar(50);column:uptime" json:"uptime"`
+	RecordedAt  time.Time `gorm:"index;column:recorded_at;autoCreateTime" json:"recorded_at"`
+
+	Router *Router `gorm:"foreignKey:RouterID;references:RouterID" json:"router,omitempty"`
+}
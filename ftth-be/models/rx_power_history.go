@@ -7,6 +7,7 @@ import (
 type RxPowerHistory struct {
 	HistoryID  int       `gorm:"primaryKey;autoIncrement;column:history_id" json:"history_id"`
 	DeviceSN   string    `gorm:"type:varchar(100);index;column:device_sn" json:"device_sn"`
+	DeviceID   string    `gorm:"type:varchar(255);index;column:device_id" json:"device_id"` // _id device GenieACS
 	RxPower    float64   `gorm:"type:double;column:rx_power" json:"rx_power"`
 	RecordedAt time.Time `gorm:"column:recorded_at;autoCreateTime" json:"recorded_at"`
 }
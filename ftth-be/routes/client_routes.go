@@ -21,4 +21,13 @@ func ClientRoutes(app *fiber.App) {
 	api.Delete("/clients/:id", middleware.RoleAdmin(), controllers.DeleteClient)
 	api.Post("/clients/:id/restore", middleware.RoleAdmin(), controllers.RestoreClient)
 	api.Post("/clients/:id/sync-mikrotik", middleware.RoleAdmin(), controllers.SyncClientToMikrotik)
+
+	// Aksi remote ONU via GenieACS
+	api.Get("/clients/:id/onu/actions", middleware.RoleAdminOrTeknisi(), controllers.GetOnuActions)
+	api.Get("/clients/:id/onu/actions/:action_id", middleware.RoleAdminOrTeknisi(), controllers.GetOnuAction)
+	api.Post("/clients/:id/onu/actions", middleware.RoleAdminOrTeknisi(), controllers.CreateOnuAction)
+	api.Get("/clients/:id/onu/wifi-credentials", middleware.RoleAdmin(), controllers.GetClientWifiCredentials)
+	api.Get("/clients/:id/onu/status-history", middleware.RoleAdminOrTeknisi(), controllers.GetOnuStatusHistory)
+	api.Get("/clients/:id/onu/hosts/history", middleware.RoleAdminOrTeknisi(), controllers.GetClientLanHostHistory)
+	api.Get("/clients/:id/onu/hosts/snapshot", middleware.RoleAdminOrTeknisi(), controllers.GetClientLanHostSnapshot)
 }
@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"akane/be-ftth/controllers"
+	"akane/be-ftth/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// FirmwareUploadPath dikecualikan dari batas body global; image firmware ONU bisa puluhan MB
+const FirmwareUploadPath = "/api/firmware/files"
+
+const firmwareUploadLimit = 64 * 1024 * 1024
+
+func FirmwareRoutes(app *fiber.App) {
+	api := app.Group("/api/firmware", middleware.JWTProtected())
+
+	api.Get("/files", middleware.RoleAdminOrTeknisi(), controllers.GetFirmwareFiles)
+	api.Post("/files", middleware.BodyLimit(firmwareUploadLimit), middleware.RoleAdmin(), controllers.RegisterFirmwareFile)
+
+	api.Get("/campaigns", middleware.RoleAdminOrTeknisi(), controllers.GetFirmwareCampaigns)
+	api.Post("/campaigns", middleware.RoleAdmin(), controllers.CreateFirmwareCampaign)
+	api.Get("/campaigns/:id", middleware.RoleAdminOrTeknisi(), controllers.GetFirmwareCampaign)
+	api.Get("/campaigns/:id/devices", middleware.RoleAdminOrTeknisi(), controllers.GetFirmwareCampaignDevices)
+	api.Post("/campaigns/:id/start", middleware.RoleAdmin(), controllers.StartFirmwareCampaign)
+	api.Post("/campaigns/:id/pause", middleware.RoleAdmin(), controllers.PauseFirmwareCampaign)
+	api.Post("/campaigns/:id/cancel", middleware.RoleAdmin(), controllers.CancelFirmwareCampaign)
+}
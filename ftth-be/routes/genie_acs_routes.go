@@ -14,5 +14,18 @@ func GenieACSRoutes(app *fiber.App) {
 	api.Get("/device", middleware.RoleAdminOrTeknisi(), controllers.GetDeviceACSInfo)
 	api.Get("/hosts", middleware.RoleAdminOrTeknisi(), controllers.GetDeviceHostsACS)
 	api.Get("/rx-history", middleware.RoleAdminOrTeknisi(), controllers.GetDeviceRxHistory)
+	api.Get("/rx-analysis", middleware.RoleAdminOrTeknisi(), controllers.GetRxPowerAnalysis)
+	api.Get("/outages", middleware.RoleAdminOrTeknisi(), controllers.GetOnuOutages)
 	api.Post("/wifi", middleware.RoleAdmin(), controllers.UpdateDeviceACSWifi)
+	api.Get("/wifi", middleware.RoleAdminOrTeknisi(), controllers.GetDeviceWlans)
+	api.Get("/wifi/profiles", middleware.RoleAdmin(), controllers.GetWifiProfiles)
+	api.Put("/wifi/:index", middleware.RoleAdmin(), controllers.UpdateDeviceWlan)
+
+	api.Get("/parameters", middleware.RoleAdmin(), controllers.GetDeviceParameters)
+	api.Get("/parameters/allowlist", middleware.RoleAdmin(), controllers.GetParameterAllowlist)
+	api.Post("/parameters", middleware.RoleAdmin(), controllers.SetDeviceParameters)
+
+	api.Get("/bindings/review", middleware.RoleAdminOrTeknisi(), controllers.GetGenieBindingReview)
+	api.Post("/bindings", middleware.RoleAdmin(), controllers.BindGenieDevice)
+	api.Delete("/bindings/:client_id", middleware.RoleAdmin(), controllers.UnbindGenieDevice)
 }
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"akane/be-ftth/controllers"
+	"akane/be-ftth/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func IncidentRoutes(app *fiber.App) {
+	api := app.Group("/api/incidents", middleware.JWTProtected())
+
+	api.Get("/", middleware.RoleAdminOrTeknisi(), controllers.GetIncidents)
+	api.Post("/", middleware.RoleAdminOrTeknisi(), controllers.CreateIncident)
+	api.Get("/:id", middleware.RoleAdminOrTeknisi(), controllers.GetIncident)
+	api.Post("/:id/resolve", middleware.RoleAdminOrTeknisi(), controllers.ResolveIncident)
+}
@@ -13,6 +13,7 @@ func InterfaceTrafficRoutes(app *fiber.App) {
 	api.Get("/traffic", middleware.JWTProtected(), middleware.RoleAdminOrTeknisi(), controllers.GetInterfacesTraffic)
 	api.Post("/traffic/add", middleware.JWTProtected(), middleware.RoleAdmin(), controllers.CreateInterfaceTraffic)
 	api.Get("/traffic/interface/:interface_id", middleware.JWTProtected(), middleware.RoleAdminOrTeknisi(), controllers.GetTrafficForInterface)
+	api.Get("/traffic/interface/:interface_id/stream", middleware.JWTProtected(), middleware.RoleAdminOrTeknisi(), controllers.StreamTrafficForInterface)
 	api.Get("/traffic/:id", middleware.JWTProtected(), middleware.RoleAdminOrTeknisi(), controllers.GetInterfaceTraffic)
 	api.Put("/traffic/:id", middleware.JWTProtected(), middleware.RoleAdmin(), controllers.UpdateInterfaceTraffic)
 	api.Delete("/traffic/:id", middleware.JWTProtected(), middleware.RoleAdmin(), controllers.DeleteInterfaceTraffic)
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"akane/be-ftth/controllers"
+	"akane/be-ftth/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func JobRoutes(app *fiber.App) {
+	api := app.Group("/api", middleware.JWTProtected())
+
+	api.Get("/jobs", middleware.RoleAdminOrTeknisi(), controllers.GetJobs)
+	api.Get("/jobs/:name/runs", middleware.RoleAdminOrTeknisi(), controllers.GetJobRuns)
+	api.Put("/jobs/:name", middleware.RoleAdmin(), controllers.UpdateJob)
+	api.Post("/jobs/:name/run", middleware.RoleAdmin(), controllers.RunJobNow)
+}
@@ -13,6 +13,10 @@ func MappingRoutes(app *fiber.App) {
 	// Get Router ID mapped to ODP Name
 	api.Get("/mappings/by-odp-name/:name", middleware.JWTProtected(), middleware.RoleAdminOrTeknisi(), controllers.GetRouterByODPName)
 
+	// Pemetaan hasil penelusuran kabel (review & approve)
+	api.Get("/mappings/derived", middleware.JWTProtected(), middleware.RoleAdminOrTeknisi(), controllers.GetDerivedMappings)
+	api.Post("/mappings/derived/apply", middleware.JWTProtected(), middleware.RoleAdmin(), controllers.ApplyDerivedMappings)
+
 	// CRUD Mappings
 	api.Get("/mappings", middleware.JWTProtected(), middleware.RoleAdminOrTeknisi(), controllers.GetTopologyMappings)
 	api.Get("/mappings/:id", middleware.JWTProtected(), middleware.RoleAdminOrTeknisi(), controllers.GetTopologyMapping)
@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"akane/be-ftth/controllers"
+	"akane/be-ftth/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func MetricsRoutes(app *fiber.App) {
+	// Endpoint scrape Prometheus (di luar /api agar cocok dengan konfigurasi default scraper)
+	app.Get("/metrics", middleware.MetricsToken(), controllers.GetPrometheusMetrics)
+}
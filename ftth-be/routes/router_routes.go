@@ -19,4 +19,9 @@ func RouterRoutes(app *fiber.App) {
 	api.Post("/routers/test-pppoe", middleware.JWTProtected(), middleware.RoleAdmin(), controllers.TestPPPoEConnection)
 	api.Post("/routers/check-pppoe", middleware.JWTProtected(), middleware.RoleAdmin(), controllers.CheckPPPoEExists)
 	api.Get("/routers/:id/ppp-profiles", middleware.JWTProtected(), middleware.RoleAdminOrTeknisi(), controllers.GetRouterPPPProfiles)
+
+	// HEALTH (CPU, Memory, Suhu, Voltase)
+	api.Get("/routers/health/latest", middleware.JWTProtected(), middleware.RoleAdminOrTeknisi(), controllers.GetRoutersHealthLatest)
+	api.Post("/routers/health/sync-now", middleware.JWTProtected(), middleware.RoleAdmin(), controllers.ManualRouterHealthSync)
+	api.Get("/routers/:id/health", middleware.JWTProtected(), middleware.RoleAdminOrTeknisi(), controllers.GetRouterHealthHistory)
 }
@@ -16,6 +16,14 @@ func TopologyRoutes(app *fiber.App) {
 	// GET Topology Table (untuk halaman tabel & ekspor Excel)
 	api.Get("/topology/table", middleware.JWTProtected(), middleware.RoleAdminOrTeknisi(), controllers.GetTopologyTable)
 
+	// IMPOR / EKSPOR PETA (KML, KMZ, GeoJSON)
+	api.Post("/topology/import", middleware.JWTProtected(), middleware.RoleAdmin(), controllers.ImportTopologyFile)
+	api.Get("/topology/export/geojson", middleware.JWTProtected(), middleware.RoleAdminOrTeknisi(), controllers.ExportTopologyGeoJSON)
+	api.Get("/topology/export/kml", middleware.JWTProtected(), middleware.RoleAdminOrTeknisi(), controllers.ExportTopologyKML)
+
+	// VALIDASI TOPOLOGI (kabel tidak sah, siklus, ODP penuh, node yatim)
+	api.Get("/topology/validate", middleware.JWTProtected(), middleware.RoleAdminOrTeknisi(), controllers.GetTopologyValidation)
+
 	// SEARCH NODES
 	api.Get("/nodes/search", middleware.JWTProtected(), middleware.RoleAdminOrTeknisi(), controllers.SearchNetworkNodes)
 
@@ -25,12 +33,43 @@ func TopologyRoutes(app *fiber.App) {
 	api.Delete("/nodes/:id", middleware.JWTProtected(), middleware.RoleAdmin(), controllers.DeleteNetworkNode)
 	api.Put("/nodes/:id/details", middleware.JWTProtected(), middleware.RoleAdmin(), controllers.UpdateNodeDetails)
 
+	// TRACE JALUR NODE KE OLT/ROUTER
+	api.Get("/nodes/:id/trace", middleware.JWTProtected(), middleware.RoleAdminOrTeknisi(), controllers.TraceNetworkNode)
+
+	// ANALISIS DAMPAK GANGGUAN (node rusak / kabel putus)
+	api.Get("/nodes/:id/impact", middleware.JWTProtected(), middleware.RoleAdminOrTeknisi(), controllers.GetNodeImpact)
+	api.Get("/cables/:id/impact", middleware.JWTProtected(), middleware.RoleAdminOrTeknisi(), controllers.GetCableImpact)
+
+	// LOSS BUDGET OPTIK (perkiraan RX vs RX terukur)
+	api.Get("/power-budget", middleware.JWTProtected(), middleware.RoleAdminOrTeknisi(), controllers.GetPowerBudget)
+	api.Get("/power-budget/config", middleware.JWTProtected(), middleware.RoleAdminOrTeknisi(), controllers.GetPowerBudgetConfig)
+	api.Get("/nodes/:id/power-budget", middleware.JWTProtected(), middleware.RoleAdminOrTeknisi(), controllers.GetNodePowerBudget)
+
+	// PORT ODP
+	api.Get("/nodes/:id/ports", middleware.JWTProtected(), middleware.RoleAdminOrTeknisi(), controllers.GetODPPorts)
+	api.Post("/nodes/:id/ports/init", middleware.JWTProtected(), middleware.RoleAdminOrTeknisi(), controllers.InitODPPorts)
+	api.Post("/nodes/:id/ports/assign", middleware.JWTProtected(), middleware.RoleAdminOrTeknisi(), controllers.AssignODPPort)
+	api.Put("/nodes/:id/ports/:port", middleware.JWTProtected(), middleware.RoleAdminOrTeknisi(), controllers.UpdateODPPortStatus)
+
+	// CARI ODP TERDEKAT DENGAN PORT KOSONG (calon pelanggan)
+	api.Get("/odp/nearest", middleware.JWTProtected(), middleware.RoleAdminOrTeknisi(), controllers.FindNearestODP)
+
 	// MANAGE CABLES
 	api.Post("/cables", middleware.JWTProtected(), middleware.RoleAdmin(), controllers.AddNetworkCable)
+	api.Post("/cables/recompute-length", middleware.JWTProtected(), middleware.RoleAdmin(), controllers.RecomputeCableLengths)
 	api.Put("/cables/:id/path", middleware.JWTProtected(), middleware.RoleAdmin(), controllers.UpdateCablePath)
 	api.Put("/cables/:id", middleware.JWTProtected(), middleware.RoleAdmin(), controllers.UpdateCableDetails)
 	api.Delete("/cables/:id", middleware.JWTProtected(), middleware.RoleAdmin(), controllers.DeleteNetworkCable)
 
+	// CORE FIBER & SAMBUNGAN (splice)
+	api.Get("/cables/:id/cores", middleware.JWTProtected(), middleware.RoleAdminOrTeknisi(), controllers.GetCableCores)
+	api.Post("/cables/:id/cores", middleware.JWTProtected(), middleware.RoleAdminOrTeknisi(), controllers.SetCableCores)
+	api.Put("/cores/:id", middleware.JWTProtected(), middleware.RoleAdminOrTeknisi(), controllers.UpdateCableCore)
+	api.Get("/cores/:id/trace", middleware.JWTProtected(), middleware.RoleAdminOrTeknisi(), controllers.TraceCableCore)
+	api.Get("/nodes/:id/splices", middleware.JWTProtected(), middleware.RoleAdminOrTeknisi(), controllers.GetNodeSplices)
+	api.Post("/nodes/:id/splices", middleware.JWTProtected(), middleware.RoleAdminOrTeknisi(), controllers.CreateSplice)
+	api.Delete("/splices/:id", middleware.JWTProtected(), middleware.RoleAdminOrTeknisi(), controllers.DeleteSplice)
+
 	// BATCH ISOLIR TOOLS
 	api.Post("/tools/isolir/upload", middleware.JWTProtected(), middleware.RoleAdmin(), controllers.ToolIsolirUpload)
 	api.Post("/tools/isolir/process", middleware.JWTProtected(), middleware.RoleAdmin(), controllers.ToolIsolirProcess)